@@ -3,37 +3,77 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/lnfu/dcard-intern/app/config"
+	"github.com/lnfu/dcard-intern/app/metrics"
 	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/tracing"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var ctx = context.Background()
+// 所有 GetActiveAdvertisements 結果的 key 都以此開頭, 方便一次清除
+const advertisementsKeyPrefix = "ads|"
+
+// 沒有快取 (或已過期)
+var ErrCacheMiss = errors.New("cache miss")
 
 type Cache struct {
 	redisClient *redis.Client
+	ttl         time.Duration
 }
 
-func NewCache(addr string, password string, db int) *Cache {
+// 連接 Redis, ctx 只用於第一次的 ping
+func NewCache(ctx context.Context, conf config.Redis, ttl time.Duration) (*Cache, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Addr:         conf.Addr,
+		Password:     conf.Password,
+		DB:           conf.DB,
+		PoolSize:     conf.PoolSize,
+		DialTimeout:  conf.DialTimeout,
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		// 使用 context 的 deadline (handler 的 redis.command_timeout) 作為 socket 的 timeout
+		ContextTimeoutEnabled: true,
 	})
 	pong, err := client.Ping(ctx).Result()
 	if err != nil {
-		log.Fatalf("Redis: 無法連接 (%v)\n", err)
+		client.Close()
+		return nil, fmt.Errorf("redis ping %s: %w", conf.Addr, err)
 	}
-	log.Printf("Redis: %v\n", pong)
+	slog.Info("redis connected", "addr", conf.Addr, "reply", pong)
+
+	return &Cache{client, ttl}, nil
+}
+
+// readiness check
+func (cache *Cache) Ping(ctx context.Context) error {
+	return cache.redisClient.Ping(ctx).Err()
+}
 
-	return &Cache{client}
+// 關閉 Redis 的連線 (shutdown 時呼叫)
+func (cache *Cache) Close() error {
+	return cache.redisClient.Close()
 }
 
-func generateGetAdvertisementsCacheKey(params sqlc.GetActiveAdvertisementsParams) string {
+// Redis 操作的 span (名稱是 Cache 的方法名稱)
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "cache."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis),
+	)
+}
+
+// locale 是 resolve 之後的語言 ("" 表示預設內容)
+func generateGetAdvertisementsCacheKey(params sqlc.GetActiveAdvertisementsParams, locale string) string {
 	components := make([]string, 0)
 	if params.Age.Valid {
 		components = append(components, fmt.Sprintf("age:%d", params.Age.Int32))
@@ -47,36 +87,114 @@ func generateGetAdvertisementsCacheKey(params sqlc.GetActiveAdvertisementsParams
 	if params.Platform.Valid {
 		components = append(components, fmt.Sprintf("platform:%s", params.Platform.String))
 	}
+	if params.Region.Valid {
+		components = append(components, fmt.Sprintf("region:%s", params.Region.String))
+	}
+	// 只放命中的 geofence ids (不放 lat/lng), key 的數量不會隨位置增加
+	if len(params.GeofenceIds) > 0 {
+		ids := make([]string, len(params.GeofenceIds))
+		for i, id := range params.GeofenceIds {
+			ids[i] = strconv.Itoa(int(id))
+		}
+		components = append(components, fmt.Sprintf("geo:%s", strings.Join(ids, ",")))
+	}
+	// segments 已經排序並去除重複
+	if len(params.Segments) > 0 {
+		components = append(components, fmt.Sprintf("segments:%s", strings.Join(params.Segments, ",")))
+	}
+	// keywords 已經正規化並排序
+	if len(params.Keywords) > 0 {
+		components = append(components, fmt.Sprintf("keywords:%s", strings.Join(params.Keywords, ",")))
+	}
+	if params.Category.Valid {
+		components = append(components, fmt.Sprintf("category:%s", params.Category.String))
+	}
+	if locale != "" {
+		components = append(components, fmt.Sprintf("lang:%s", locale))
+	}
 	components = append(components,
 		fmt.Sprintf("offset:%d", params.Offset),
 		fmt.Sprintf("limit:%d", params.Limit),
 	)
-	return strings.Join(components, "|")
+	return advertisementsKeyPrefix + strings.Join(components, "|")
 }
 
-func (cache *Cache) GetAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
-	key := generateGetAdvertisementsCacheKey(params)
+// 取出 params 對應的快取結果並 decode 到 ads (用法同 json.Unmarshal), 沒有快取時回傳 ErrCacheMiss
+func (cache *Cache) GetAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	ctx, span := startSpan(ctx, "GetAdvertisementsFromCache")
+	defer span.End()
+
+	key := generateGetAdvertisementsCacheKey(params, locale)
 	val, err := cache.redisClient.Get(ctx, key).Result()
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if err == redis.Nil {
+		metrics.ObserveCache("get", metrics.CacheMiss)
+		return ErrCacheMiss
+	}
 	if err != nil {
-		return nil, err
+		metrics.ObserveCache("get", metrics.CacheError)
+		tracing.RecordError(span, err)
+		return err
 	}
+	metrics.ObserveCache("get", metrics.CacheHit)
 
-	var ads []sqlc.Advertisement
-	if err := json.Unmarshal([]byte(val), &ads); err != nil {
-		return nil, err
-	}
-	return ads, nil
+	err = json.Unmarshal([]byte(val), ads)
+	tracing.RecordError(span, err)
+	return err
 }
 
-func (cache *Cache) SetAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, ads []sqlc.Advertisement) error {
+func (cache *Cache) SetAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	ctx, span := startSpan(ctx, "SetAdvertisementsToCache")
+	defer span.End()
+
 	jsonData, err := json.Marshal(ads)
 	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	key := generateGetAdvertisementsCacheKey(params, locale)
+	err = cache.redisClient.Set(ctx, key, jsonData, cache.ttl).Err()
+	if err != nil {
+		metrics.ObserveCache("set", metrics.CacheError)
+		tracing.RecordError(span, err)
 		return err
 	}
-	key := generateGetAdvertisementsCacheKey(params)
-	err = cache.redisClient.Set(ctx, key, jsonData, time.Minute*5).Err()
+	metrics.ObserveCache("set", metrics.CacheOK)
+	return nil
+}
+
+// 清除所有快取的 advertisements (廣告狀態改變時呼叫, 避免繼續投放已暫停/封存的廣告)
+func (cache *Cache) InvalidateAdvertisements(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "InvalidateAdvertisements")
+	defer span.End()
+
+	err := cache.invalidateAdvertisements(ctx)
 	if err != nil {
+		metrics.ObserveCache("invalidate", metrics.CacheError)
+		tracing.RecordError(span, err)
+		return err
+	}
+	metrics.ObserveCache("invalidate", metrics.CacheOK)
+	return nil
+}
+
+func (cache *Cache) invalidateAdvertisements(ctx context.Context) error {
+	iter := cache.redisClient.Scan(ctx, 0, advertisementsKeyPrefix+"*", 1000).Iterator()
+	keys := make([]string, 0, 1000)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			if err := cache.redisClient.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
 		return err
 	}
+	if len(keys) > 0 {
+		return cache.redisClient.Unlink(ctx, keys...).Err()
+	}
 	return nil
 }
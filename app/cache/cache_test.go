@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lnfu/dcard-intern/app/config"
+)
+
+func TestNewCache_pingError(t *testing.T) {
+	// 沒有 listen 的 port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewCache(context.Background(), config.Redis{Addr: addr, DialTimeout: time.Second}, time.Minute)
+	if err == nil {
+		t.Fatal("expected error, but got nil")
+	}
+	if !strings.HasPrefix(err.Error(), "redis ping "+addr+": ") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("expected the dial error to be wrapped, got: %v", err)
+	}
+}
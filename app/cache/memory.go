@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// 不需要 Redis 的快取 (-mode test 與測試使用), key 與 TTL 的行為與 Cache 相同
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), ttl: ttl, now: time.Now}
+}
+
+func (cache *Memory) GetAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	key := generateGetAdvertisementsCacheKey(params, locale)
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	if ok && !cache.now().Before(entry.expiresAt) {
+		delete(cache.entries, key)
+		ok = false
+	}
+	cache.mu.Unlock()
+
+	if !ok {
+		return ErrCacheMiss
+	}
+	return json.Unmarshal(entry.value, ads)
+}
+
+func (cache *Memory) SetAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	jsonData, err := json.Marshal(ads)
+	if err != nil {
+		return err
+	}
+	key := generateGetAdvertisementsCacheKey(params, locale)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.entries[key] = memoryEntry{jsonData, cache.now().Add(cache.ttl)}
+	return nil
+}
+
+func (cache *Memory) InvalidateAdvertisements(ctx context.Context) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for key := range cache.entries {
+		if strings.HasPrefix(key, advertisementsKeyPrefix) {
+			delete(cache.entries, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewMemory(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	params := sqlc.GetActiveAdvertisementsParams{Limit: 5}
+	var ads []string
+
+	if err := cache.GetAdvertisementsFromCache(ctx, params, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	if err := cache.SetAdvertisementsToCache(ctx, params, "", []string{"AD 1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.GetAdvertisementsFromCache(ctx, params, "", &ads); err != nil || len(ads) != 1 || ads[0] != "AD 1" {
+		t.Fatalf("expected: [AD 1], got: %v (%v)", ads, err)
+	}
+
+	// locale 是 key 的一部分
+	if err := cache.GetAdvertisementsFromCache(ctx, params, "zh-TW", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	// 命中的 geofences 是 key 的一部分
+	if err := cache.GetAdvertisementsFromCache(ctx, sqlc.GetActiveAdvertisementsParams{Limit: 5, GeofenceIds: []int32{1, 2}}, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	// segments 是 key 的一部分
+	if err := cache.GetAdvertisementsFromCache(ctx, sqlc.GetActiveAdvertisementsParams{Limit: 5, Segments: []string{"gamer"}}, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	// keywords 與 category 是 key 的一部分
+	if err := cache.GetAdvertisementsFromCache(ctx, sqlc.GetActiveAdvertisementsParams{Limit: 5, Keywords: []string{"switch"}}, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+	if err := cache.GetAdvertisementsFromCache(ctx, sqlc.GetActiveAdvertisementsParams{Limit: 5, Category: sql.NullString{String: "game", Valid: true}}, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	// expired
+	now = now.Add(time.Minute)
+	if err := cache.GetAdvertisementsFromCache(ctx, params, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+
+	// invalidate
+	if err := cache.SetAdvertisementsToCache(ctx, params, "", []string{"AD 1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.InvalidateAdvertisements(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.GetAdvertisementsFromCache(ctx, params, "", &ads); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected error: %v, got: %v", ErrCacheMiss, err)
+	}
+}
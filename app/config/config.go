@@ -1,78 +1,596 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	ModeDev  = "dev"
+	ModeTest = "test"
+	ModeProd = "prod"
+)
+
+var modes = []string{ModeDev, ModeTest, ModeProd}
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+var drivers = []string{DriverMySQL, DriverPostgres, DriverSQLite}
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+var tracingExporters = []string{TracingNone, TracingStdout, TracingOTLP}
+
+// 設定的優先順序: 預設值 < 設定檔 (YAML) < 環境變數 < command-line flags
 type Config struct {
-	Mode     string
-	Address  string
-	Database Database
-	Redis    Redis
+	Mode     string   `yaml:"mode"`
+	Address  string   `yaml:"address"`
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Redis    Redis    `yaml:"redis"`
+	Cache    Cache    `yaml:"cache"`
+	API      API      `yaml:"api"`
+	GeoIP    GeoIP    `yaml:"geoip"`
+	Metrics  Metrics  `yaml:"metrics"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+}
+
+// HTTP server timeouts (0 表示不限制)
+type Server struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"` // SIGTERM 之後等待進行中的 requests 完成的時間
 }
 
 type Database struct {
-	Driver string
-	Source string
+	Driver          string        `yaml:"driver"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"` // 0: driver 的預設 port (3306/5432)
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"` // sqlite: 資料庫檔案的路徑
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	QueryTimeout    time.Duration `yaml:"query_timeout"` // request 中一次資料庫操作 (或 transaction) 的 timeout, 0 表示不限制
+	SSLMode         string        `yaml:"sslmode"`       // 只有 postgres 使用
+	AutoMigrate     bool          `yaml:"auto_migrate"`  // 啟動時自動套用 migrations (sqlite 一定會套用)
 }
 
 type Redis struct {
-	Addr     string
-	Password string
-	DB       int
-}
-
-func Init(mode string) *Config {
-
-	conf := Config{}
-	conf.Database.Driver = "mysql"
-	conf.Redis.Password = ""
-	conf.Redis.DB = 0
-
-	switch mode {
-	case "prod":
-		gin.SetMode(gin.ReleaseMode)
-
-		conf.Database.Source = fmt.Sprintf(
-			"%s:%s@tcp(%s)/%s?parseTime=true",
-			os.Getenv("MYSQL_USER"),
-			os.Getenv("MYSQL_PASSWORD"),
-			"mysql",
-			os.Getenv("MYSQL_DATABASE"),
-		)
-		conf.Redis.Addr = "redis:6379"
-	case "dev":
-		err := godotenv.Load("../.env")
+	Addr         string        `yaml:"addr"`
+	Password     string        `yaml:"password"`
+	DB           int           `yaml:"db"`
+	PoolSize     int           `yaml:"pool_size"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// request 中一次 cache 操作的 timeout, 0 表示不限制
+	CommandTimeout time.Duration `yaml:"command_timeout"`
+}
+
+type Cache struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// GET /ad 與 GET /ads 的 limit, 與重新讀取 reference data (gender/country/platform) 的間隔
+type API struct {
+	DefaultLimit             int32         `yaml:"default_limit"`
+	ListDefaultLimit         int32         `yaml:"list_default_limit"`
+	MaxLimit                 int32         `yaml:"max_limit"`
+	ReferenceRefreshInterval time.Duration `yaml:"reference_refresh_interval"`
+	InferPlatform            bool          `yaml:"infer_platform"` // GET /ad 沒有 platform (或 platform=auto) 時以 User-Agent 推測
+}
+
+// 沒有 country 的 GET /ad 以 client IP 查詢位置 (database 為空時停用)
+type GeoIP struct {
+	Database string `yaml:"database"` // MMDB 檔案的路徑 (GeoIP2/GeoLite2 Country 或 City)
+}
+
+// Prometheus 的 /metrics 使用另一個 listener (只開放給內部), address 為空時停用
+type Metrics struct {
+	Address string `yaml:"address"`
+}
+
+// JSON 格式的 log (log/slog), level 為 debug/info/warn/error
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// 已經 Validate 過的 level
+func (log Log) SlogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(log.Level))
+	return level
+}
+
+// OpenTelemetry tracing (W3C trace context), exporter 為 none/stdout/otlp
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP 的 URL, 空字串表示使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	SampleRatio float64 `yaml:"sample_ratio"` // 沒有 traceparent 的 request 被 sample 的比例
+}
+
+func Default() Config {
+	return Config{
+		Mode:    ModeDev,
+		Address: ":8080",
+		Server: Server{
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			// export 是串流輸出, 預設不限制
+			WriteTimeout: 0,
+			IdleTimeout:  time.Minute,
+			// 小於 docker compose 的 stop_grace_period
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Database: Database{
+			Driver:          DriverMySQL,
+			Host:            "localhost",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+			ConnectTimeout:  5 * time.Second,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			QueryTimeout:    5 * time.Second,
+			SSLMode:         "disable",
+		},
+		Redis: Redis{
+			Addr:         "localhost:6379",
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+			// cache 應該比資料庫快, 太慢時回應 504 而不是等待
+			CommandTimeout: time.Second,
+		},
+		Cache: Cache{
+			TTL: 5 * time.Minute,
+		},
+		API: API{
+			DefaultLimit:     5,
+			ListDefaultLimit: 20,
+			MaxLimit:         100,
+			// 其他 replica 透過 admin API 的變更, 最晚在這個間隔後生效
+			ReferenceRefreshInterval: time.Minute,
+			// 預設不推測 (推測錯誤的 platform 會讓符合的廣告被排除)
+			InferPlatform: false,
+		},
+		Metrics: Metrics{
+			Address: ":9090",
+		},
+		Log: Log{
+			Level: "info",
+		},
+		Tracing: Tracing{
+			Exporter:    TracingNone,
+			SampleRatio: 1,
+		},
+	}
+}
+
+// 每個設定項目對應的 key (設定檔路徑, 也是 flag 名稱) 與環境變數
+type binding struct {
+	key string
+	env string
+	set func(string) error
+}
+
+func (conf *Config) bindings() []binding {
+	return []binding{
+		{"mode", "APP_MODE", stringSetter(&conf.Mode)},
+		{"address", "APP_ADDRESS", stringSetter(&conf.Address)},
+		{"server.read_timeout", "APP_READ_TIMEOUT", durationSetter(&conf.Server.ReadTimeout)},
+		{"server.read_header_timeout", "APP_READ_HEADER_TIMEOUT", durationSetter(&conf.Server.ReadHeaderTimeout)},
+		{"server.write_timeout", "APP_WRITE_TIMEOUT", durationSetter(&conf.Server.WriteTimeout)},
+		{"server.idle_timeout", "APP_IDLE_TIMEOUT", durationSetter(&conf.Server.IdleTimeout)},
+		{"server.shutdown_timeout", "APP_SHUTDOWN_TIMEOUT", durationSetter(&conf.Server.ShutdownTimeout)},
+		{"database.driver", "DB_DRIVER", stringSetter(&conf.Database.Driver)},
+		{"database.host", "DB_HOST", stringSetter(&conf.Database.Host)},
+		{"database.port", "DB_PORT", intSetter(&conf.Database.Port)},
+		{"database.user", "DB_USER", stringSetter(&conf.Database.User)},
+		{"database.password", "DB_PASSWORD", stringSetter(&conf.Database.Password)},
+		{"database.name", "DB_NAME", stringSetter(&conf.Database.Name)},
+		{"database.max_open_conns", "DB_MAX_OPEN_CONNS", intSetter(&conf.Database.MaxOpenConns)},
+		{"database.max_idle_conns", "DB_MAX_IDLE_CONNS", intSetter(&conf.Database.MaxIdleConns)},
+		{"database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", durationSetter(&conf.Database.ConnMaxLifetime)},
+		{"database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", durationSetter(&conf.Database.ConnMaxIdleTime)},
+		{"database.connect_timeout", "DB_CONNECT_TIMEOUT", durationSetter(&conf.Database.ConnectTimeout)},
+		{"database.read_timeout", "DB_READ_TIMEOUT", durationSetter(&conf.Database.ReadTimeout)},
+		{"database.write_timeout", "DB_WRITE_TIMEOUT", durationSetter(&conf.Database.WriteTimeout)},
+		{"database.query_timeout", "DB_QUERY_TIMEOUT", durationSetter(&conf.Database.QueryTimeout)},
+		{"database.sslmode", "DB_SSLMODE", stringSetter(&conf.Database.SSLMode)},
+		{"database.auto_migrate", "DB_AUTO_MIGRATE", boolSetter(&conf.Database.AutoMigrate)},
+		{"redis.addr", "REDIS_ADDR", stringSetter(&conf.Redis.Addr)},
+		{"redis.password", "REDIS_PASSWORD", stringSetter(&conf.Redis.Password)},
+		{"redis.db", "REDIS_DB", intSetter(&conf.Redis.DB)},
+		{"redis.pool_size", "REDIS_POOL_SIZE", intSetter(&conf.Redis.PoolSize)},
+		{"redis.dial_timeout", "REDIS_DIAL_TIMEOUT", durationSetter(&conf.Redis.DialTimeout)},
+		{"redis.read_timeout", "REDIS_READ_TIMEOUT", durationSetter(&conf.Redis.ReadTimeout)},
+		{"redis.write_timeout", "REDIS_WRITE_TIMEOUT", durationSetter(&conf.Redis.WriteTimeout)},
+		{"redis.command_timeout", "REDIS_COMMAND_TIMEOUT", durationSetter(&conf.Redis.CommandTimeout)},
+		{"cache.ttl", "CACHE_TTL", durationSetter(&conf.Cache.TTL)},
+		{"api.default_limit", "API_DEFAULT_LIMIT", int32Setter(&conf.API.DefaultLimit)},
+		{"api.list_default_limit", "API_LIST_DEFAULT_LIMIT", int32Setter(&conf.API.ListDefaultLimit)},
+		{"api.max_limit", "API_MAX_LIMIT", int32Setter(&conf.API.MaxLimit)},
+		{"api.reference_refresh_interval", "API_REFERENCE_REFRESH_INTERVAL", durationSetter(&conf.API.ReferenceRefreshInterval)},
+		{"api.infer_platform", "API_INFER_PLATFORM", boolSetter(&conf.API.InferPlatform)},
+		{"geoip.database", "GEOIP_DATABASE", stringSetter(&conf.GeoIP.Database)},
+		{"metrics.address", "METRICS_ADDRESS", stringSetter(&conf.Metrics.Address)},
+		{"log.level", "LOG_LEVEL", stringSetter(&conf.Log.Level)},
+		{"tracing.exporter", "TRACING_EXPORTER", stringSetter(&conf.Tracing.Exporter)},
+		{"tracing.endpoint", "TRACING_ENDPOINT", stringSetter(&conf.Tracing.Endpoint)},
+		{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", float64Setter(&conf.Tracing.SampleRatio)},
+	}
+}
+
+// 只支援 MySQL 時的環境變數名稱, 新的名稱沒有設定時使用 (.env 與 docker-compose 的 mysql 共用)
+var legacyEnv = map[string]string{
+	"DB_HOST":     "MYSQL_HOST",
+	"DB_PORT":     "MYSQL_PORT",
+	"DB_USER":     "MYSQL_USER",
+	"DB_PASSWORD": "MYSQL_PASSWORD",
+	"DB_NAME":     "MYSQL_DATABASE",
+}
+
+// 讀取環境變數 env (沒有設定時改讀 legacyEnv 的舊名稱), 回傳實際讀取的名稱
+func lookupEnv(env string) (string, string, bool) {
+	if value, ok := os.LookupEnv(env); ok {
+		return env, value, true
+	}
+	if legacy, ok := legacyEnv[env]; ok {
+		if value, ok := os.LookupEnv(legacy); ok {
+			return legacy, value, true
+		}
+	}
+	return env, "", false
+}
+
+func stringSetter(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+func intSetter(p *int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
 		if err != nil {
-			log.Fatal("Error loading .env file")
+			return errors.New("must be an integer")
 		}
-		conf.Database.Source = fmt.Sprintf(
-			"%s:%s@tcp(%s)/%s?parseTime=true",
-			os.Getenv("MYSQL_USER"),
-			os.Getenv("MYSQL_PASSWORD"),
-			"localhost",
-			os.Getenv("MYSQL_DATABASE"),
-		)
-		conf.Redis.Addr = "localhost:6379"
-	default: // dev
-		err := godotenv.Load("../.env")
+		*p = i
+		return nil
+	}
+}
+
+func int32Setter(p *int32) func(string) error {
+	return func(value string) error {
+		i, err := strconv.ParseInt(value, 10, 32)
 		if err != nil {
-			log.Fatal("Error loading .env file")
+			return errors.New("must be an integer")
+		}
+		*p = int32(i)
+		return nil
+	}
+}
+
+func float64Setter(p *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		*p = f
+		return nil
+	}
+}
+
+func boolSetter(p *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		*p = b
+		return nil
+	}
+}
+
+func durationSetter(p *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration, e.g. 5s")
+		}
+		*p = d
+		return nil
+	}
+}
+
+type flagValue struct {
+	binding binding
+	value   string
+}
+
+// 讀取設定 (args 不含程式名稱), 所有錯誤一起回傳, 呼叫端應該直接結束程式
+func Load(args []string) (*Config, error) {
+	conf := Default()
+	bindings := conf.bindings()
+
+	// command-line flags (最後才套用, 才能覆蓋設定檔與環境變數)
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("APP_CONFIG"), "path to the YAML config file (env APP_CONFIG)")
+	var flagValues []flagValue
+	for _, b := range bindings {
+		flags.Func(b.key, fmt.Sprintf("%s (env %s)", b.key, b.env), func(value string) error {
+			flagValues = append(flagValues, flagValue{b, value})
+			return nil
+		})
+	}
+	address := bindings[slices.IndexFunc(bindings, func(b binding) bool { return b.key == "address" })]
+	flags.Func("addr", "alias of -address", func(value string) error {
+		flagValues = append(flagValues, flagValue{address, value})
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// config file
+	if *path != "" {
+		if err := conf.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	// 開發環境從 ../.env 讀取環境變數 (不覆蓋已經存在的環境變數)
+	if conf.mode(flagValues) == ModeDev {
+		if _, err := os.Stat("../.env"); err == nil {
+			if err := godotenv.Load("../.env"); err != nil {
+				return nil, fmt.Errorf("load ../.env: %v", err)
+			}
+		}
+	}
+
+	var errs []error
+
+	// environment variables
+	for _, b := range bindings {
+		env, value, ok := lookupEnv(b.env)
+		if !ok {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid environment variable %s=%q (%v)", env, value, err))
+		}
+	}
+
+	for _, f := range flagValues {
+		if err := f.binding.set(f.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid flag -%s=%q (%v)", f.binding.key, f.value, err))
 		}
-		conf.Database.Source = fmt.Sprintf(
-			"%s:%s@tcp(%s)/%s?parseTime=true",
-			os.Getenv("MYSQL_USER"),
-			os.Getenv("MYSQL_PASSWORD"),
-			"localhost",
-			os.Getenv("MYSQL_DATABASE"),
-		)
-		conf.Redis.Addr = "localhost:6379"
 	}
 
-	return &conf
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+func (conf *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %v", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// 拼錯的 key 直接報錯, 避免設定被默默忽略
+	decoder.KnownFields(true)
+	if err := decoder.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// 還沒套用環境變數與 flags 之前, 先決定 mode (用來判斷要不要讀 .env)
+func (conf *Config) mode(flagValues []flagValue) string {
+	mode := conf.Mode
+	if value, ok := os.LookupEnv("APP_MODE"); ok {
+		mode = value
+	}
+	for _, f := range flagValues {
+		if f.binding.key == "mode" {
+			mode = f.value
+		}
+	}
+	return mode
+}
+
+// 檢查所有設定, 回傳所有不合法的項目
+func (conf *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains(modes, conf.Mode), "invalid mode value %q (must be dev, test or prod)", conf.Mode)
+	check(conf.Address != "", "invalid address value (must not be empty)")
+
+	check(conf.Server.ReadTimeout >= 0, "invalid server.read_timeout value (must be >= 0)")
+	check(conf.Server.ReadHeaderTimeout >= 0, "invalid server.read_header_timeout value (must be >= 0)")
+	check(conf.Server.WriteTimeout >= 0, "invalid server.write_timeout value (must be >= 0)")
+	check(conf.Server.IdleTimeout >= 0, "invalid server.idle_timeout value (must be >= 0)")
+	check(conf.Server.ShutdownTimeout > 0, "invalid server.shutdown_timeout value (must be > 0)")
+
+	// test mode 使用記憶體, 不需要資料庫與 Redis 的設定
+	if conf.Mode != ModeTest {
+		check(slices.Contains(drivers, conf.Database.Driver), "invalid database.driver value %q (must be mysql, postgres or sqlite)", conf.Database.Driver)
+		// sqlite 是本機檔案, 不需要連線設定
+		if conf.Database.Driver != DriverSQLite {
+			check(conf.Database.Host != "", "invalid database.host value (must not be empty)")
+			check(conf.Database.Port >= 0 && conf.Database.Port <= 65535, "invalid database.port value (must be 0 ~ 65535)")
+			check(conf.Database.User != "", "invalid database.user value (must not be empty)")
+		}
+		check(conf.Database.Name != "", "invalid database.name value (must not be empty)")
+		check(conf.Database.MaxOpenConns >= 0, "invalid database.max_open_conns value (must be >= 0)")
+		check(conf.Database.MaxIdleConns >= 0, "invalid database.max_idle_conns value (must be >= 0)")
+		check(conf.Database.MaxOpenConns == 0 || conf.Database.MaxIdleConns <= conf.Database.MaxOpenConns,
+			"invalid database.max_idle_conns value (must be <= max_open_conns)")
+		check(conf.Database.ConnMaxLifetime >= 0, "invalid database.conn_max_lifetime value (must be >= 0)")
+		check(conf.Database.ConnMaxIdleTime >= 0, "invalid database.conn_max_idle_time value (must be >= 0)")
+		check(conf.Database.ConnectTimeout >= 0, "invalid database.connect_timeout value (must be >= 0)")
+		check(conf.Database.ReadTimeout >= 0, "invalid database.read_timeout value (must be >= 0)")
+		check(conf.Database.WriteTimeout >= 0, "invalid database.write_timeout value (must be >= 0)")
+		check(conf.Database.QueryTimeout >= 0, "invalid database.query_timeout value (must be >= 0)")
+
+		// sqlite 可以單獨執行, redis.addr 為空時使用記憶體 cache
+		check(conf.Redis.Addr != "" || conf.Database.Driver == DriverSQLite, "invalid redis.addr value (must not be empty)")
+		check(conf.Redis.DB >= 0 && conf.Redis.DB <= 15, "invalid redis.db value (must be 0 ~ 15)")
+		check(conf.Redis.PoolSize >= 0, "invalid redis.pool_size value (must be >= 0)")
+		check(conf.Redis.DialTimeout >= 0, "invalid redis.dial_timeout value (must be >= 0)")
+		check(conf.Redis.ReadTimeout >= 0, "invalid redis.read_timeout value (must be >= 0)")
+		check(conf.Redis.WriteTimeout >= 0, "invalid redis.write_timeout value (must be >= 0)")
+		check(conf.Redis.CommandTimeout >= 0, "invalid redis.command_timeout value (must be >= 0)")
+	}
+
+	check(conf.Cache.TTL > 0, "invalid cache.ttl value (must be > 0)")
+
+	check(conf.API.MaxLimit >= 1, "invalid api.max_limit value (must be >= 1)")
+	check(conf.API.DefaultLimit >= 1 && conf.API.DefaultLimit <= conf.API.MaxLimit,
+		"invalid api.default_limit value (must be 1 ~ max_limit)")
+	check(conf.API.ListDefaultLimit >= 1 && conf.API.ListDefaultLimit <= conf.API.MaxLimit,
+		"invalid api.list_default_limit value (must be 1 ~ max_limit)")
+	check(conf.API.ReferenceRefreshInterval > 0, "invalid api.reference_refresh_interval value (must be > 0)")
+
+	check(conf.Metrics.Address != conf.Address, "invalid metrics.address value (must be different from address)")
+
+	check(slices.Contains(logLevels, strings.ToLower(conf.Log.Level)), "invalid log.level value %q (must be debug, info, warn or error)", conf.Log.Level)
+
+	check(slices.Contains(tracingExporters, conf.Tracing.Exporter), "invalid tracing.exporter value %q (must be none, stdout or otlp)", conf.Tracing.Exporter)
+	if conf.Tracing.Endpoint != "" {
+		endpoint, err := url.Parse(conf.Tracing.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"invalid tracing.endpoint value %q (must be an http or https URL)", conf.Tracing.Endpoint)
+	}
+	check(conf.Tracing.SampleRatio >= 0 && conf.Tracing.SampleRatio <= 1, "invalid tracing.sample_ratio value (must be 0 ~ 1)")
+
+	return errors.Join(errs...)
+}
+
+// database/sql 的 data source name (依 driver 決定格式)
+func (database Database) DSN() string {
+	switch database.Driver {
+	case DriverPostgres:
+		return database.postgresDSN()
+	case DriverSQLite:
+		return database.sqliteDSN()
+	}
+
+	return database.mysqlConfig().FormatDSN()
+}
+
+func (database Database) mysqlConfig() *mysql.Config {
+	dsn := mysql.NewConfig()
+	dsn.User = database.User
+	dsn.Passwd = database.Password
+	dsn.Net = "tcp"
+	dsn.Addr = database.addr(3306)
+	dsn.DBName = database.Name
+	dsn.ParseTime = true
+	dsn.Timeout = database.ConnectTimeout
+	dsn.ReadTimeout = database.ReadTimeout
+	dsn.WriteTimeout = database.WriteTimeout
+	return dsn
+}
+
+// 執行 migrations 用的 DSN (一個 migration 檔案有多個 statements, MySQL 需要 multiStatements)
+func (database Database) MigrateDSN() string {
+	if database.Driver != DriverMySQL {
+		return database.DSN()
+	}
+	dsn := database.mysqlConfig()
+	dsn.MultiStatements = true
+	return dsn.FormatDSN()
+}
+
+// lib/pq 的 URL 格式 (沒有 read/write timeout, 由 context 控制)
+func (database Database) postgresDSN() string {
+	query := url.Values{}
+	query.Set("sslmode", database.SSLMode)
+	if database.ConnectTimeout > 0 {
+		// 單位是秒, 至少 1 秒
+		query.Set("connect_timeout", strconv.Itoa(max(int(database.ConnectTimeout/time.Second), 1)))
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(database.User, database.Password),
+		Host:     database.addr(5432),
+		Path:     "/" + database.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// modernc.org/sqlite 的 URI 格式, 每個連線都開啟 foreign key 檢查
+func (database Database) sqliteDSN() string {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	if database.ConnectTimeout > 0 {
+		// 資料庫被鎖住時最多等待的時間
+		query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", database.ConnectTimeout.Milliseconds()))
+	}
+	// 時間以文字儲存, 固定格式才能直接比較
+	query.Set("_time_format", "sqlite")
+	query.Set("_txlock", "immediate")
+	return "file:" + database.Name + "?" + query.Encode()
+}
+
+// host:port, port 沒設定時使用 driver 的預設 port
+func (database Database) addr(defaultPort int) string {
+	port := database.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	return net.JoinHostPort(database.Host, strconv.Itoa(port))
 }
@@ -45,7 +45,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "國家條件 (參考 ISO_3166-1 alpha-2)",
+                        "description": "國家條件 (參考 ISO_3166-1 alpha-2, 沒有 country 與 region 時由 client IP 推測並在 inferred 中回傳)",
                         "name": "country",
                         "in": "query"
                     },
@@ -53,13 +53,68 @@ const docTemplate = `{
                         "enum": [
                             "android",
                             "ios",
-                            "web"
+                            "web",
+                            "auto"
                         ],
                         "type": "string",
-                        "description": "平台條件",
+                        "description": "平台條件 (開啟 api.infer_platform 時, 沒有 platform 或 auto 由 User-Agent 推測並在 inferred 中回傳)",
                         "name": "platform",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "推測 platform 與 device",
+                        "name": "User-Agent",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "地區條件 (參考 ISO 3166-2, 沒有 country 時以前兩碼為 country)",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "description": "緯度 (與 lng 一起使用, 比對 geofence 條件)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "description": "經度 (與 lat 一起使用, 比對 geofence 條件)",
+                        "name": "lng",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "audience segments (可以重複或以逗號分隔, 未定義的 segment 會被忽略)",
+                        "name": "segments",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "頁面的 keywords (可以重複或以逗號分隔, 比對前正規化: 全形轉半形, 不分大小寫)",
+                        "name": "keywords",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "頁面的看板/分類 (比對前正規化)",
+                        "name": "category",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": " ",
@@ -71,6 +126,24 @@ const docTemplate = `{
                         "description": " ",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "使用者 id (同一個使用者會固定看到同一個 variant)",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "語言 (優先於 Accept-Language, fallback: zh-TW → zh → 預設)",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "語言偏好",
+                        "name": "Accept-Language",
+                        "in": "header"
                     }
                 ],
                 "responses": {}
@@ -96,6 +169,399 @@ const docTemplate = `{
                 ],
                 "responses": {}
             }
+        },
+        "/ad/{id}/status": {
+            "patch": {
+                "description": "允許的狀態轉換: draft → scheduled → active ⇄ paused → archived",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "advertisement"
+                ],
+                "summary": "變更廣告狀態",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "廣告 id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "新的狀態",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AdvertisementStatus"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/country-groups": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "列出 country groups 與它們的 countries",
+                "responses": {}
+            }
+        },
+        "/admin/country-groups/{code}": {
+            "put": {
+                "description": "members 在比對時才展開, 已經使用這個 group 的廣告馬上套用新的 countries; built-in groups 不能修改 (409)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "新增或取代自訂的 country group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "DACH",
+                        "description": "country group 的 code (不能與 country code 相同)",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "name 與 countries",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CountryGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "description": "built-in groups 與還有條件使用的 group 不能刪除 (409)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "刪除自訂的 country group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "DACH",
+                        "description": "country group 的 code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/segments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "列出 audience segments",
+                "responses": {}
+            }
+        },
+        "/admin/segments/{code}": {
+            "put": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "新增 audience segment 或修改它的 name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "gamer",
+                        "description": "segment 的 code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SegmentRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "description": "還有條件使用的 segment 不能刪除 (409)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "刪除 audience segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "gamer",
+                        "description": "segment 的 code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/{kind}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "列出可以使用的 reference data (不包含已停用的)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "genders",
+                            "countries",
+                            "platforms"
+                        ],
+                        "type": "string",
+                        "description": "種類",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/{kind}/{code}": {
+            "put": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "新增 reference data (已停用的會重新啟用)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "genders",
+                            "countries",
+                            "platforms"
+                        ],
+                        "type": "string",
+                        "description": "種類",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "tv",
+                        "description": "gender/country 的 code 或 platform 的名稱",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "genders/countries 必填",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReferenceValue"
+                        }
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "description": "archived 以外的廣告還在使用的值不能停用 (409, paused/draft 之後可能再變成 active), 已經存在的條件不受影響",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "停用 reference data",
+                "parameters": [
+                    {
+                        "enum": [
+                            "genders",
+                            "countries",
+                            "platforms"
+                        ],
+                        "type": "string",
+                        "description": "種類",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "tv",
+                        "description": "gender/country 的 code 或 platform 的名稱",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/ads": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "advertisement"
+                ],
+                "summary": "列出所有廣告 (管理用, 不論狀態與條件)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "draft",
+                            "scheduled",
+                            "active",
+                            "paused",
+                            "archived"
+                        ],
+                        "type": "string",
+                        "description": "狀態條件",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": " ",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": " ",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/ads:bulk": {
+            "post": {
+                "description": "接受 JSONL (每行一個 Advertisement) 或 CSV (title,startAt,endAt,conditions,status,creative,variants,localizations) 格式, 每一列各自驗證後分批寫入, 並回傳每一列的結果",
+                "consumes": [
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "advertisement"
+                ],
+                "summary": "批次產⽣廣告資源",
+                "parameters": [
+                    {
+                        "enum": [
+                            "jsonl",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "輸入格式 (預設依 Content-Type 判斷)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只驗證不寫入",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BulkCreateAdvertisementsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ads:export": {
+            "get": {
+                "description": "以串流方式匯出所有廣告與完整 conditions, 格式與 POST /ad 的 request body 相同 (可直接用 POST /ads:bulk 匯入)",
+                "produces": [
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "advertisement"
+                ],
+                "summary": "匯出廣告資源",
+                "parameters": [
+                    {
+                        "enum": [
+                            "jsonl",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "輸出格式",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "draft",
+                            "scheduled",
+                            "active",
+                            "paused",
+                            "archived"
+                        ],
+                        "type": "string",
+                        "description": "狀態條件",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只匯出 endAt \u003e= from 的廣告 (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只匯出 startAt \u003c= to 的廣告 (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
         }
     },
     "definitions": {
@@ -122,12 +588,43 @@ const docTemplate = `{
                     "x-order": "2",
                     "example": "2023-12-31T16:00:00.000Z"
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "scheduled"
+                    ],
+                    "x-order": "3",
+                    "example": "scheduled"
+                },
+                "creative": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.Creative"
+                        }
+                    ],
+                    "x-order": "4"
+                },
+                "localizations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handlers.Localization"
+                    },
+                    "x-order": "5"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.Variant"
+                    },
+                    "x-order": "6"
+                },
                 "conditions": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/handlers.AdvertisementCondition"
                     },
-                    "x-order": "3"
+                    "x-order": "7"
                 }
             }
         },
@@ -155,6 +652,7 @@ const docTemplate = `{
                     ]
                 },
                 "country": {
+                    "description": "country 或 country group (例如 EU) 的 code",
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -175,6 +673,287 @@ const docTemplate = `{
                         "android",
                         "ios"
                     ]
+                },
+                "region": {
+                    "description": "ISO 3166-2 subdivision",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-order": "5",
+                    "example": [
+                        "TW-TPE",
+                        "TW-NWT"
+                    ]
+                },
+                "geofence": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.Geofence"
+                    },
+                    "x-order": "6"
+                },
+                "segment": {
+                    "description": "audience segment 的 code",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-order": "7",
+                    "example": [
+                        "gamer"
+                    ]
+                },
+                "keyword": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-order": "8",
+                    "example": [
+                        "switch",
+                        "ps5"
+                    ]
+                },
+                "category": {
+                    "description": "看板/分類",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-order": "9",
+                    "example": [
+                        "game"
+                    ]
+                }
+            }
+        },
+        "handlers.AdvertisementStatus": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "scheduled",
+                        "active",
+                        "paused",
+                        "archived"
+                    ],
+                    "example": "paused"
+                }
+            }
+        },
+        "handlers.BulkCreateAdvertisementsResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BulkRowResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BulkRowResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "valid",
+                        "created",
+                        "invalid",
+                        "failed"
+                    ],
+                    "example": "created"
+                }
+            }
+        },
+        "handlers.CountryGroupRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "x-order": "0",
+                    "example": "Germany, Austria and Switzerland"
+                },
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-order": "1",
+                    "example": [
+                        "DE",
+                        "AT",
+                        "CH"
+                    ]
+                }
+            }
+        },
+        "handlers.Creative": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "x-order": "0",
+                    "example": "全館商品 5 折起"
+                },
+                "imageUrl": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "https://cdn.example.com/ad55.png"
+                },
+                "imageWidth": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1200
+                },
+                "imageHeight": {
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 628
+                },
+                "landingUrl": {
+                    "type": "string",
+                    "x-order": "4",
+                    "example": "https://shop.example.com/sale"
+                },
+                "ctaLabel": {
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "立即購買"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "text",
+                        "image",
+                        "native"
+                    ],
+                    "x-order": "6",
+                    "example": "image"
+                }
+            }
+        },
+        "handlers.Geofence": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number",
+                    "x-order": "0",
+                    "example": 25.034
+                },
+                "lng": {
+                    "type": "number",
+                    "x-order": "1",
+                    "example": 121.5645
+                },
+                "radius": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 2000
+                }
+            }
+        },
+        "handlers.Localization": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string",
+                    "x-order": "0",
+                    "example": "廣告 55"
+                },
+                "description": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "全館商品 5 折起"
+                },
+                "ctaLabel": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "立即購買"
+                }
+            }
+        },
+        "handlers.ReferenceValue": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "genders/countries 必填, platforms 不使用",
+                    "type": "string",
+                    "example": "Non-binary"
+                }
+            }
+        },
+        "handlers.SegmentRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "x-order": "0",
+                    "example": "Gamers"
+                }
+            }
+        },
+        "handlers.Variant": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "x-order": "0",
+                    "example": "A"
+                },
+                "weight": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 50
+                },
+                "title": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "AD 55 (A)"
+                },
+                "creative": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.Creative"
+                        }
+                    ],
+                    "x-order": "3"
                 }
             }
         }
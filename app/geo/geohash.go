@@ -0,0 +1,111 @@
+package geo
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// geofence 的 cells 使用的 precision 範圍 (request 的位置以 MaxPrecision 編碼, 再用 prefix 比對)
+	MinPrecision = 2
+	MaxPrecision = 6
+
+	// 緯度 1 度的長度 (公尺, 地球平均半徑 6371 km)
+	MetersPerDegree = 111195
+)
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// (lat, lng) 所在的 geohash cell
+func Encode(lat float64, lng float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var builder strings.Builder
+	bits, value := 0, 0
+	even := true // 偶數 bit 是經度
+	for builder.Len() < precision {
+		r, v := &latRange, lat
+		if even {
+			r, v = &lngRange, lng
+		}
+		mid := (r[0] + r[1]) / 2
+		value <<= 1
+		if v >= mid {
+			value |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+
+		bits++
+		if bits == 5 {
+			builder.WriteByte(base32[value])
+			bits, value = 0, 0
+		}
+	}
+	return builder.String()
+}
+
+// precision 的 cell 的高與寬 (度)
+func cellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	return 180 / math.Exp2(float64(bits/2)), 360 / math.Exp2(float64((bits+1)/2))
+}
+
+// 涵蓋 (lat, lng) 半徑 radius 公尺內所有位置的 cells:
+// 選擇長寬都不小於 radius 的最小 cell, 圓一定落在中心 cell 與周圍 8 個 cells 之中
+func Cover(lat float64, lng float64, radius int32) []string {
+	// 圓最靠近極點的緯度 (cell 在這裡最窄)
+	edge := math.Min(math.Abs(lat)+float64(radius)/MetersPerDegree, 90)
+
+	precision := MinPrecision
+	for p := MaxPrecision; p > MinPrecision; p-- {
+		height, width := cellSize(p)
+		if height*MetersPerDegree >= float64(radius) && width*MetersPerDegree*math.Cos(edge*math.Pi/180) >= float64(radius) {
+			precision = p
+			break
+		}
+	}
+
+	height, width := cellSize(precision)
+	cells := make([]string, 0, 9)
+	for dy := -1; dy <= 1; dy++ {
+		y := lat + float64(dy)*height
+		if y < -90 || y > 90 {
+			continue
+		}
+		for dx := -1; dx <= 1; dx++ {
+			// 經度超過 ±180 時繞回另一邊
+			x := math.Mod(lng+float64(dx)*width+540, 360) - 180
+			cell := Encode(y, x, precision)
+			if !slices.Contains(cells, cell) {
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// geohash 在 MinPrecision ~ 自身長度之間的 prefixes (與 ResolveGeofences 的 IN 相同)
+func Prefixes(geohash string) []string {
+	prefixes := make([]string, 0, len(geohash))
+	for n := MinPrecision; n <= len(geohash); n++ {
+		prefixes = append(prefixes, geohash[:n])
+	}
+	return prefixes
+}
+
+// 經度差換算成距離時的比例 (cos²(lat)), 由呼叫端算好再傳給 query (SQLite 沒有三角函數)
+func LngScale(lat float64) float64 {
+	cos := math.Cos(lat * math.Pi / 180)
+	return cos * cos
+}
+
+// (lat, lng) 是否在 (centerLat, centerLng) 半徑 radius 公尺內, 與 ResolveGeofences 的 WHERE 相同 (equirectangular 近似, 不處理跨越 180 度經線)
+func Within(lat float64, lng float64, centerLat float64, centerLng float64, radius int32) bool {
+	dLat, dLng := lat-centerLat, lng-centerLng
+	return (dLat*dLat+dLng*dLng*LngScale(lat))*MetersPerDegree*MetersPerDegree <= float64(radius)*float64(radius)
+}
@@ -0,0 +1,80 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		lat, lng  float64
+		precision int
+		expected  string
+	}{
+		{lat: 57.64911, lng: 10.40744, precision: 11, expected: "u4pruydqqvj"},
+		{lat: 42.6, lng: -5.6, precision: 5, expected: "ezs42"},
+		{lat: 25.0340, lng: 121.5645, precision: 6, expected: "wsqqqm"},
+		{lat: -33.8688, lng: 151.2093, precision: 2, expected: "r3"},
+	}
+	for _, tc := range testCases {
+		if got := Encode(tc.lat, tc.lng, tc.precision); got != tc.expected {
+			t.Errorf("Encode(%v, %v, %d): expected %s, got: %s", tc.lat, tc.lng, tc.precision, tc.expected, got)
+		}
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	expected := []string{"ws", "wsq", "wsqq", "wsqqq", "wsqqqm"}
+	if got := Prefixes("wsqqqm"); !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got: %v", expected, got)
+	}
+}
+
+// 圓內的每一個位置都要有 prefix 落在 Cover 的 cells 中
+func TestCover(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lat, lng float64
+		radius   int32
+	}{
+		{name: "taipei 500m", lat: 25.0340, lng: 121.5645, radius: 500},
+		{name: "taipei 50km", lat: 25.0340, lng: 121.5645, radius: 50000},
+		{name: "cell corner", lat: 25.048828125, lng: 121.552734375, radius: 1000},
+		{name: "oslo 5km", lat: 59.9139, lng: 10.7522, radius: 5000},
+		{name: "antimeridian", lat: -17.7134, lng: 179.99, radius: 2000},
+	}
+	random := rand.New(rand.NewSource(1))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cells := Cover(tc.lat, tc.lng, tc.radius)
+			if len(cells) == 0 || len(cells) > 9 {
+				t.Fatalf("expected 1 ~ 9 cells, got: %v", cells)
+			}
+			for i := 0; i < 1000; i++ {
+				// 圓內的隨機位置
+				angle := random.Float64() * 2 * math.Pi
+				distance := float64(tc.radius) * math.Sqrt(random.Float64())
+				lat := tc.lat + distance*math.Sin(angle)/MetersPerDegree
+				lng := tc.lng + distance*math.Cos(angle)/MetersPerDegree/math.Cos(tc.lat*math.Pi/180)
+				lng = math.Mod(lng+540, 360) - 180
+
+				geohash := Encode(lat, lng, MaxPrecision)
+				if !slices.ContainsFunc(Prefixes(geohash), func(prefix string) bool { return slices.Contains(cells, prefix) }) {
+					t.Fatalf("(%v, %v) is not covered by %v", lat, lng, cells)
+				}
+			}
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	// 台北 101 到台北車站約 5 km
+	if !Within(25.0478, 121.5170, 25.0340, 121.5645, 5500) {
+		t.Error("expected to be within 5.5 km")
+	}
+	if Within(25.0478, 121.5170, 25.0340, 121.5645, 4500) {
+		t.Error("expected not to be within 4.5 km")
+	}
+}
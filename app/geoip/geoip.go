@@ -0,0 +1,56 @@
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// IP 所在的 country (ISO 3166-1 alpha-2) 與 region (ISO 3166-2, 資料庫沒有 subdivision 時為空)
+type Location struct {
+	Country string
+	Region  string
+}
+
+// GeoIP2/GeoLite2 Country 與 City 資料庫中用到的欄位
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// 本機的 MMDB 檔案 (整個檔案 mmap 到記憶體, 可以同時查詢)
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+func Open(path string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{db}, nil
+}
+
+func (reader *Reader) Close() error {
+	return reader.db.Close()
+}
+
+// 查詢 ip 的位置, 資料庫中沒有這個 ip (或沒有 country) 時 ok 為 false
+func (reader *Reader) Lookup(ip net.IP) (Location, bool) {
+	var r record
+	_, ok, err := reader.db.LookupNetwork(ip, &r)
+	if err != nil || !ok || r.Country.ISOCode == "" {
+		return Location{}, false
+	}
+
+	location := Location{Country: r.Country.ISOCode}
+	// 第一個 subdivision 是最大的行政區 (例如 GB-ENG 而不是 GB-WSM)
+	if len(r.Subdivisions) > 0 && r.Subdivisions[0].ISOCode != "" {
+		location.Region = r.Country.ISOCode + "-" + r.Subdivisions[0].ISOCode
+	}
+	return location, true
+}
@@ -0,0 +1,234 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"net"
+	"os"
+	"slices"
+	"testing"
+)
+
+// go test ./geoip -update 重新產生 testdata/test.mmdb
+var update = flag.Bool("update", false, "rewrite testdata/test.mmdb")
+
+const fixturePath = "testdata/test.mmdb"
+
+// fixture 中的 networks (不重疊)
+var fixtureNetworks = []struct {
+	network string
+	country string
+	region  string // subdivision 的 code (不含 country)
+}{
+	{network: "1.2.3.0/24", country: "TW", region: "TPE"},
+	{network: "1.2.4.0/24", country: "TW"},
+	{network: "5.6.7.0/24", country: "JP", region: "13"},
+	{network: "8.8.0.0/16", country: "US", region: "CA"},
+	{network: "2001:db8::/32", country: "JP"},
+}
+
+func TestFixture(t *testing.T) {
+	data := buildFixture(t)
+	if *update {
+		if err := os.WriteFile(fixturePath, data, 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	existing, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(existing, data) {
+		t.Errorf("%s is out of date, run go test ./geoip -update", fixturePath)
+	}
+}
+
+func TestReader_Lookup(t *testing.T) {
+	reader, err := Open(fixturePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+	// 檢查 writer 產生的檔案符合格式
+	if err := reader.db.Verify(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		ip       string
+		expected Location
+		ok       bool
+	}{
+		{ip: "1.2.3.4", expected: Location{Country: "TW", Region: "TW-TPE"}, ok: true},
+		{ip: "1.2.4.200", expected: Location{Country: "TW"}, ok: true},
+		{ip: "5.6.7.8", expected: Location{Country: "JP", Region: "JP-13"}, ok: true},
+		{ip: "8.8.8.8", expected: Location{Country: "US", Region: "US-CA"}, ok: true},
+		{ip: "2001:db8::1", expected: Location{Country: "JP"}, ok: true},
+		{ip: "::ffff:1.2.3.4", expected: Location{Country: "TW", Region: "TW-TPE"}, ok: true},
+		{ip: "192.0.2.1", ok: false},
+		{ip: "2001:db9::1", ok: false},
+	}
+	for _, tc := range testCases {
+		location, ok := reader.Lookup(net.ParseIP(tc.ip))
+		if ok != tc.ok || location != tc.expected {
+			t.Errorf("%s: expected: %+v (%v), got: %+v (%v)", tc.ip, tc.expected, tc.ok, location, ok)
+		}
+	}
+}
+
+// 以下是只支援 fixture 需要的部分的 MMDB writer (IPv6 tree, 24-bit records)
+// 格式參考 https://maxmind.github.io/MaxMind-DB/
+
+type node struct {
+	children [2]*node
+	data     int // leaf: data section 中的 offset, 其他為 -1
+}
+
+func buildFixture(t *testing.T) []byte {
+	t.Helper()
+
+	root := &node{data: -1}
+	var dataSection []byte
+	for _, n := range fixtureNetworks {
+		_, network, err := net.ParseCIDR(n.network)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ones, _ := network.Mask.Size()
+		ip := network.IP.To16()
+		// IPv4 放在 ::/96 之下
+		if network.IP.To4() != nil {
+			ip = append(make(net.IP, 12), network.IP.To4()...)
+			ones += 96
+		}
+
+		value := map[string]any{
+			"country": map[string]any{"iso_code": n.country},
+		}
+		if n.region != "" {
+			value["subdivisions"] = []any{map[string]any{"iso_code": n.region}}
+		}
+
+		current := root
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if current.children[bit] == nil {
+				current.children[bit] = &node{data: -1}
+			}
+			current = current.children[bit]
+		}
+		current.data = len(dataSection)
+		dataSection = append(dataSection, encode(value)...)
+	}
+
+	// 只有非 leaf 的 nodes 會寫入 tree (BFS 順序編號)
+	var nodes []*node
+	queue := []*node{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		nodes = append(nodes, current)
+		for _, child := range current.children {
+			if child != nil && child.data < 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+	nodeCount := len(nodes)
+	index := make(map[*node]int, nodeCount)
+	for i, n := range nodes {
+		index[n] = i
+	}
+
+	var buffer bytes.Buffer
+	for _, n := range nodes {
+		for _, child := range n.children {
+			record := nodeCount // 沒有資料
+			if child != nil && child.data >= 0 {
+				record = nodeCount + 16 + child.data
+			} else if child != nil {
+				record = index[child]
+			}
+			buffer.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	buffer.Write(make([]byte, 16))
+	buffer.Write(dataSection)
+	buffer.WriteString("\xab\xcd\xefMaxMind.com")
+	buffer.Write(encode(map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1713312000),
+		"database_type":               "GeoIP2-City",
+		"description":                 map[string]any{"en": "dcard-intern test database"},
+		"ip_version":                  uint16(6),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	}))
+	return buffer.Bytes()
+}
+
+// MMDB data section 的編碼 (string, map, array, uint16/32/64)
+func encode(value any) []byte {
+	var body []byte
+	var typ, size int
+	switch v := value.(type) {
+	case string:
+		typ, size, body = 2, len(v), []byte(v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		typ, size = 7, len(v)
+		for _, key := range keys {
+			body = append(body, encode(key)...)
+			body = append(body, encode(v[key])...)
+		}
+	case []any:
+		typ, size = 11, len(v)
+		for _, item := range v {
+			body = append(body, encode(item)...)
+		}
+	case uint16:
+		typ, body = 5, trimLeadingZeros(binary.BigEndian.AppendUint16(nil, v))
+		size = len(body)
+	case uint32:
+		typ, body = 6, trimLeadingZeros(binary.BigEndian.AppendUint32(nil, v))
+		size = len(body)
+	case uint64:
+		typ, body = 9, trimLeadingZeros(binary.BigEndian.AppendUint64(nil, v))
+		size = len(body)
+	default:
+		panic("unsupported type")
+	}
+
+	var control []byte
+	if typ <= 7 {
+		control = []byte{byte(typ << 5)}
+	} else {
+		// extended type: 第一個 byte 的 type 為 0, 下一個 byte 是 type - 7
+		control = []byte{0, byte(typ - 7)}
+	}
+	switch {
+	case size < 29:
+		control[0] |= byte(size)
+	case size < 285:
+		control[0] |= 29
+		control = append(control, byte(size-29))
+	default:
+		control[0] |= 30
+		control = binary.BigEndian.AppendUint16(control, uint16(size-285))
+	}
+	return append(control, body...)
+}
+
+func trimLeadingZeros(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
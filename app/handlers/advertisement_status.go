@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+const (
+	AdvertisementStatusDraft     = "draft"
+	AdvertisementStatusScheduled = "scheduled"
+	AdvertisementStatusActive    = "active"
+	AdvertisementStatusPaused    = "paused"
+	AdvertisementStatusArchived  = "archived"
+)
+
+// 允許的狀態轉換: draft → scheduled → active ⇄ paused → archived
+// (scheduled 在 startAt 到了之後由 ActivateScheduledAdvertisements 自動轉成 active,
+// active/paused 在 endAt 過了之後由 ArchiveExpiredAdvertisements 自動轉成 archived)
+var advertisementStatusTransitions = map[string][]string{
+	AdvertisementStatusDraft:     {AdvertisementStatusScheduled},
+	AdvertisementStatusScheduled: {AdvertisementStatusActive},
+	AdvertisementStatusActive:    {AdvertisementStatusPaused},
+	AdvertisementStatusPaused:    {AdvertisementStatusActive, AdvertisementStatusArchived},
+	AdvertisementStatusArchived:  {},
+}
+
+type AdvertisementStatus struct {
+	Status string `json:"status" binding:"required" example:"paused" enums:"draft,scheduled,active,paused,archived"`
+}
+
+func isAdvertisementStatus(status string) bool {
+	_, ok := advertisementStatusTransitions[status]
+	return ok
+}
+
+// 判斷狀態轉換是否允許
+func validateStatusTransition(from string, to string) error {
+	if !isAdvertisementStatus(to) {
+		return errors.New("invalid status value")
+	}
+	if !slices.Contains(advertisementStatusTransitions[from], to) {
+		return fmt.Errorf("invalid status transition (%s -> %s)", from, to)
+	}
+	return nil
+}
+
+// @Summary		變更廣告狀態
+// @Description	允許的狀態轉換: draft → scheduled → active ⇄ paused → archived
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		id path int true "廣告 id"
+// @Param		request body handlers.AdvertisementStatus true "新的狀態"
+// @Produce		json
+// @Tags		advertisement
+// @Router		/ad/{id}/status [patch]
+func (handler *Handler) UpdateAdvertisementStatusHandler(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil || id < 1 {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid id value"))
+		return
+	}
+
+	body := AdvertisementStatus{}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+	if !isAdvertisementStatus(body.Status) {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid status value"))
+		return
+	}
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	ad, err := handler.databaseQueries.GetAdvertisementStatus(dbCtx, int32(id))
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, "advertisement not found"))
+		return
+	}
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+
+	current := ad.Status
+	if err := validateStatusTransition(current, body.Status); err != nil {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+	// scheduled 要等到 startAt 才能 active (之後由 ActivateScheduledAdvertisements 自動轉換)
+	if current == AdvertisementStatusScheduled && body.Status == AdvertisementStatusActive && ad.StartAt.After(time.Now()) {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, "invalid status transition (startAt has not passed yet)"))
+		return
+	}
+
+	// 只有狀態仍是 current 時才更新, 避免覆蓋同時進行的其他轉換
+	affected, err := handler.databaseQueries.UpdateAdvertisementStatus(dbCtx, sqlc.UpdateAdvertisementStatusParams{
+		ID:            int32(id),
+		Status:        body.Status,
+		CurrentStatus: current,
+	})
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	if affected == 0 {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, "status was changed concurrently, please retry"))
+		return
+	}
+
+	handler.invalidateAdvertisements(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":     id,
+		"status": body.Status,
+	})
+}
+
+// 把 startAt 已經到了的 scheduled 廣告轉成 active, 回傳轉換的數量
+func (handler *Handler) ActivateScheduledAdvertisements(ctx context.Context) (int64, error) {
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	affected, err := handler.databaseQueries.ActivateScheduledAdvertisements(dbCtx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		handler.invalidateAdvertisements(ctx)
+	}
+	return affected, nil
+}
+
+// 把 endAt 已經過了的 active/paused 廣告轉成 archived, 回傳轉換的數量
+func (handler *Handler) ArchiveExpiredAdvertisements(ctx context.Context) (int64, error) {
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	affected, err := handler.databaseQueries.ArchiveExpiredAdvertisements(dbCtx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		handler.invalidateAdvertisements(ctx)
+	}
+	return affected, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	testCases := []struct {
+		from          string
+		to            string
+		expectedError bool
+	}{
+		{from: "draft", to: "scheduled", expectedError: false},
+		{from: "scheduled", to: "active", expectedError: false},
+		{from: "active", to: "paused", expectedError: false},
+		{from: "paused", to: "active", expectedError: false},
+		{from: "paused", to: "archived", expectedError: false},
+		{from: "draft", to: "active", expectedError: true},
+		{from: "scheduled", to: "draft", expectedError: true},
+		{from: "active", to: "archived", expectedError: true},
+		{from: "active", to: "active", expectedError: true},
+		{from: "archived", to: "active", expectedError: true},
+		{from: "archived", to: "draft", expectedError: true},
+		{from: "paused", to: "deleted", expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.from+" -> "+tc.to, func(t *testing.T) {
+			err := validateStatusTransition(tc.from, tc.to)
+			if tc.expectedError && err == nil {
+				t.Errorf("expected error, but got nil")
+			}
+			if !tc.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateAdvertisementStatusHandler_badRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &Handler{}
+	router := gin.New()
+	router.PATCH("/ad/:id/status", handler.UpdateAdvertisementStatusHandler)
+
+	testCases := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "invalid id", path: "/ad/abc/status", body: `{"status":"paused"}`},
+		{name: "zero id", path: "/ad/0/status", body: `{"status":"paused"}`},
+		{name: "missing status", path: "/ad/1/status", body: `{}`},
+		{name: "invalid status", path: "/ad/1/status", body: `{"status":"deleted"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(tc.body)))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got: %d", http.StatusBadRequest, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateAdvertisementStatusHandler_startAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, db, _ := newTestHandler(t)
+	router := gin.New()
+	router.PATCH("/ad/:id/status", handler.UpdateAdvertisementStatusHandler)
+
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(time.Hour), EndAt: now.Add(2 * time.Hour), Status: AdvertisementStatusScheduled},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Status: AdvertisementStatusScheduled},
+	}
+	for _, ad := range ads {
+		if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{path: "/ad/1/status", expectedCode: http.StatusConflict, expectedBody: `{"error":"invalid status transition (startAt has not passed yet)"}`},
+		{path: "/ad/2/status", expectedCode: http.StatusOK, expectedBody: `{"id":2,"status":"active"}`},
+	}
+	for _, tc := range testCases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(`{"status":"active"}`)))
+		if recorder.Code != tc.expectedCode || recorder.Body.String() != tc.expectedBody {
+			t.Errorf("%s: expected %d %s, got: %d %s", tc.path, tc.expectedCode, tc.expectedBody, recorder.Code, recorder.Body.String())
+		}
+	}
+}
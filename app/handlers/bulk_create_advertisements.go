@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+const (
+	bulkMaxRows         = 10000
+	bulkInsertBatchSize = 100
+	bulkMaxLineBytes    = 1 << 20
+)
+
+const (
+	bulkFormatJSONL = "jsonl"
+	bulkFormatCSV   = "csv"
+)
+
+const (
+	bulkRowStatusValid   = "valid"
+	bulkRowStatusCreated = "created"
+	bulkRowStatusInvalid = "invalid"
+	bulkRowStatusFailed  = "failed"
+)
+
+// CSV 欄位 (conditions/variants 以 JSON array, creative/localizations 以 JSON object 表示, 前三個欄位必填)
+var advertisementCSVHeader = []string{"title", "startAt", "endAt", "conditions", "status", "creative", "variants", "localizations"}
+
+type BulkRowResult struct {
+	Row    int    `json:"row" example:"1"`
+	Status string `json:"status" example:"created" enums:"valid,created,invalid,failed"`
+	ID     *int64 `json:"id,omitempty" example:"42"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkCreateAdvertisementsResponse struct {
+	DryRun  bool            `json:"dryRun"`
+	Total   int             `json:"total"`
+	Valid   int             `json:"valid"`
+	Created int             `json:"created"`
+	Invalid int             `json:"invalid"`
+	Failed  int             `json:"failed"`
+	Results []BulkRowResult `json:"results"`
+}
+
+// 解析後的單筆資料 (err != nil 代表該列無法解析)
+type bulkRow struct {
+	row int
+	ad  Advertisement
+	err error
+}
+
+// @Summary		批次產⽣廣告資源
+// @Description	接受 JSONL (每行一個 Advertisement) 或 CSV (title,startAt,endAt,conditions,status,creative,variants,localizations) 格式, 每一列各自驗證後分批寫入, 並回傳每一列的結果
+// @BasePath	/api/v1
+// @Version		1.0
+// @Accept		application/x-ndjson,text/csv
+// @Param		format query string false "輸入格式 (預設依 Content-Type 判斷)" Enums(jsonl, csv)
+// @Param		dryRun query bool false "只驗證不寫入"
+// @Produce		json
+// @Success		200 {object} handlers.BulkCreateAdvertisementsResponse
+// @Tags		advertisement
+// @Router		/ads:bulk [post]
+func (handler *Handler) BulkCreateAdvertisementsHandler(ctx *gin.Context) {
+	format, err := bulkFormat(ctx.Query("format"), ctx.ContentType())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	dryRun := false
+	if value := ctx.Query("dryRun"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid dryRun value (must be true/false)"))
+			return
+		}
+	}
+
+	var rows []bulkRow
+	switch format {
+	case bulkFormatCSV:
+		rows, err = parseAdvertisementsCSV(ctx.Request.Body)
+	default:
+		rows, err = parseAdvertisementsJSONL(ctx.Request.Body)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	response := BulkCreateAdvertisementsResponse{
+		DryRun:  dryRun,
+		Total:   len(rows),
+		Results: make([]BulkRowResult, len(rows)),
+	}
+
+	// validate every row, keep the valid ones (index into rows) for insertion
+	valid := make([]int, 0, len(rows))
+	for i, row := range rows {
+		response.Results[i].Row = row.row
+		if row.err == nil {
+			row.err = handler.validateAdvertisement(row.ad)
+		}
+		if row.err != nil {
+			response.Results[i].Status = bulkRowStatusInvalid
+			response.Results[i].Error = row.err.Error()
+			continue
+		}
+		response.Results[i].Status = bulkRowStatusValid
+		valid = append(valid, i)
+	}
+
+	if !dryRun {
+		for start := 0; start < len(valid); start += bulkInsertBatchSize {
+			end := min(start+bulkInsertBatchSize, len(valid))
+			handler.insertAdvertisementBatch(ctx, rows, valid[start:end], response.Results)
+		}
+	}
+
+	for _, result := range response.Results {
+		switch result.Status {
+		case bulkRowStatusValid:
+			response.Valid++
+		case bulkRowStatusCreated:
+			response.Created++
+		case bulkRowStatusInvalid:
+			response.Invalid++
+		case bulkRowStatusFailed:
+			response.Failed++
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// 在同一個 transaction 寫入一批 advertisement, 失敗時整批標記為 failed
+func (handler *Handler) insertAdvertisementBatch(ctx *gin.Context, rows []bulkRow, batch []int, results []BulkRowResult) {
+	markFailed := func(err error) {
+		slog.ErrorContext(ctx, "database error", "error", err)
+		for _, i := range batch {
+			results[i].Status = bulkRowStatusFailed
+			results[i].ID = nil
+			results[i].Error = "database error"
+		}
+	}
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	ids := make([]int64, len(batch))
+	err := handler.databaseQueries.InTx(dbCtx, func(queries sqlc.Querier) error {
+		for j, i := range batch {
+			id, err := handler.insertAdvertisement(dbCtx, queries, rows[i].ad)
+			if err != nil {
+				return err
+			}
+			ids[j] = id
+		}
+		return nil
+	})
+	if err != nil {
+		markFailed(err)
+		return
+	}
+	for _, i := range batch {
+		handler.addTerms(rows[i].ad)
+	}
+
+	for j, i := range batch {
+		results[i].Status = bulkRowStatusCreated
+		results[i].ID = &ids[j]
+	}
+}
+
+// 決定輸入格式: format query parameter 優先, 否則依 Content-Type 判斷 (預設 JSONL)
+func bulkFormat(format string, contentType string) (string, error) {
+	switch format {
+	case bulkFormatJSONL, bulkFormatCSV:
+		return format, nil
+	case "":
+	default:
+		return "", errors.New("invalid format value (must be jsonl or csv)")
+	}
+
+	if contentType == "text/csv" {
+		return bulkFormatCSV, nil
+	}
+	return bulkFormatJSONL, nil
+}
+
+// 解析 JSONL, 每一行是一個 Advertisement (空行略過)
+func parseAdvertisementsJSONL(r io.Reader) ([]bulkRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), bulkMaxLineBytes)
+
+	rows := make([]bulkRow, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == bulkMaxRows {
+			return nil, fmt.Errorf("too many rows (must be <= %d)", bulkMaxRows)
+		}
+
+		row := bulkRow{row: line}
+		if err := json.Unmarshal(text, &row.ad); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid JSONL body: %v", err)
+	}
+
+	return rows, nil
+}
+
+// 解析 CSV, 第一列必須是 header (title,startAt,endAt,conditions,status,creative,variants,localizations, 順序不限)
+func parseAdvertisementsCSV(r io.Reader) ([]bulkRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []bulkRow{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range advertisementCSVHeader[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid CSV header: missing column '%s'", name)
+		}
+	}
+
+	rows := make([]bulkRow, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) == bulkMaxRows {
+			return nil, fmt.Errorf("too many rows (must be <= %d)", bulkMaxRows)
+		}
+
+		row := bulkRow{row: line}
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			row.err = fmt.Errorf("invalid CSV: %v", parseError.Err)
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CSV body: %v", err)
+		} else {
+			row.ad, row.err = advertisementFromCSVRecord(columns, record)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func advertisementFromCSVRecord(columns map[string]int, record []string) (Advertisement, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var ad Advertisement
+	var err error
+
+	ad.Title = field("title")
+
+	if value := field("startAt"); value != "" {
+		ad.StartAt, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return ad, errors.New("invalid startAt value (must be RFC 3339)")
+		}
+	}
+
+	if value := field("endAt"); value != "" {
+		ad.EndAt, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return ad, errors.New("invalid endAt value (must be RFC 3339)")
+		}
+	}
+
+	ad.Status = field("status")
+
+	if value := field("creative"); value != "" {
+		if err := json.Unmarshal([]byte(value), &ad.Creative); err != nil {
+			return ad, errors.New("invalid creative value (must be a JSON object)")
+		}
+	}
+
+	if value := field("variants"); value != "" {
+		if err := json.Unmarshal([]byte(value), &ad.Variants); err != nil {
+			return ad, errors.New("invalid variants value (must be a JSON array)")
+		}
+	}
+
+	if value := field("localizations"); value != "" {
+		if err := json.Unmarshal([]byte(value), &ad.Localizations); err != nil {
+			return ad, errors.New("invalid localizations value (must be a JSON object)")
+		}
+	}
+
+	if value := field("conditions"); value != "" {
+		if err := json.Unmarshal([]byte(value), &ad.Conditions); err != nil {
+			return ad, errors.New("invalid conditions value (must be a JSON array)")
+		}
+	}
+
+	return ad, nil
+}
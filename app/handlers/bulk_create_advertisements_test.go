@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkFormat(t *testing.T) {
+	testCases := []struct {
+		name           string
+		format         string
+		contentType    string
+		expectedFormat string
+		expectedError  bool
+	}{
+		{name: "default", expectedFormat: bulkFormatJSONL},
+		{name: "csv content type", contentType: "text/csv", expectedFormat: bulkFormatCSV},
+		{name: "ndjson content type", contentType: "application/x-ndjson", expectedFormat: bulkFormatJSONL},
+		{name: "format overrides content type", format: "jsonl", contentType: "text/csv", expectedFormat: bulkFormatJSONL},
+		{name: "csv format", format: "csv", expectedFormat: bulkFormatCSV},
+		{name: "invalid format", format: "xml", expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := bulkFormat(tc.format, tc.contentType)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if format != tc.expectedFormat {
+				t.Errorf("expected: %v, got: %v", tc.expectedFormat, format)
+			}
+		})
+	}
+}
+
+func TestParseAdvertisementsJSONL(t *testing.T) {
+	body := strings.Join([]string{
+		`{"title":"AD 1","startAt":"2023-12-10T03:00:00.000Z","endAt":"2023-12-31T16:00:00.000Z","conditions":[{"ageStart":20,"country":["TW"]}]}`,
+		``,
+		`{"title":"AD 2",`,
+		`{"title":"AD 3","startAt":"2023-12-10T03:00:00Z","endAt":"2023-12-31T16:00:00Z"}`,
+	}, "\n")
+
+	rows, err := parseAdvertisementsJSONL(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got: %d", len(rows))
+	}
+
+	// row numbers are line numbers (blank lines are skipped but counted)
+	if rows[0].row != 1 || rows[1].row != 3 || rows[2].row != 4 {
+		t.Errorf("unexpected row numbers: %d, %d, %d", rows[0].row, rows[1].row, rows[2].row)
+	}
+	if rows[0].err != nil || rows[0].ad.Title != "AD 1" || len(rows[0].ad.Conditions) != 1 || *rows[0].ad.Conditions[0].AgeStart != 20 {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].err == nil {
+		t.Errorf("expected error for malformed line, but got nil")
+	}
+	if rows[2].err != nil || rows[2].ad.Title != "AD 3" {
+		t.Errorf("unexpected third row: %+v", rows[2])
+	}
+}
+
+func TestParseAdvertisementsCSV(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		expectedRows  int
+		expectedError bool
+		rowErrors     []bool
+	}{
+		{
+			name: "valid",
+			body: "title,startAt,endAt,conditions\n" +
+				`AD 1,2023-12-10T03:00:00Z,2023-12-31T16:00:00Z,"[{""gender"":[""M""]}]"` + "\n" +
+				"AD 2,2023-12-10T03:00:00Z,2023-12-31T16:00:00Z,\n",
+			expectedRows: 2,
+			rowErrors:    []bool{false, false},
+		},
+		{
+			name: "columns in any order, conditions optional",
+			body: "endAt,title,startAt\n" +
+				"2023-12-31T16:00:00Z,AD 1,2023-12-10T03:00:00Z\n",
+			expectedRows: 1,
+			rowErrors:    []bool{false},
+		},
+		{
+			name: "invalid rows",
+			body: "title,startAt,endAt,conditions\n" +
+				"AD 1,yesterday,2023-12-31T16:00:00Z,\n" +
+				"AD 2,2023-12-10T03:00:00Z,2023-12-31T16:00:00Z,{}\n",
+			expectedRows: 2,
+			rowErrors:    []bool{true, true},
+		},
+		{
+			name:          "missing column",
+			body:          "title,startAt\nAD 1,2023-12-10T03:00:00Z\n",
+			expectedError: true,
+		},
+		{
+			name:         "empty",
+			body:         "",
+			expectedRows: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := parseAdvertisementsCSV(strings.NewReader(tc.body))
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rows) != tc.expectedRows {
+				t.Fatalf("expected %d rows, got: %d", tc.expectedRows, len(rows))
+			}
+			for i, row := range rows {
+				if (row.err != nil) != tc.rowErrors[i] {
+					t.Errorf("row %d: unexpected error state: %v", row.row, row.err)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_BulkCreateAdvertisementsHandler_dryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &Handler{
+		genderSet:       mapset.NewSet("M", "F"),
+		countrySet:      mapset.NewSet("TW", "US", "JP"),
+		countryGroupSet: mapset.NewSet("EU"),
+		platformSet:     mapset.NewSet("android", "ios", "web"),
+	}
+	router := gin.New()
+	router.POST("/ads:bulk", handler.BulkCreateAdvertisementsHandler)
+
+	body := strings.Join([]string{
+		`{"title":"AD 1","startAt":"2023-12-10T03:00:00Z","endAt":"2023-12-31T16:00:00Z","conditions":[{"country":["TW"]}]}`,
+		`{"title":"AD 2","startAt":"2023-12-10T03:00:00Z","endAt":"2023-12-31T16:00:00Z","conditions":[{"country":["AA"]}]}`,
+		`{"title":"AD 3","startAt":"2023-12-31T16:00:00Z","endAt":"2023-12-10T03:00:00Z"}`,
+		`{"startAt":"2023-12-10T03:00:00Z","endAt":"2023-12-31T16:00:00Z"}`,
+	}, "\n")
+	request := httptest.NewRequest(http.MethodPost, "/ads:bulk?dryRun=true", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got: %d (%s)", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+
+	var response BulkCreateAdvertisementsResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !response.DryRun || response.Total != 4 || response.Valid != 1 || response.Invalid != 3 || response.Created != 0 {
+		t.Errorf("unexpected summary: %+v", response)
+	}
+
+	expected := []struct {
+		status string
+		error  string
+	}{
+		{bulkRowStatusValid, ""},
+		{bulkRowStatusInvalid, "invalid country value"},
+		{bulkRowStatusInvalid, "invalid endAt value (must be >= startAt)"},
+		{bulkRowStatusInvalid, "invalid title value (must not be empty)"},
+	}
+	for i, result := range response.Results {
+		if result.Row != i+1 || result.Status != expected[i].status || result.Error != expected[i].error {
+			t.Errorf("row %d: expected %+v, got: %+v", i+1, expected[i], result)
+		}
+	}
+}
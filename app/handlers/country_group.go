@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// country group 的 code 與 country code 放在同一個欄位 (AdvertisementCondition.Country), 不能重複
+var countryGroupPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+type CountryGroup struct {
+	Code      string   `json:"code" example:"DACH" extensions:"x-order=0"`
+	Name      string   `json:"name" example:"Germany, Austria and Switzerland" extensions:"x-order=1"`
+	Builtin   bool     `json:"builtin" extensions:"x-order=2"`
+	Countries []string `json:"countries" example:"DE,AT,CH" swaggertype:"array,string" extensions:"x-order=3"`
+}
+
+type CountryGroupRequest struct {
+	Name      string   `json:"name" example:"Germany, Austria and Switzerland" extensions:"x-order=0"`
+	Countries []string `json:"countries" example:"DE,AT,CH" swaggertype:"array,string" extensions:"x-order=1"`
+}
+
+var (
+	errCountryGroupBuiltin = errors.New("country group is built-in")
+	errCountryGroupInUse   = errors.New("country group in use")
+)
+
+// @Summary		列出 country groups 與它們的 countries
+// @BasePath	/api/v1
+// @Version		1.0
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/country-groups [get]
+func (handler *Handler) ListCountryGroupsHandler(ctx *gin.Context) {
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	rows, err := handler.databaseQueries.ListCountryGroupMembers(dbCtx)
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+
+	// rows 依 group code 排序, 沒有 member 的 group 只有一列 (country 為 NULL)
+	groups := []CountryGroup{}
+	for _, row := range rows {
+		if len(groups) == 0 || groups[len(groups)-1].Code != row.Code {
+			groups = append(groups, CountryGroup{Code: row.Code, Name: row.Name, Builtin: row.Builtin, Countries: []string{}})
+		}
+		if row.Country.Valid {
+			group := &groups[len(groups)-1]
+			group.Countries = append(group.Countries, row.Country.String)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": groups,
+	})
+}
+
+// 從 path 取得 country group 的 code, 不合法時直接回應 400
+func countryGroupFromPath(ctx *gin.Context) (string, bool) {
+	code := ctx.Param("code")
+	if !countryGroupPattern.MatchString(code) {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, fmt.Sprintf("invalid country group value (must match %s)", countryGroupPattern)))
+		return "", false
+	}
+	return code, true
+}
+
+// @Summary		新增或取代自訂的 country group
+// @Description	members 在比對時才展開, 已經使用這個 group 的廣告馬上套用新的 countries; built-in groups 不能修改 (409)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		code path string true "country group 的 code (不能與 country code 相同)" example(DACH)
+// @Param		request body handlers.CountryGroupRequest true "name 與 countries"
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/country-groups/{code} [put]
+func (handler *Handler) PutCountryGroupHandler(ctx *gin.Context) {
+	code, ok := countryGroupFromPath(ctx)
+	if !ok {
+		return
+	}
+	if handler.countrySet.Contains(code) {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("country group %s conflicts with a country code", code)))
+		return
+	}
+
+	body := CountryGroupRequest{}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+	if body.Name == "" || len(body.Name) > 255 {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid name value (must be 1 ~ 255 characters)"))
+		return
+	}
+	if len(body.Countries) == 0 {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid countries value (must not be empty)"))
+		return
+	}
+	// 只能包含 country (不能包含其他 group)
+	for _, country := range body.Countries {
+		if !handler.countrySet.Contains(country) {
+			ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid country value"))
+			return
+		}
+	}
+	slices.Sort(body.Countries)
+	body.Countries = slices.Compact(body.Countries)
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	err := handler.databaseQueries.InTx(dbCtx, func(q sqlc.Querier) error {
+		builtin, err := q.GetCountryGroupBuiltin(dbCtx, code)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if builtin {
+			return errCountryGroupBuiltin
+		}
+		if err := q.UpsertCountryGroup(dbCtx, sqlc.UpsertCountryGroupParams{Code: code, Name: body.Name}); err != nil {
+			return err
+		}
+		if err := q.DeleteCountryGroupMembers(dbCtx, code); err != nil {
+			return err
+		}
+		for _, country := range body.Countries {
+			if err := q.CreateCountryGroupMember(dbCtx, sqlc.CreateCountryGroupMemberParams{CountryGroup: code, Country: country}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errCountryGroupBuiltin) {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("country group %s is built-in", code)))
+		return
+	}
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	handler.countryGroupSet.Add(code)
+
+	// 已經使用這個 group 的廣告的比對結果可能改變
+	handler.invalidateAdvertisements(ctx)
+
+	ctx.JSON(http.StatusOK, CountryGroup{Code: code, Name: body.Name, Countries: body.Countries})
+}
+
+// @Summary		刪除自訂的 country group
+// @Description	built-in groups 與還有條件使用的 group 不能刪除 (409)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		code path string true "country group 的 code" example(DACH)
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/country-groups/{code} [delete]
+func (handler *Handler) DeleteCountryGroupHandler(ctx *gin.Context) {
+	code, ok := countryGroupFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	var count int64
+	err := handler.databaseQueries.InTx(dbCtx, func(q sqlc.Querier) error {
+		builtin, err := q.GetCountryGroupBuiltin(dbCtx, code)
+		if err != nil {
+			return err
+		}
+		if builtin {
+			return errCountryGroupBuiltin
+		}
+		count, err = q.CountConditionsUsingCountryGroup(dbCtx, code)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errCountryGroupInUse
+		}
+		if err := q.DeleteCountryGroupMembers(dbCtx, code); err != nil {
+			return err
+		}
+		_, err = q.DeleteCountryGroup(dbCtx, code)
+		return err
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, "country group not found"))
+		return
+	case errors.Is(err, errCountryGroupBuiltin):
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("country group %s is built-in", code)))
+		return
+	case errors.Is(err, errCountryGroupInUse):
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("country group %s is used by %d conditions", code, count)))
+		return
+	case err != nil:
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	handler.countryGroupSet.Remove(code)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    code,
+		"deleted": true,
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_CountryGroupHandlers(t *testing.T) {
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Country: []string{"GCC"}}}},
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		storeFailures map[string]error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{name: "list", method: http.MethodGet, target: "/admin/country-groups", expectedCode: http.StatusOK, expectedBody: `{"code":"DACH","name":"Germany, Austria and Switzerland","builtin":true,"countries":["AT","CH","DE"]}`},
+		{name: "add", method: http.MethodPut, target: "/admin/country-groups/BENELUX", body: `{"name":"Benelux","countries":["NL","BE","LU","BE"]}`, expectedCode: http.StatusOK, expectedBody: `{"code":"BENELUX","name":"Benelux","builtin":false,"countries":["BE","LU","NL"]}`},
+		{name: "invalid code", method: http.MethodPut, target: "/admin/country-groups/benelux", body: `{"name":"Benelux","countries":["NL"]}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid country group value (must match ^[A-Z][A-Z0-9_]{1,31}$)"}`},
+		{name: "country code", method: http.MethodPut, target: "/admin/country-groups/TW", body: `{"name":"Taiwan","countries":["TW"]}`, expectedCode: http.StatusConflict, expectedBody: `{"error":"country group TW conflicts with a country code"}`},
+		{name: "without countries", method: http.MethodPut, target: "/admin/country-groups/BENELUX", body: `{"name":"Benelux"}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid countries value (must not be empty)"}`},
+		{name: "nested group", method: http.MethodPut, target: "/admin/country-groups/EMEA", body: `{"name":"EMEA","countries":["EU","GCC"]}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid country value"}`},
+		{name: "modify built-in", method: http.MethodPut, target: "/admin/country-groups/EU", body: `{"name":"EU","countries":["FR"]}`, expectedCode: http.StatusConflict, expectedBody: `{"error":"country group EU is built-in"}`},
+		{name: "delete built-in", method: http.MethodDelete, target: "/admin/country-groups/EU", expectedCode: http.StatusConflict, expectedBody: `{"error":"country group EU is built-in"}`},
+		{name: "delete unknown", method: http.MethodDelete, target: "/admin/country-groups/BENELUX", expectedCode: http.StatusNotFound, expectedBody: `{"error":"country group not found"}`},
+		{name: "add country with group code", method: http.MethodPut, target: "/admin/countries/EU", body: `{"name":"Europe"}`, expectedCode: http.StatusConflict, expectedBody: `{"error":"country EU conflicts with a country group code"}`},
+		{
+			name:          "database error",
+			method:        http.MethodPut,
+			target:        "/admin/country-groups/BENELUX",
+			body:          `{"name":"Benelux","countries":["NL"]}`,
+			storeFailures: map[string]error{"InTx": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"database error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for method, err := range tc.storeFailures {
+				db.failures[method] = err
+			}
+
+			recorder := serveAdmin(handler, tc.method, tc.target, tc.body)
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), tc.expectedBody) {
+				t.Errorf("expected body to contain: %s, got: %s", tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_CountryGroupHandlers_matching(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+
+	// 自訂的 group 馬上可以在條件中使用, 修改 members 後已經存在的廣告也跟著改變
+	if recorder := serveAdmin(handler, http.MethodPut, "/admin/country-groups/ISLANDS", `{"name":"Islands","countries":["TW","JP"]}`); recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d (%s)", recorder.Code, recorder.Body.String())
+	}
+	ad := Advertisement{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Country: []string{"ISLANDS", "US"}}}}
+	if err := handler.validateAdvertisement(ad); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	matches := func(country string) bool {
+		t.Helper()
+		recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?country="+country, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d (%s)", recorder.Code, recorder.Body.String())
+		}
+		return strings.Contains(recorder.Body.String(), "AD 1")
+	}
+	if !matches("JP") || !matches("US") || matches("KR") {
+		t.Error("expected AD 1 for JP and US only")
+	}
+
+	serveAdmin(handler, http.MethodPut, "/admin/country-groups/ISLANDS", `{"name":"Islands","countries":["KR"]}`)
+	if matches("JP") || !matches("KR") {
+		t.Error("expected AD 1 for KR after the update")
+	}
+
+	recorder := serveAdmin(handler, http.MethodDelete, "/admin/country-groups/ISLANDS", "")
+	if expected := `{"error":"country group ISLANDS is used by 1 conditions"}`; recorder.Body.String() != expected {
+		t.Errorf("expected body: %s, got: %s", expected, recorder.Body.String())
+	}
+}
@@ -1,32 +1,67 @@
 package handlers
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
+	"regexp"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/geo"
+	"github.com/lnfu/dcard-intern/app/logging"
 	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/term"
 
 	"github.com/lnfu/dcard-intern/app/utils"
 )
 
 type Advertisement struct {
-	Title      string                   `json:"title" binding:"required" example:"AD 55" extensions:"x-order=0"`
-	StartAt    time.Time                `json:"startAt" binding:"required" example:"2023-12-10T03:00:00.000Z" extensions:"x-order=1"`
-	EndAt      time.Time                `json:"endAt" binding:"required" example:"2023-12-31T16:00:00.000Z" extensions:"x-order=2"`
-	Conditions []AdvertisementCondition `json:"conditions" extensions:"x-order=3"`
+	Title         string                   `json:"title" binding:"required" example:"AD 55" extensions:"x-order=0"`
+	StartAt       time.Time                `json:"startAt" binding:"required" example:"2023-12-10T03:00:00.000Z" extensions:"x-order=1"`
+	EndAt         time.Time                `json:"endAt" binding:"required" example:"2023-12-31T16:00:00.000Z" extensions:"x-order=2"`
+	Status        string                   `json:"status,omitempty" example:"scheduled" enums:"draft,scheduled" extensions:"x-order=3"`
+	Creative      *Creative                `json:"creative,omitempty" extensions:"x-order=4"`
+	Localizations map[string]Localization  `json:"localizations,omitempty" extensions:"x-order=5"`
+	Variants      []Variant                `json:"variants,omitempty" extensions:"x-order=6"`
+	Conditions    []AdvertisementCondition `json:"conditions" extensions:"x-order=7"`
 }
 
 type AdvertisementCondition struct {
-	AgeStart *int32   `json:"ageStart,omitempty" example:"20" swaggertype:"integer" extensions:"x-order=0"`
-	AgeEnd   *int32   `json:"ageEnd,omitempty" example:"30" swaggertype:"integer" extensions:"x-order=1"`
-	Gender   []string `json:"gender,omitempty" example:"M" swaggertype:"array,string" extensions:"x-order=2"`
-	Country  []string `json:"country,omitempty" example:"TW,JP" swaggertype:"array,string" extensions:"x-order=3"`
-	Platform []string `json:"platform,omitempty" example:"android,ios" swaggertype:"array,string" extensions:"x-order=4"`
+	AgeStart *int32     `json:"ageStart,omitempty" example:"20" swaggertype:"integer" extensions:"x-order=0"`
+	AgeEnd   *int32     `json:"ageEnd,omitempty" example:"30" swaggertype:"integer" extensions:"x-order=1"`
+	Gender   []string   `json:"gender,omitempty" example:"M" swaggertype:"array,string" extensions:"x-order=2"`
+	Country  []string   `json:"country,omitempty" example:"TW,JP" swaggertype:"array,string" extensions:"x-order=3"` // country 或 country group (例如 EU) 的 code
+	Platform []string   `json:"platform,omitempty" example:"android,ios" swaggertype:"array,string" extensions:"x-order=4"`
+	Region   []string   `json:"region,omitempty" example:"TW-TPE,TW-NWT" swaggertype:"array,string" extensions:"x-order=5"` // ISO 3166-2 subdivision
+	Geofence []Geofence `json:"geofence,omitempty" extensions:"x-order=6"`
+	Segment  []string   `json:"segment,omitempty" example:"gamer" swaggertype:"array,string" extensions:"x-order=7"` // audience segment 的 code
+	Keyword  []string   `json:"keyword,omitempty" example:"switch,ps5" swaggertype:"array,string" extensions:"x-order=8"`
+	Category []string   `json:"category,omitempty" example:"game" swaggertype:"array,string" extensions:"x-order=9"` // 看板/分類
 }
 
+// 以 (lat, lng) 為圓心, 半徑 radius 公尺的範圍
+type Geofence struct {
+	Lat    float64 `json:"lat" example:"25.034" extensions:"x-order=0"`
+	Lng    float64 `json:"lng" example:"121.5645" extensions:"x-order=1"`
+	Radius int32   `json:"radius" example:"2000" extensions:"x-order=2"`
+}
+
+const (
+	minGeofenceRadius = 100
+	maxGeofenceRadius = 50000
+	// 圓心的緯度上限 (越靠近極點 cell 越窄, 距離的近似也越不準)
+	maxGeofenceLat = 80
+	// 一個條件最多的 keywords/categories (正規化並去除重複後)
+	maxConditionKeywords   = 100
+	maxConditionCategories = 20
+)
+
+// ISO 3166-2 subdivision code (前兩碼是 country code)
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}-[A-Z0-9]{1,3}$`)
+
 // @Summary		產⽣廣告資源
 // @BasePath	/api/v1
 // @Version		1.0
@@ -39,99 +74,301 @@ func (handler *Handler) CreateAdvertisementHandler(ctx *gin.Context) {
 	body := Advertisement{}
 	err := ctx.BindJSON(&body)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
 		return
 	}
 
-	// TODO validate startAt <= endAt
+	if err := handler.validateAdvertisement(body); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
 
-	// add ad to database
-	advertisementId, err := handler.databaseQueries.CreateAdvertisement(ctx, sqlc.CreateAdvertisementParams{
-		Title:   body.Title,
-		StartAt: body.StartAt,
-		EndAt:   body.EndAt,
+	// add ad (and its conditions) to database in one transaction
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	err = handler.databaseQueries.InTx(dbCtx, func(queries sqlc.Querier) error {
+		_, err := handler.insertAdvertisement(dbCtx, queries, body)
+		return err
 	})
 	if err != nil {
-		log.Println("Database error:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		respondDependencyError(ctx, "database", err)
 		return
 	}
+	handler.addTerms(body)
 
-	// process conditions
-	for _, condition := range body.Conditions {
-		if err := handler.validateCondition(condition); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// commit 之後把 conditions 的 keywords/categories 加入 keywordSet/categorySet (其他 replica 由 ReloadReferenceData 讀取)
+func (handler *Handler) addTerms(ads ...Advertisement) {
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	for _, ad := range ads {
+		for _, condition := range ad.Conditions {
+			handler.keywordSet.Append(term.Terms(condition.Keyword)...)
+			handler.categorySet.Append(term.Terms(condition.Category)...)
+		}
+	}
+}
+
+// 將 advertisement 與其 conditions 寫入 database, 回傳 advertisement id
+func (handler *Handler) insertAdvertisement(ctx context.Context, queries sqlc.Querier, ad Advertisement) (int64, error) {
+	// 沒有指定 status 時, startAt 還沒到的是 scheduled (由 ActivateScheduledAdvertisements 轉成 active)
+	status := ad.Status
+	if status == "" {
+		status = AdvertisementStatusActive
+		if ad.StartAt.After(time.Now()) {
+			status = AdvertisementStatusScheduled
 		}
+	}
 
+	creative := Creative{}
+	if ad.Creative != nil {
+		creative = *ad.Creative
+	}
+	if creative.Format == "" {
+		creative.Format = CreativeFormatText
+	}
+
+	advertisementId, err := queries.CreateAdvertisement(ctx, sqlc.CreateAdvertisementParams{
+		Title:       ad.Title,
+		StartAt:     ad.StartAt,
+		EndAt:       ad.EndAt,
+		Status:      status,
+		Description: creative.Description,
+		ImageUrl:    creative.ImageURL,
+		ImageWidth:  creative.ImageWidth,
+		ImageHeight: creative.ImageHeight,
+		LandingUrl:  creative.LandingURL,
+		CtaLabel:    creative.CTALabel,
+		Format:      creative.Format,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for locale, localization := range ad.Localizations {
+		// add localization
+		err = queries.CreateAdvertisementLocalization(ctx, sqlc.CreateAdvertisementLocalizationParams{
+			AdvertisementID: int32(advertisementId),
+			Locale:          locale,
+			Title:           localization.Title,
+			Description:     localization.Description,
+			CtaLabel:        localization.CTALabel,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, variant := range ad.Variants {
+		// add variant (沒有填的欄位留空, 投放時沿用廣告本身的值)
+		override := Creative{}
+		if variant.Creative != nil {
+			override = *variant.Creative
+		}
+		err = queries.CreateAdvertisementVariant(ctx, sqlc.CreateAdvertisementVariantParams{
+			AdvertisementID: int32(advertisementId),
+			Name:            variant.Name,
+			Weight:          variant.Weight,
+			Title:           variant.Title,
+			Description:     override.Description,
+			ImageUrl:        override.ImageURL,
+			ImageWidth:      override.ImageWidth,
+			ImageHeight:     override.ImageHeight,
+			LandingUrl:      override.LandingURL,
+			CtaLabel:        override.CTALabel,
+			Format:          override.Format,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, condition := range ad.Conditions {
 		// add condition
-		conditionId, err := handler.databaseQueries.CreateCondition(ctx, sqlc.CreateConditionParams{
+		conditionId, err := queries.CreateCondition(ctx, sqlc.CreateConditionParams{
 			AgeStart: utils.NullInt32FromInt32Pointer(condition.AgeStart),
 			AgeEnd:   utils.NullInt32FromInt32Pointer(condition.AgeEnd),
 		})
-
 		if err != nil {
-			log.Println("Database Error:", err.Error())
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-			return
+			return 0, err
 		}
 
+		// add gender-condition relation
 		for _, gender := range condition.Gender {
-
-			// add gender-condition relation
-			err = handler.databaseQueries.CreateConditionGender(ctx, sqlc.CreateConditionGenderParams{
+			err = queries.CreateConditionGender(ctx, sqlc.CreateConditionGenderParams{
 				ConditionID: int32(conditionId),
 				Gender:      gender,
 			})
 			if err != nil {
-				log.Println("Database Error:", err.Error())
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-				return
+				return 0, err
 			}
 		}
 
+		// add country-condition relation (country group 在比對時才展開)
 		for _, country := range condition.Country {
+			if handler.countryGroupSet.Contains(country) {
+				err = queries.CreateConditionCountryGroup(ctx, sqlc.CreateConditionCountryGroupParams{
+					ConditionID:  int32(conditionId),
+					CountryGroup: country,
+				})
+			} else {
+				err = queries.CreateConditionCountry(ctx, sqlc.CreateConditionCountryParams{
+					ConditionID: int32(conditionId),
+					Country:     country,
+				})
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
 
-			// add country-condition relation
-			err = handler.databaseQueries.CreateConditionCountry(ctx, sqlc.CreateConditionCountryParams{
+		// add region-condition relation
+		for _, region := range condition.Region {
+			err = queries.CreateConditionRegion(ctx, sqlc.CreateConditionRegionParams{
 				ConditionID: int32(conditionId),
-				Country:     country,
+				Region:      region,
+				Country:     region[:2],
 			})
 			if err != nil {
-				log.Println("Database Error:", err.Error())
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-				return
+				return 0, err
 			}
 		}
 
-		for _, platform := range condition.Platform {
+		// add geofence-condition relation (與涵蓋圓的 geohash cells)
+		for _, geofence := range condition.Geofence {
+			geofenceId, err := queries.CreateConditionGeofence(ctx, sqlc.CreateConditionGeofenceParams{
+				ConditionID: int32(conditionId),
+				Lat:         geofence.Lat,
+				Lng:         geofence.Lng,
+				Radius:      geofence.Radius,
+			})
+			if err != nil {
+				return 0, err
+			}
+			for _, cell := range geo.Cover(geofence.Lat, geofence.Lng, geofence.Radius) {
+				err = queries.CreateConditionGeofenceCell(ctx, sqlc.CreateConditionGeofenceCellParams{
+					GeofenceID: int32(geofenceId),
+					Geohash:    cell,
+				})
+				if err != nil {
+					return 0, err
+				}
+			}
+		}
 
-			// add platform-condition relation
-			err = handler.databaseQueries.CreateConditionPlatform(ctx, sqlc.CreateConditionPlatformParams{
+		// add platform-condition relation
+		for _, platform := range condition.Platform {
+			err = queries.CreateConditionPlatform(ctx, sqlc.CreateConditionPlatformParams{
 				ConditionID: int32(conditionId),
 				Platform:    platform,
 			})
 			if err != nil {
-				log.Println("Database Error:", err.Error())
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-				return
+				return 0, err
+			}
+		}
+
+		// add segment-condition relation
+		for _, segment := range condition.Segment {
+			err = queries.CreateConditionSegment(ctx, sqlc.CreateConditionSegmentParams{
+				ConditionID: int32(conditionId),
+				Segment:     segment,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		// add keyword/category-condition relation (存正規化後的 terms)
+		for _, keyword := range term.Terms(condition.Keyword) {
+			err = queries.CreateConditionKeyword(ctx, sqlc.CreateConditionKeywordParams{
+				ConditionID: int32(conditionId),
+				Term:        keyword,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+		for _, category := range term.Terms(condition.Category) {
+			err = queries.CreateConditionCategory(ctx, sqlc.CreateConditionCategoryParams{
+				ConditionID: int32(conditionId),
+				Term:        category,
+			})
+			if err != nil {
+				return 0, err
 			}
 		}
 
 		// add condition-advertisement relation
-		err = handler.databaseQueries.CreateAdvertisementCondition(ctx, sqlc.CreateAdvertisementConditionParams{
+		err = queries.CreateAdvertisementCondition(ctx, sqlc.CreateAdvertisementConditionParams{
 			AdvertisementID: int32(advertisementId),
 			ConditionID:     int32(conditionId),
 		})
 		if err != nil {
-			log.Println("Database Error:", err.Error())
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-			return
+			return 0, err
 		}
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-	})
+	return advertisementId, nil
+}
+
+// 判斷 advertisement (時間區間與所有 conditions) 是否 valid
+func (handler *Handler) validateAdvertisement(ad Advertisement) error {
+	// title
+	if ad.Title == "" {
+		return errors.New("invalid title value (must not be empty)")
+	}
+	if utf8.RuneCountInString(ad.Title) > maxTitleLength {
+		return errors.New("invalid title value (must be <= 255 characters)")
+	}
+
+	// startAt/endAt
+	if ad.StartAt.IsZero() {
+		return errors.New("invalid startAt value (must not be empty)")
+	}
+	if ad.EndAt.IsZero() {
+		return errors.New("invalid endAt value (must not be empty)")
+	}
+
+	// startAt <= endAt
+	if ad.EndAt.Before(ad.StartAt) {
+		return errors.New("invalid endAt value (must be >= startAt)")
+	}
+
+	// status: 新增時只能是 draft/scheduled (沒有指定時依 startAt 決定 active 或 scheduled)
+	if ad.Status != "" && ad.Status != AdvertisementStatusDraft && ad.Status != AdvertisementStatusScheduled {
+		return errors.New("invalid status value (must be draft or scheduled)")
+	}
+
+	// creative
+	if ad.Creative != nil {
+		if err := validateCreative(*ad.Creative); err != nil {
+			return err
+		}
+	}
+
+	// localizations
+	if err := handler.validateLocalizations(ad.Localizations); err != nil {
+		return err
+	}
+
+	// variants
+	if err := validateVariants(ad); err != nil {
+		return err
+	}
+
+	// conditions
+	for _, condition := range ad.Conditions {
+		if err := handler.validateCondition(condition); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (handler *Handler) validateCondition(condition AdvertisementCondition) error {
@@ -157,9 +394,9 @@ func (handler *Handler) validateCondition(condition AdvertisementCondition) erro
 		}
 	}
 
-	// country
+	// country (或 country group)
 	for _, country := range condition.Country {
-		if !handler.countrySet.Contains(country) {
+		if !handler.countrySet.Contains(country) && !handler.countryGroupSet.Contains(country) {
 			return errors.New("invalid country value")
 		}
 	}
@@ -171,5 +408,59 @@ func (handler *Handler) validateCondition(condition AdvertisementCondition) erro
 		}
 	}
 
+	// region
+	for _, region := range condition.Region {
+		if !regionPattern.MatchString(region) || !handler.countrySet.Contains(region[:2]) {
+			return errors.New("invalid region value")
+		}
+	}
+
+	// geofence
+	for _, geofence := range condition.Geofence {
+		if geofence.Lat < -maxGeofenceLat || geofence.Lat > maxGeofenceLat || geofence.Lng < -180 || geofence.Lng > 180 {
+			return fmt.Errorf("invalid geofence value (lat must be -%d ~ %d, lng must be -180 ~ 180)", maxGeofenceLat, maxGeofenceLat)
+		}
+		if geofence.Radius < minGeofenceRadius || geofence.Radius > maxGeofenceRadius {
+			return fmt.Errorf("invalid geofence radius value (must be %d ~ %d)", minGeofenceRadius, maxGeofenceRadius)
+		}
+	}
+
+	// segment
+	for _, segment := range condition.Segment {
+		if !handler.segmentSet.Contains(segment) {
+			return errors.New("invalid segment value")
+		}
+	}
+
+	// keyword/category
+	if !validTerms(condition.Keyword) {
+		return fmt.Errorf("invalid keyword value (must be 1 ~ %d characters)", term.MaxLength)
+	}
+	if len(term.Terms(condition.Keyword)) > maxConditionKeywords {
+		return fmt.Errorf("invalid keyword value (must be at most %d)", maxConditionKeywords)
+	}
+	if !validTerms(condition.Category) {
+		return fmt.Errorf("invalid category value (must be 1 ~ %d characters)", term.MaxLength)
+	}
+	if len(term.Terms(condition.Category)) > maxConditionCategories {
+		return fmt.Errorf("invalid category value (must be at most %d)", maxConditionCategories)
+	}
+
 	return nil
 }
+
+// 每個值正規化後至少有一個 term, 且每個 term 不超過 term.MaxLength
+func validTerms(values []string) bool {
+	for _, value := range values {
+		terms := term.Terms([]string{value})
+		if len(terms) == 0 {
+			return false
+		}
+		for _, t := range terms {
+			if utf8.RuneCountInString(t) > term.MaxLength {
+				return false
+			}
+		}
+	}
+	return true
+}
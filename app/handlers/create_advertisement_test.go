@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
 )
 
 func TestHandler_validateCondition(t *testing.T) {
 	handler := Handler{
-		genderSet:   mapset.NewSet("M", "F"),
-		countrySet:  mapset.NewSet("TW", "US", "JP"),
-		platformSet: mapset.NewSet("android", "ios", "web"),
+		genderSet:       mapset.NewSet("M", "F"),
+		countrySet:      mapset.NewSet("TW", "US", "JP"),
+		countryGroupSet: mapset.NewSet("EU"),
+		platformSet:     mapset.NewSet("android", "ios", "web"),
 	}
 
 	testCases := []struct {
@@ -52,6 +59,20 @@ func TestHandler_validateCondition(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "valid condition (country group)",
+			condition: AdvertisementCondition{
+				Country: []string{"EU", "TW"},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid country group",
+			condition: AdvertisementCondition{
+				Country: []string{"APAC"},
+			},
+			expectedError: errors.New("invalid country value"),
+		},
 		{
 			name: "invalid ageStart (zero)",
 			condition: AdvertisementCondition{
@@ -109,6 +130,78 @@ func TestHandler_validateCondition(t *testing.T) {
 			},
 			expectedError: errors.New("invalid platform value"),
 		},
+		{
+			name: "valid region and geofence",
+			condition: AdvertisementCondition{
+				Region:   []string{"TW-TPE", "JP-13"},
+				Geofence: []Geofence{{Lat: 25.034, Lng: 121.5645, Radius: 2000}},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid region",
+			condition: AdvertisementCondition{
+				Region: []string{"TW-TPE", "XX-01"},
+			},
+			expectedError: errors.New("invalid region value"),
+		},
+		{
+			name: "invalid geofence center",
+			condition: AdvertisementCondition{
+				Geofence: []Geofence{{Lat: 85, Lng: 0, Radius: 2000}},
+			},
+			expectedError: errors.New("invalid geofence value (lat must be -80 ~ 80, lng must be -180 ~ 180)"),
+		},
+		{
+			name: "invalid geofence radius",
+			condition: AdvertisementCondition{
+				Geofence: []Geofence{{Lat: 25.034, Lng: 121.5645, Radius: 50001}},
+			},
+			expectedError: errors.New("invalid geofence radius value (must be 100 ~ 50000)"),
+		},
+		{
+			name: "valid keyword and category",
+			condition: AdvertisementCondition{
+				Keyword:  []string{"Nintendo Ｓｗｉｔｃｈ", "遊戲,ps5"},
+				Category: []string{"game"},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "empty keyword",
+			condition: AdvertisementCondition{
+				Keyword: []string{"switch", "　"},
+			},
+			expectedError: errors.New("invalid keyword value (must be 1 ~ 64 characters)"),
+		},
+		{
+			name: "too long category",
+			condition: AdvertisementCondition{
+				Category: []string{strings.Repeat("看板", 33)},
+			},
+			expectedError: errors.New("invalid category value (must be 1 ~ 64 characters)"),
+		},
+		{
+			name: "max keywords (after normalization)",
+			condition: AdvertisementCondition{
+				Keyword: append(numberedTerms("k", maxConditionKeywords), "K1"), // K1 與 k1 相同
+			},
+			expectedError: nil,
+		},
+		{
+			name: "too many keywords",
+			condition: AdvertisementCondition{
+				Keyword: numberedTerms("k", maxConditionKeywords+1),
+			},
+			expectedError: errors.New("invalid keyword value (must be at most 100)"),
+		},
+		{
+			name: "too many categories",
+			condition: AdvertisementCondition{
+				Category: numberedTerms("c", maxConditionCategories+1),
+			},
+			expectedError: errors.New("invalid category value (must be at most 20)"),
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -128,3 +221,280 @@ func TestHandler_validateCondition(t *testing.T) {
 	}
 
 }
+
+// prefix1, prefix2, ... prefixN
+func numberedTerms(prefix string, n int) []string {
+	terms := make([]string, n)
+	for i := range terms {
+		terms[i] = prefix + strconv.Itoa(i+1)
+	}
+	return terms
+}
+
+func TestHandler_validateAdvertisement(t *testing.T) {
+	handler := Handler{
+		genderSet:       mapset.NewSet("M", "F"),
+		countrySet:      mapset.NewSet("TW", "US", "JP"),
+		countryGroupSet: mapset.NewSet("EU"),
+		platformSet:     mapset.NewSet("android", "ios", "web"),
+	}
+	startAt := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	endAt := time.Date(2023, 12, 31, 16, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		advertisement Advertisement
+		expectedError error
+	}{
+		{
+			name: "valid advertisement",
+			advertisement: Advertisement{
+				Title:      "AD 55",
+				StartAt:    startAt,
+				EndAt:      endAt,
+				Conditions: []AdvertisementCondition{{Country: []string{"TW"}}},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid advertisement (startAt == endAt)",
+			advertisement: Advertisement{
+				Title:   "AD 55",
+				StartAt: startAt,
+				EndAt:   startAt,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid title (empty)",
+			advertisement: Advertisement{
+				StartAt: startAt,
+				EndAt:   endAt,
+			},
+			expectedError: errors.New("invalid title value (must not be empty)"),
+		},
+		{
+			name: "invalid startAt (empty)",
+			advertisement: Advertisement{
+				Title: "AD 55",
+				EndAt: endAt,
+			},
+			expectedError: errors.New("invalid startAt value (must not be empty)"),
+		},
+		{
+			name: "invalid endAt (< startAt)",
+			advertisement: Advertisement{
+				Title:   "AD 55",
+				StartAt: endAt,
+				EndAt:   startAt,
+			},
+			expectedError: errors.New("invalid endAt value (must be >= startAt)"),
+		},
+		{
+			name: "valid advertisement (draft)",
+			advertisement: Advertisement{
+				Title:   "AD 55",
+				StartAt: startAt,
+				EndAt:   endAt,
+				Status:  "draft",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid status",
+			advertisement: Advertisement{
+				Title:   "AD 55",
+				StartAt: startAt,
+				EndAt:   endAt,
+				Status:  "deleted",
+			},
+			expectedError: errors.New("invalid status value (must be draft or scheduled)"),
+		},
+		{
+			name: "invalid status (active on create)",
+			advertisement: Advertisement{
+				Title:   "AD 55",
+				StartAt: startAt,
+				EndAt:   endAt,
+				Status:  "active",
+			},
+			expectedError: errors.New("invalid status value (must be draft or scheduled)"),
+		},
+		{
+			name: "invalid title (too long)",
+			advertisement: Advertisement{
+				Title:   strings.Repeat("a", 256),
+				StartAt: startAt,
+				EndAt:   endAt,
+			},
+			expectedError: errors.New("invalid title value (must be <= 255 characters)"),
+		},
+		{
+			name: "invalid creative",
+			advertisement: Advertisement{
+				Title:    "AD 55",
+				StartAt:  startAt,
+				EndAt:    endAt,
+				Creative: &Creative{LandingURL: "ftp://shop.example.com"},
+			},
+			expectedError: errors.New("invalid landingUrl value (scheme must be http or https)"),
+		},
+		{
+			name: "invalid condition",
+			advertisement: Advertisement{
+				Title:      "AD 55",
+				StartAt:    startAt,
+				EndAt:      endAt,
+				Conditions: []AdvertisementCondition{{Country: []string{"TW"}}, {Platform: []string{"computer"}}},
+			},
+			expectedError: errors.New("invalid platform value"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := handler.validateAdvertisement(tc.advertisement)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHandler_CreateAdvertisementHandler(t *testing.T) {
+	const valid = `{
+		"title": "AD 55",
+		"startAt": "2023-12-10T03:00:00.000Z",
+		"endAt": "2023-12-31T16:00:00.000Z",
+		"conditions": [{"ageStart": 20, "ageEnd": 30, "country": ["TW", "JP"], "platform": ["android", "ios"]}]
+	}`
+
+	testCases := []struct {
+		name          string
+		body          string
+		failures      map[string]error
+		expectedCode  int
+		expectedBody  string
+		expectedCount int
+	}{
+		{
+			name:          "valid",
+			body:          valid,
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"status":"ok"}`,
+			expectedCount: 1,
+		},
+		{
+			name:         "invalid JSON",
+			body:         `{"title": `,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"unexpected EOF"}`,
+		},
+		{
+			name:         "missing title",
+			body:         `{"startAt": "2023-12-10T03:00:00.000Z", "endAt": "2023-12-31T16:00:00.000Z", "conditions": []}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid advertisement",
+			body:         `{"title": "AD 55", "startAt": "2023-12-31T16:00:00.000Z", "endAt": "2023-12-10T03:00:00.000Z", "conditions": []}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid endAt value (must be \u003e= startAt)"}`,
+		},
+		{
+			name:         "invalid condition",
+			body:         `{"title": "AD 55", "startAt": "2023-12-10T03:00:00.000Z", "endAt": "2023-12-31T16:00:00.000Z", "conditions": [{"country": ["XX"]}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid country value"}`,
+		},
+		{
+			name:         "database error",
+			body:         valid,
+			failures:     map[string]error{"InTx": errors.New("connection refused")},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"database error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			for method, err := range tc.failures {
+				db.failures[method] = err
+			}
+
+			recorder := serve(handler.CreateAdvertisementHandler, http.MethodPost, "/ad", tc.body)
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedBody != "" && recorder.Body.String() != tc.expectedBody {
+				t.Errorf("expected body: %s, got: %s", tc.expectedBody, recorder.Body.String())
+			}
+
+			ads, err := db.ListAdvertisements(context.Background(), sqlc.ListAdvertisementsParams{Limit: 10})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ads) != tc.expectedCount {
+				t.Errorf("expected %d advertisements, got: %d", tc.expectedCount, len(ads))
+			}
+		})
+	}
+}
+
+func TestHandler_insertAdvertisement_defaultStatus(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name     string
+		ad       Advertisement
+		expected string
+	}{
+		{"started", Advertisement{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour)}, AdvertisementStatusActive},
+		{"not started", Advertisement{Title: "AD 2", StartAt: now.Add(time.Hour), EndAt: now.Add(2 * time.Hour)}, AdvertisementStatusScheduled},
+		{"explicit status", Advertisement{Title: "AD 3", StartAt: now.Add(time.Hour), EndAt: now.Add(2 * time.Hour), Status: AdvertisementStatusDraft}, AdvertisementStatusDraft},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			id, err := handler.insertAdvertisement(context.Background(), db, tc.ad)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := db.GetAdvertisementStatus(context.Background(), int32(id))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Status != tc.expected {
+				t.Errorf("expected status %s, got: %s", tc.expected, got.Status)
+			}
+		})
+	}
+}
+
+func TestHandler_CreateAdvertisementHandler_terms(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	body := `{"title": "AD 1", "startAt": "2023-12-10T03:00:00.000Z", "endAt": "2023-12-31T16:00:00.000Z", "conditions": [{"keyword": ["PS5"], "category": ["Game"]}]}`
+
+	// rollback 時不加入 keywordSet/categorySet
+	db.failures["InTx.commit"] = errors.New("connection reset")
+	if recorder := serve(handler.CreateAdvertisementHandler, http.MethodPost, "/ad", body); recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got: %d (%s)", http.StatusInternalServerError, recorder.Code, recorder.Body.String())
+	}
+	if handler.keywordSet.Contains("ps5") || handler.categorySet.Contains("game") {
+		t.Errorf("unexpected terms after rollback: %v %v", handler.keywordSet, handler.categorySet)
+	}
+
+	delete(db.failures, "InTx.commit")
+	if recorder := serve(handler.CreateAdvertisementHandler, http.MethodPost, "/ad", body); recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got: %d (%s)", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	if !handler.keywordSet.Contains("ps5") || !handler.categorySet.Contains("game") {
+		t.Errorf("expected ps5 and game, got: %v %v", handler.keywordSet, handler.categorySet)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CreativeFormatText   = "text"
+	CreativeFormatImage  = "image"
+	CreativeFormatNative = "native"
+)
+
+var creativeFormats = []string{CreativeFormatText, CreativeFormatImage, CreativeFormatNative}
+
+// 長度限制 (與 advertisement table 的欄位長度一致)
+const (
+	maxTitleLength       = 255
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+	maxCTALabelLength    = 32
+	maxImageDimension    = 10000
+)
+
+type Creative struct {
+	Description string `json:"description,omitempty" example:"全館商品 5 折起" extensions:"x-order=0"`
+	ImageURL    string `json:"imageUrl,omitempty" example:"https://cdn.example.com/ad55.png" extensions:"x-order=1"`
+	ImageWidth  int32  `json:"imageWidth,omitempty" example:"1200" extensions:"x-order=2"`
+	ImageHeight int32  `json:"imageHeight,omitempty" example:"628" extensions:"x-order=3"`
+	LandingURL  string `json:"landingUrl,omitempty" example:"https://shop.example.com/sale" extensions:"x-order=4"`
+	CTALabel    string `json:"ctaLabel,omitempty" example:"立即購買" extensions:"x-order=5"`
+	Format      string `json:"format,omitempty" example:"image" enums:"text,image,native" extensions:"x-order=6"`
+}
+
+// 判斷 creative 是否 valid
+func validateCreative(creative Creative) error {
+	// description
+	if utf8.RuneCountInString(creative.Description) > maxDescriptionLength {
+		return errors.New("invalid description value (must be <= 1000 characters)")
+	}
+
+	// imageUrl (只接受 https, 避免 mixed content)
+	if creative.ImageURL != "" {
+		if err := validateURL(creative.ImageURL, "https"); err != nil {
+			return errors.New("invalid imageUrl value (" + err.Error() + ")")
+		}
+	}
+
+	// imageWidth/imageHeight (有圖片時必填)
+	if creative.ImageURL == "" && (creative.ImageWidth != 0 || creative.ImageHeight != 0) {
+		return errors.New("invalid imageWidth/imageHeight value (imageUrl is required)")
+	}
+	if creative.ImageURL != "" && (creative.ImageWidth < 1 || creative.ImageWidth > maxImageDimension) {
+		return errors.New("invalid imageWidth value (must be 1 ~ 10000)")
+	}
+	if creative.ImageURL != "" && (creative.ImageHeight < 1 || creative.ImageHeight > maxImageDimension) {
+		return errors.New("invalid imageHeight value (must be 1 ~ 10000)")
+	}
+
+	// landingUrl
+	if creative.LandingURL != "" {
+		if err := validateURL(creative.LandingURL, "http", "https"); err != nil {
+			return errors.New("invalid landingUrl value (" + err.Error() + ")")
+		}
+	}
+
+	// ctaLabel
+	if utf8.RuneCountInString(creative.CTALabel) > maxCTALabelLength {
+		return errors.New("invalid ctaLabel value (must be <= 32 characters)")
+	}
+
+	// format
+	if creative.Format != "" && !slices.Contains(creativeFormats, creative.Format) {
+		return errors.New("invalid format value (must be text, image or native)")
+	}
+	if creative.Format == CreativeFormatImage && creative.ImageURL == "" {
+		return errors.New("invalid format value (image format requires imageUrl)")
+	}
+
+	return nil
+}
+
+// 判斷是否為指定 scheme 的絕對 URL
+func validateURL(rawURL string, schemes ...string) error {
+	if len(rawURL) > maxURLLength {
+		return errors.New("must be <= 2048 characters")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return errors.New("must be an absolute URL")
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return errors.New("scheme must be " + strings.Join(schemes, " or "))
+	}
+	return nil
+}
+
+// 從 advertisement table 的欄位組回 Creative (全部都是預設值時回傳 nil)
+func creativeFromColumns(description, imageURL string, imageWidth, imageHeight int32, landingURL, ctaLabel, format string) *Creative {
+	creative := Creative{
+		Description: description,
+		ImageURL:    imageURL,
+		ImageWidth:  imageWidth,
+		ImageHeight: imageHeight,
+		LandingURL:  landingURL,
+		CTALabel:    ctaLabel,
+		Format:      format,
+	}
+	if creative == (Creative{Format: CreativeFormatText}) {
+		return nil
+	}
+	return &creative
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateCreative(t *testing.T) {
+	testCases := []struct {
+		name          string
+		creative      Creative
+		expectedError error
+	}{
+		{
+			name: "valid creative (all)",
+			creative: Creative{
+				Description: "全館商品 5 折起",
+				ImageURL:    "https://cdn.example.com/ad55.png",
+				ImageWidth:  1200,
+				ImageHeight: 628,
+				LandingURL:  "http://shop.example.com/sale",
+				CTALabel:    "立即購買",
+				Format:      "image",
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "valid creative (empty)",
+			creative:      Creative{},
+			expectedError: nil,
+		},
+		{
+			name: "valid creative (description at limit, multi-byte)",
+			creative: Creative{
+				Description: strings.Repeat("廣", 1000),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid description (too long)",
+			creative: Creative{
+				Description: strings.Repeat("a", 1001),
+			},
+			expectedError: errors.New("invalid description value (must be <= 1000 characters)"),
+		},
+		{
+			name: "invalid imageUrl (http)",
+			creative: Creative{
+				ImageURL:    "http://cdn.example.com/ad55.png",
+				ImageWidth:  1200,
+				ImageHeight: 628,
+			},
+			expectedError: errors.New("invalid imageUrl value (scheme must be https)"),
+		},
+		{
+			name: "invalid imageUrl (relative)",
+			creative: Creative{
+				ImageURL:    "/ad55.png",
+				ImageWidth:  1200,
+				ImageHeight: 628,
+			},
+			expectedError: errors.New("invalid imageUrl value (must be an absolute URL)"),
+		},
+		{
+			name: "invalid imageWidth (missing)",
+			creative: Creative{
+				ImageURL:    "https://cdn.example.com/ad55.png",
+				ImageHeight: 628,
+			},
+			expectedError: errors.New("invalid imageWidth value (must be 1 ~ 10000)"),
+		},
+		{
+			name: "invalid imageHeight (> 10000)",
+			creative: Creative{
+				ImageURL:    "https://cdn.example.com/ad55.png",
+				ImageWidth:  1200,
+				ImageHeight: 10001,
+			},
+			expectedError: errors.New("invalid imageHeight value (must be 1 ~ 10000)"),
+		},
+		{
+			name: "invalid image dimensions (without imageUrl)",
+			creative: Creative{
+				ImageWidth:  1200,
+				ImageHeight: 628,
+			},
+			expectedError: errors.New("invalid imageWidth/imageHeight value (imageUrl is required)"),
+		},
+		{
+			name: "invalid landingUrl (javascript)",
+			creative: Creative{
+				LandingURL: "javascript:alert(1)",
+			},
+			expectedError: errors.New("invalid landingUrl value (must be an absolute URL)"),
+		},
+		{
+			name: "invalid landingUrl (ftp)",
+			creative: Creative{
+				LandingURL: "ftp://shop.example.com/sale",
+			},
+			expectedError: errors.New("invalid landingUrl value (scheme must be http or https)"),
+		},
+		{
+			name: "invalid landingUrl (too long)",
+			creative: Creative{
+				LandingURL: "https://shop.example.com/" + strings.Repeat("a", 2048),
+			},
+			expectedError: errors.New("invalid landingUrl value (must be <= 2048 characters)"),
+		},
+		{
+			name: "invalid ctaLabel (too long)",
+			creative: Creative{
+				CTALabel: strings.Repeat("a", 33),
+			},
+			expectedError: errors.New("invalid ctaLabel value (must be <= 32 characters)"),
+		},
+		{
+			name: "invalid format",
+			creative: Creative{
+				Format: "video",
+			},
+			expectedError: errors.New("invalid format value (must be text, image or native)"),
+		},
+		{
+			name: "invalid format (image without imageUrl)",
+			creative: Creative{
+				Format: "image",
+			},
+			expectedError: errors.New("invalid format value (image format requires imageUrl)"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCreative(tc.creative)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+)
+
+// client 在 response 之前中斷連線 (nginx 的 499), 只會出現在 access log 與 metrics
+const statusClientClosedRequest = 499
+
+// 每個 dependency 一次操作的 timeout (0 表示只受 request context 限制)
+type Timeouts struct {
+	Database time.Duration
+	Cache    time.Duration
+}
+
+// 設定 database 與 cache 的 timeout
+func (handler *Handler) SetTimeouts(timeouts Timeouts) {
+	handler.timeouts = timeouts
+}
+
+// database 操作的 context (同一個 transaction 的 queries 共用)
+func (handler *Handler) databaseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, handler.timeouts.Database)
+}
+
+func (handler *Handler) cacheContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, handler.timeouts.Cache)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// dependency 錯誤對應的 status: 超過 timeout 為 504, 無法連線為 503, 其他為 500
+func dependencyStatus(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout
+	case errors.As(err, &netErr), errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// 記錄 dependency ("database" 或 "cache") 的錯誤並回應對應的 status
+func respondDependencyError(ctx *gin.Context, dependency string, err error) {
+	switch status := dependencyStatus(err); status {
+	case statusClientClosedRequest:
+		slog.WarnContext(ctx, "request canceled", "dependency", dependency, "error", err)
+		ctx.AbortWithStatus(status)
+	case http.StatusGatewayTimeout:
+		slog.ErrorContext(ctx, dependency+" timeout", "error", err)
+		ctx.JSON(status, logging.ErrorBody(ctx, dependency+" timeout"))
+	case http.StatusServiceUnavailable:
+		slog.ErrorContext(ctx, dependency+" unavailable", "error", err)
+		ctx.JSON(status, logging.ErrorBody(ctx, dependency+" unavailable"))
+	default:
+		slog.ErrorContext(ctx, dependency+" error", "error", err)
+		ctx.JSON(status, logging.ErrorBody(ctx, dependency+" error"))
+	}
+}
+
+// 清除廣告的 cache, 失敗時只記錄 (最晚在 cache.ttl 之後更新).
+// 資料庫已經寫入, client 中斷連線時仍然要清除
+func (handler *Handler) invalidateAdvertisements(ctx context.Context) {
+	cacheCtx, cancel := handler.cacheContext(context.WithoutCancel(ctx))
+	defer cancel()
+	if err := handler.cac.InvalidateAdvertisements(cacheCtx); err != nil {
+		slog.ErrorContext(ctx, "cache error", "error", err)
+	}
+}
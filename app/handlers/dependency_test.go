@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestDependencyStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"client disconnected", fmt.Errorf("%w: %w", errDatabase, context.Canceled), statusClientClosedRequest},
+		{"deadline", fmt.Errorf("%w: %w", errCache, context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"read timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, http.StatusGatewayTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, http.StatusServiceUnavailable},
+		{"bad connection", driver.ErrBadConn, http.StatusServiceUnavailable},
+		{"other", errors.New("syntax error"), http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := dependencyStatus(tc.err); status != tc.expected {
+				t.Errorf("expected status %d, got: %d", tc.expected, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/utils"
+)
+
+// 每匯出幾筆 advertisement flush 一次 response
+const exportFlushInterval = 100
+
+type ExportQueryParameters struct {
+	Format *string    `form:"format" example:"jsonl"`
+	Status *string    `form:"status" example:"active"`
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2023-12-01T00:00:00Z"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2023-12-31T23:59:59Z"`
+}
+
+// @Summary		匯出廣告資源
+// @Description	以串流方式匯出所有廣告與完整 conditions, 格式與 POST /ad 的 request body 相同 (可直接用 POST /ads:bulk 匯入)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		format query string false "輸出格式" Enums(jsonl, csv)
+// @Param		status query string false "狀態條件" Enums(draft, scheduled, active, paused, archived)
+// @Param		from query string false "只匯出 endAt >= from 的廣告 (RFC 3339)"
+// @Param		to query string false "只匯出 startAt <= to 的廣告 (RFC 3339)"
+// @Produce		application/x-ndjson,text/csv
+// @Tags		advertisement
+// @Router		/ads:export [get]
+func (handler *Handler) ExportAdvertisementsHandler(ctx *gin.Context) {
+	var queryParameters ExportQueryParameters
+	if err := ctx.ShouldBindQuery(&queryParameters); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	if err := validateExportQueryParameters(queryParameters); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	params := sqlc.ExportAdvertisementsParams{
+		Status:      utils.NullStringFromStringPointer(queryParameters.Status),
+		WindowStart: utils.NullTimeFromTimePointer(queryParameters.From),
+		WindowEnd:   utils.NullTimeFromTimePointer(queryParameters.To),
+	}
+
+	var write func(Advertisement) error
+	var flush func()
+	if queryParameters.Format != nil && *queryParameters.Format == bulkFormatCSV {
+		writer := csv.NewWriter(ctx.Writer)
+		write = func(ad Advertisement) error {
+			record, err := advertisementToCSVRecord(ad)
+			if err != nil {
+				return err
+			}
+			return writer.Write(record)
+		}
+		flush = func() {
+			writer.Flush()
+			ctx.Writer.Flush()
+		}
+
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", `attachment; filename="advertisements.csv"`)
+		ctx.Status(http.StatusOK)
+		if err := writer.Write(advertisementCSVHeader); err != nil {
+			slog.ErrorContext(ctx, "export error", "error", err)
+			return
+		}
+	} else {
+		encoder := json.NewEncoder(ctx.Writer)
+		write = func(ad Advertisement) error {
+			return encoder.Encode(ad)
+		}
+		flush = ctx.Writer.Flush
+
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Header("Content-Disposition", `attachment; filename="advertisements.jsonl"`)
+		ctx.Status(http.StatusOK)
+	}
+
+	// response 開始寫出之後發生錯誤只能中斷串流
+	exported := 0
+	assembler := advertisementAssembler{emit: func(ad Advertisement) error {
+		if err := write(ad); err != nil {
+			return err
+		}
+		exported++
+		if exported%exportFlushInterval == 0 {
+			flush()
+		}
+		return nil
+	}}
+	// 串流輸出, 不套用 database.query_timeout (client 中斷連線時停止)
+	err := handler.databaseQueries.ExportAdvertisementsEach(ctx, params, assembler.add)
+	if err == nil {
+		err = assembler.flush()
+	}
+	if err != nil {
+		if !ctx.Writer.Written() {
+			// 還沒有寫出任何資料 (CSV 的 header 還在 buffer 中), 改成 JSON 的錯誤 response
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			respondDependencyError(ctx, "database", err)
+			return
+		}
+		slog.ErrorContext(ctx, "database error", "error", err)
+	}
+	flush()
+}
+
+// 判斷 export query parameters 是否 valid
+func validateExportQueryParameters(queryParameters ExportQueryParameters) error {
+	// format
+	if queryParameters.Format != nil && *queryParameters.Format != bulkFormatJSONL && *queryParameters.Format != bulkFormatCSV {
+		return errors.New("invalid format value (must be jsonl or csv)")
+	}
+
+	// status
+	if queryParameters.Status != nil && !isAdvertisementStatus(*queryParameters.Status) {
+		return errors.New("invalid status value")
+	}
+
+	// from <= to
+	if queryParameters.From != nil && queryParameters.To != nil && queryParameters.To.Before(*queryParameters.From) {
+		return errors.New("invalid to value (must be >= from)")
+	}
+
+	return nil
+}
+
+// 把 ExportAdvertisements 的結果 (每個 condition 一列, 依 advertisement id 排序) 組回 Advertisement,
+// 每組好一個就交給 emit, 所以同時只會保留一個 advertisement 在記憶體中
+type advertisementAssembler struct {
+	emit    func(Advertisement) error
+	id      int32
+	current *Advertisement
+}
+
+func (assembler *advertisementAssembler) add(row sqlc.ExportAdvertisementsRow) error {
+	if assembler.current == nil || assembler.id != row.ID {
+		if err := assembler.flush(); err != nil {
+			return err
+		}
+		variants, err := variantsFromJSON(row.Variants)
+		if err != nil {
+			return err
+		}
+		localizations, err := localizationsFromJSON(row.Localizations)
+		if err != nil {
+			return err
+		}
+		assembler.id = row.ID
+		assembler.current = &Advertisement{
+			Title:         row.Title,
+			StartAt:       row.StartAt,
+			EndAt:         row.EndAt,
+			Status:        row.Status,
+			Creative:      creativeFromColumns(row.Description, row.ImageUrl, row.ImageWidth, row.ImageHeight, row.LandingUrl, row.CtaLabel, row.Format),
+			Localizations: localizations,
+			Variants:      variants,
+			Conditions:    []AdvertisementCondition{},
+		}
+	}
+
+	// advertisement 沒有任何 condition
+	if !row.CondID.Valid {
+		return nil
+	}
+
+	geofences, err := geofencesFromJSON(row.Geofences)
+	if err != nil {
+		return err
+	}
+	var genders, countries, countryGroups, platforms, regions, segments, keywords, categories []string
+	for _, column := range []struct {
+		value  json.RawMessage
+		target *[]string
+	}{
+		{row.Genders, &genders},
+		{row.Countries, &countries},
+		{row.CountryGroups, &countryGroups},
+		{row.Platforms, &platforms},
+		{row.Regions, &regions},
+		{row.Segments, &segments},
+		{row.Keywords, &keywords},
+		{row.Categories, &categories},
+	} {
+		if *column.target, err = stringsFromJSON(column.value); err != nil {
+			return err
+		}
+	}
+	assembler.current.Conditions = append(assembler.current.Conditions, AdvertisementCondition{
+		AgeStart: utils.Int32PointerFromNullInt32(row.AgeStart),
+		AgeEnd:   utils.Int32PointerFromNullInt32(row.AgeEnd),
+		Gender:   genders,
+		Country:  append(countries, countryGroups...),
+		Platform: platforms,
+		Region:   regions,
+		Geofence: geofences,
+		Segment:  segments,
+		Keyword:  keywords,
+		Category: categories,
+	})
+	return nil
+}
+
+func (assembler *advertisementAssembler) flush() error {
+	if assembler.current == nil {
+		return nil
+	}
+	ad := *assembler.current
+	assembler.current = nil
+	return assembler.emit(ad)
+}
+
+// JSON array 的結果 (["TW","JP"]) -> []string (沒有資料時是 "[]", 改成 nil)
+func stringsFromJSON(value json.RawMessage) ([]string, error) {
+	var values []string
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &values); err != nil {
+			return nil, err
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// JSON_ARRAYAGG 的結果 -> []Variant (沒有覆蓋任何欄位的 creative 改成 nil)
+func variantsFromJSON(value json.RawMessage) ([]Variant, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var variants []Variant
+	if err := json.Unmarshal(value, &variants); err != nil {
+		return nil, err
+	}
+	for i := range variants {
+		if variants[i].Creative != nil && *variants[i].Creative == (Creative{}) {
+			variants[i].Creative = nil
+		}
+	}
+	return variants, nil
+}
+
+// JSON array 的結果 -> []Geofence (沒有 geofence 時是 "[]")
+func geofencesFromJSON(value json.RawMessage) ([]Geofence, error) {
+	var geofences []Geofence
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &geofences); err != nil {
+			return nil, err
+		}
+	}
+	if len(geofences) == 0 {
+		return nil, nil
+	}
+	return geofences, nil
+}
+
+// Advertisement -> CSV record (欄位順序同 advertisementCSVHeader)
+func advertisementToCSVRecord(ad Advertisement) ([]string, error) {
+	conditions := ad.Conditions
+	if conditions == nil {
+		conditions = []AdvertisementCondition{}
+	}
+	conditionsJSON, err := json.Marshal(conditions)
+	if err != nil {
+		return nil, err
+	}
+	creativeJSON := []byte{}
+	if ad.Creative != nil {
+		creativeJSON, err = json.Marshal(ad.Creative)
+		if err != nil {
+			return nil, err
+		}
+	}
+	variantsJSON := []byte{}
+	if len(ad.Variants) > 0 {
+		variantsJSON, err = json.Marshal(ad.Variants)
+		if err != nil {
+			return nil, err
+		}
+	}
+	localizationsJSON := []byte{}
+	if len(ad.Localizations) > 0 {
+		localizationsJSON, err = json.Marshal(ad.Localizations)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []string{
+		ad.Title,
+		ad.StartAt.Format(time.RFC3339Nano),
+		ad.EndAt.Format(time.RFC3339Nano),
+		string(conditionsJSON),
+		ad.Status,
+		string(creativeJSON),
+		string(variantsJSON),
+		string(localizationsJSON),
+	}, nil
+}
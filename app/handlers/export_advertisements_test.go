@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+func TestValidateExportQueryParameters(t *testing.T) {
+	from := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name            string
+		queryParameters ExportQueryParameters
+		expectedError   error
+	}{
+		{
+			name:            "valid (empty)",
+			queryParameters: ExportQueryParameters{},
+			expectedError:   nil,
+		},
+		{
+			name: "valid (all)",
+			queryParameters: ExportQueryParameters{
+				Format: StringPtr("csv"),
+				From:   &from,
+				To:     &to,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid format",
+			queryParameters: ExportQueryParameters{
+				Format: StringPtr("xml"),
+			},
+			expectedError: errors.New("invalid format value (must be jsonl or csv)"),
+		},
+		{
+			name: "invalid status",
+			queryParameters: ExportQueryParameters{
+				Status: StringPtr("deleted"),
+			},
+			expectedError: errors.New("invalid status value"),
+		},
+		{
+			name: "invalid to (< from)",
+			queryParameters: ExportQueryParameters{
+				From: &to,
+				To:   &from,
+			},
+			expectedError: errors.New("invalid to value (must be >= from)"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateExportQueryParameters(tc.queryParameters)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHandler_ExportAdvertisementsHandler_invalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &Handler{}
+	router := gin.New()
+	router.GET("/ads:export", handler.ExportAdvertisementsHandler)
+
+	for _, query := range []string{"format=xml", "from=yesterday", "from=2023-12-31T00:00:00Z&to=2023-12-01T00:00:00Z"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ads:export?"+query, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got: %d", query, http.StatusBadRequest, recorder.Code)
+		}
+	}
+}
+
+// query 失敗時還沒寫出任何資料, 回應 JSON 的錯誤而不是 attachment
+func TestHandler_ExportAdvertisementsHandler_databaseError(t *testing.T) {
+	for _, format := range []string{"jsonl", "csv"} {
+		handler, db, _ := newTestHandler(t)
+		db.failures["ExportAdvertisementsEach"] = errors.New("connection refused")
+
+		recorder := serve(handler.ExportAdvertisementsHandler, http.MethodGet, "/ads:export?format="+format, "")
+		if recorder.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status %d, got: %d", format, http.StatusInternalServerError, recorder.Code)
+		}
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+			t.Errorf("%s: expected a JSON error, got Content-Type: %s", format, contentType)
+		}
+		if disposition := recorder.Header().Get("Content-Disposition"); disposition != "" {
+			t.Errorf("%s: expected no Content-Disposition, got: %s", format, disposition)
+		}
+		if body := recorder.Body.String(); body != `{"error":"database error"}` {
+			t.Errorf("%s: unexpected body: %s", format, body)
+		}
+	}
+}
+
+func TestAdvertisementAssembler(t *testing.T) {
+	startAt := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	endAt := time.Date(2023, 12, 31, 16, 0, 0, 0, time.UTC)
+	rows := []sqlc.ExportAdvertisementsRow{
+		{
+			ID: 1, Title: "AD 1", StartAt: startAt, EndAt: endAt, Format: "text",
+			CondID:    sql.NullInt32{Int32: 1, Valid: true},
+			AgeStart:  sql.NullInt32{Int32: 20, Valid: true},
+			AgeEnd:    sql.NullInt32{Int32: 30, Valid: true},
+			Genders:   json.RawMessage(`["M"]`),
+			Countries: json.RawMessage(`["TW", "JP"]`),
+			// country groups 接在 countries 後面
+			CountryGroups: json.RawMessage(`["EU"]`),
+		},
+		{
+			ID: 1, Title: "AD 1", StartAt: startAt, EndAt: endAt, Format: "text",
+			CondID:     sql.NullInt32{Int32: 2, Valid: true},
+			Platforms:  json.RawMessage(`["ios"]`),
+			Regions:    json.RawMessage(`["TW-TPE", "TW-NWT"]`),
+			Geofences:  []byte(`[{"lat": 25.034, "lng": 121.5645, "radius": 2000}]`),
+			Segments:   json.RawMessage(`["gamer", "traveler"]`),
+			Keywords:   json.RawMessage(`["nintendo switch", "ps5"]`),
+			Categories: json.RawMessage(`["game"]`),
+		},
+		{
+			ID: 2, Title: "AD 2", StartAt: startAt, EndAt: endAt, Status: "archived",
+			ImageUrl: "https://cdn.example.com/ad2.png", ImageWidth: 1200, ImageHeight: 628, Format: "image",
+			Variants:      []byte(`[{"name": "A", "title": "AD 2 (A)", "weight": 50, "creative": {"format": "", "ctaLabel": "", "imageUrl": "", "imageWidth": 0, "landingUrl": "", "description": "", "imageHeight": 0}}, {"name": "B", "title": "", "weight": 50, "creative": {"format": "", "ctaLabel": "Go", "imageUrl": "", "imageWidth": 0, "landingUrl": "", "description": "", "imageHeight": 0}}]`),
+			Localizations: []byte(`{"zh-TW": {"title": "廣告 2", "ctaLabel": "", "description": ""}}`),
+		},
+	}
+
+	var ads []Advertisement
+	assembler := advertisementAssembler{emit: func(ad Advertisement) error {
+		ads = append(ads, ad)
+		return nil
+	}}
+	for _, row := range rows {
+		if err := assembler.add(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := assembler.flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Advertisement{
+		{
+			Title: "AD 1", StartAt: startAt, EndAt: endAt,
+			Conditions: []AdvertisementCondition{
+				{AgeStart: Int32Ptr(20), AgeEnd: Int32Ptr(30), Gender: []string{"M"}, Country: []string{"TW", "JP", "EU"}},
+				{Platform: []string{"ios"}, Region: []string{"TW-TPE", "TW-NWT"}, Geofence: []Geofence{{Lat: 25.034, Lng: 121.5645, Radius: 2000}}, Segment: []string{"gamer", "traveler"}, Keyword: []string{"nintendo switch", "ps5"}, Category: []string{"game"}},
+			},
+		},
+		{
+			Title: "AD 2", StartAt: startAt, EndAt: endAt, Status: "archived",
+			Creative: &Creative{ImageURL: "https://cdn.example.com/ad2.png", ImageWidth: 1200, ImageHeight: 628, Format: "image"},
+			Localizations: map[string]Localization{
+				"zh-TW": {Title: "廣告 2"},
+			},
+			Variants: []Variant{
+				{Name: "A", Weight: 50, Title: "AD 2 (A)"},
+				{Name: "B", Weight: 50, Creative: &Creative{CTALabel: "Go"}},
+			},
+			Conditions: []AdvertisementCondition{},
+		},
+	}
+	if !reflect.DeepEqual(ads, expected) {
+		t.Errorf("expected: %+v, got: %+v", expected, ads)
+	}
+}
+
+func TestAdvertisementToCSVRecord_roundTrip(t *testing.T) {
+	ad := Advertisement{
+		Title:   "AD, \"55\"",
+		StartAt: time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC),
+		EndAt:   time.Date(2023, 12, 31, 16, 0, 0, 500, time.UTC),
+		Status:  "paused",
+		Creative: &Creative{
+			Description: "全館商品 5 折起",
+			LandingURL:  "https://shop.example.com/sale?a=1,b=2",
+			CTALabel:    "立即購買",
+		},
+		Localizations: map[string]Localization{
+			"zh-TW": {Title: "廣告 55", CTALabel: "立即購買"},
+		},
+		Variants: []Variant{
+			{Name: "A", Weight: 1, Title: "AD 55 (A)"},
+		},
+		Conditions: []AdvertisementCondition{
+			{AgeStart: Int32Ptr(20), Country: []string{"TW", "JP"}},
+		},
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	record, err := advertisementToCSVRecord(ad)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writer.Write(advertisementCSVHeader)
+	writer.Write(record)
+	writer.Flush()
+
+	rows, err := parseAdvertisementsCSV(&buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].err != nil {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+	if !reflect.DeepEqual(rows[0].ad, ad) {
+		t.Errorf("expected: %+v, got: %+v", ad, rows[0].ad)
+	}
+}
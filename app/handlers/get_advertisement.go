@@ -1,24 +1,49 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"errors"
-	"log"
+	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/lnfu/dcard-intern/app/cache"
+	"github.com/lnfu/dcard-intern/app/geo"
+	"github.com/lnfu/dcard-intern/app/logging"
 	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
-	"github.com/redis/go-redis/v9"
+	"github.com/lnfu/dcard-intern/app/term"
+	"github.com/lnfu/dcard-intern/app/tracing"
+	"github.com/lnfu/dcard-intern/app/useragent"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lnfu/dcard-intern/app/utils"
 )
 
+// platform=auto: 以 User-Agent 推測 platform
+const platformAuto = "auto"
+
+// 一個 request 最多帶的 keywords (正規化並去除重複後)
+const maxRequestKeywords = 20
+
 type QueryParameters struct {
-	Age      *int32  `form:"age" example:"24"`
-	Gender   *string `form:"gender" example:"M"`
-	Country  *string `form:"country" example:"TW"`
-	Platform *string `form:"platform" example:"android"`
-	Offset   *int32  `form:"offset" example:"0"`
-	Limit    *int32  `form:"limit" example:"5"`
+	Age      *int32   `form:"age" example:"24"`
+	Gender   *string  `form:"gender" example:"M"`
+	Country  *string  `form:"country" example:"TW"`
+	Platform *string  `form:"platform" example:"android"`
+	Region   *string  `form:"region" example:"TW-TPE"`
+	Lat      *float64 `form:"lat" example:"25.0330"`
+	Lng      *float64 `form:"lng" example:"121.5654"`
+	Offset   *int32   `form:"offset" example:"0"`
+	Limit    *int32   `form:"limit" example:"5"`
+	UserID   *string  `form:"userId" example:"u_12345"`
+	Lang     *string  `form:"lang" example:"zh-TW"`
+	Segments []string `form:"segments" example:"gamer,traveler"`
+	Keywords []string `form:"keywords" example:"switch,ps5"`
+	Category *string  `form:"category" example:"game"`
 }
 
 // @Summary		列出符合可⽤和匹配⽬標條件的廣告
@@ -26,34 +51,146 @@ type QueryParameters struct {
 // @Version		1.0
 // @Param		age query int false "年齡條件" minimum(1) maximum(100)
 // @Param		gender query string false "性別條件 (M/F)" Enums(M, F)
-// @Param		country query string false "國家條件 (參考 ISO_3166-1 alpha-2)"
-// @Param		platform query string false "平台條件" Enums(android, ios, web)
+// @Param		country query string false "國家條件 (參考 ISO_3166-1 alpha-2, 沒有 country 與 region 時由 client IP 推測並在 inferred 中回傳)"
+// @Param		platform query string false "平台條件 (開啟 api.infer_platform 時, 沒有 platform 或 auto 由 User-Agent 推測並在 inferred 中回傳)" Enums(android, ios, web, auto)
+// @Param		User-Agent header string false "推測 platform 與 device"
+// @Param		region query string false "地區條件 (參考 ISO 3166-2, 沒有 country 時以前兩碼為 country)"
+// @Param		lat query number false "緯度 (與 lng 一起使用, 比對 geofence 條件)" minimum(-90) maximum(90)
+// @Param		lng query number false "經度 (與 lat 一起使用, 比對 geofence 條件)" minimum(-180) maximum(180)
+// @Param		segments query []string false "audience segments (可以重複或以逗號分隔, 未定義的 segment 會被忽略)" collectionFormat(multi)
+// @Param		keywords query []string false "頁面的 keywords (可以重複或以逗號分隔, 比對前正規化: 全形轉半形, 不分大小寫)" collectionFormat(multi)
+// @Param		category query string false "頁面的看板/分類 (比對前正規化)"
 // @Param		offset query int false " "
 // @Param		limit query int false " "
+// @Param		userId query string false "使用者 id (同一個使用者會固定看到同一個 variant)"
+// @Param		lang query string false "語言 (優先於 Accept-Language, fallback: zh-TW → zh → 預設)"
+// @Param		Accept-Language header string false "語言偏好"
 // @Produce		json
 // @Tags		advertisement
 // @Router		/ad [get]
 func (handler *Handler) GetAdvertisementHandler(ctx *gin.Context) {
 	var queryParameters QueryParameters
 	if err := ctx.ShouldBindQuery(&queryParameters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
 		return
 	}
+	queryParameters.Segments = splitList(queryParameters.Segments)
+	queryParameters.Keywords = term.Terms(queryParameters.Keywords)
+	if queryParameters.Category != nil {
+		category := term.Normalize(*queryParameters.Category)
+		queryParameters.Category = &category
+	}
+
+	// 沒有 platform (或 platform=auto) 時, 以 User-Agent 推測
+	var inferred Inferred
+	if queryParameters.Platform == nil || *queryParameters.Platform == platformAuto {
+		if !handler.api.InferPlatform && queryParameters.Platform != nil {
+			ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid platform value (platform inference is disabled)"))
+			return
+		}
+		queryParameters.Platform = nil
+		if handler.api.InferPlatform {
+			handler.inferPlatform(ctx.GetHeader("User-Agent"), &inferred)
+			if inferred.Platform != "" {
+				queryParameters.Platform = &inferred.Platform
+			}
+		}
+	}
 
 	if err := handler.validateQueryParameters(queryParameters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	// 沒有 country 與 region 時, 以 client IP 推測
+	if queryParameters.Country == nil && queryParameters.Region == nil {
+		handler.inferLocation(ctx.ClientIP(), &inferred)
+		if inferred.Country != "" {
+			queryParameters.Country = &inferred.Country
+		}
+		if inferred.Region != "" {
+			queryParameters.Region = &inferred.Region
+		}
 	}
 
 	params := handler.buildDBParams(queryParameters)
 
-	ads, err := handler.retrieveAdvertisements(params)
+	// 位置換成包含它的 geofences (cache key 只有 geofence ids, 不包含 lat/lng)
+	if queryParameters.Lat != nil && queryParameters.Lng != nil {
+		geofenceIds, err := handler.resolveGeofences(ctx, *queryParameters.Lat, *queryParameters.Lng)
+		if err != nil {
+			respondDependencyError(ctx, "database", err)
+			return
+		}
+		params.GeofenceIds = geofenceIds
+	}
+
+	locale := handler.resolveLocale(queryParameters.Lang, ctx.GetHeader("Accept-Language"))
+
+	ads, err := handler.retrieveAdvertisements(ctx, params, locale)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		dependency := "cache"
+		if errors.Is(err, errDatabase) {
+			dependency = "database"
+		}
+		respondDependencyError(ctx, dependency, err)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"items": ads,
-	})
+	// pick one variant per advertisement
+	items := make([]AdvertisementItem, len(ads))
+	for i, ad := range ads {
+		items[i] = pickVariant(ad, queryParameters.UserID)
+	}
+
+	ctx.Header("Vary", "Accept-Language")
+	if locale != "" {
+		ctx.Header("Content-Language", locale)
+	}
+	response := gin.H{
+		"items": items,
+	}
+	if inferred != (Inferred{}) {
+		response["inferred"] = inferred
+	}
+	// JSON encoding 也是 latency 的一部分 (items 多時可能比 cache 慢)
+	_, span := tracing.Tracer().Start(ctx, "encode response")
+	ctx.JSON(http.StatusOK, response)
+	span.End()
+}
+
+// 由 client IP 與 User-Agent 推測的條件 (回應中的 inferred, 沒有推測的欄位省略)
+type Inferred struct {
+	Country  string `json:"country,omitempty" example:"TW"`
+	Region   string `json:"region,omitempty" example:"TW-TPE"`
+	Platform string `json:"platform,omitempty" example:"ios"`
+	Device   string `json:"device,omitempty" example:"mobile" enums:"mobile,tablet,desktop,tv,bot"`
+}
+
+// client IP -> country/region, 沒有啟用、查不到或不是已知的 country 時不設定
+func (handler *Handler) inferLocation(clientIP string, inferred *Inferred) {
+	ip := net.ParseIP(clientIP)
+	if handler.geoIP == nil || ip == nil {
+		return
+	}
+	location, ok := handler.geoIP.Lookup(ip)
+	if !ok || !handler.countrySet.Contains(location.Country) {
+		return
+	}
+	inferred.Country = location.Country
+	// 格式不符的 subdivision 不使用 (只用 country)
+	if regionPattern.MatchString(location.Region) {
+		inferred.Region = location.Region
+	}
+}
+
+// User-Agent -> platform/device, platform 不在 platformSet 中 (例如已經停用) 時只設定 device
+func (handler *Handler) inferPlatform(userAgent string, inferred *Inferred) {
+	device := useragent.Parse(userAgent)
+	inferred.Device = device.Class
+	if device.Platform != "" && handler.platformSet.Contains(device.Platform) {
+		inferred.Platform = device.Platform
+	}
 }
 
 // 判斷 query parameters 是否 valid
@@ -79,14 +216,61 @@ func (handler *Handler) validateQueryParameters(queryParameters QueryParameters)
 		return errors.New("invalid platform value")
 	}
 
+	// region (與 country 同時存在時必須一致)
+	if queryParameters.Region != nil {
+		region := *queryParameters.Region
+		if !regionPattern.MatchString(region) || !handler.countrySet.Contains(region[:2]) {
+			return errors.New("invalid region value")
+		}
+		if queryParameters.Country != nil && *queryParameters.Country != region[:2] {
+			return errors.New("invalid region value (must be in country)")
+		}
+	}
+
+	// lat/lng
+	if (queryParameters.Lat == nil) != (queryParameters.Lng == nil) {
+		return errors.New("invalid lat/lng value (must be given together)")
+	}
+	if queryParameters.Lat != nil && (*queryParameters.Lat < -90 || *queryParameters.Lat > 90) {
+		return errors.New("invalid lat value (must be -90 ~ 90)")
+	}
+	if queryParameters.Lng != nil && (*queryParameters.Lng < -180 || *queryParameters.Lng > 180) {
+		return errors.New("invalid lng value (must be -180 ~ 180)")
+	}
+
+	// segments
+	if len(queryParameters.Segments) > maxRequestSegments {
+		return fmt.Errorf("invalid segments value (must be at most %d)", maxRequestSegments)
+	}
+	for _, segment := range queryParameters.Segments {
+		if !segmentPattern.MatchString(segment) {
+			return fmt.Errorf("invalid segments value (must match %s)", segmentPattern)
+		}
+	}
+
+	// keywords/category (已經正規化)
+	if len(queryParameters.Keywords) > maxRequestKeywords {
+		return fmt.Errorf("invalid keywords value (must be at most %d)", maxRequestKeywords)
+	}
+	for _, keyword := range queryParameters.Keywords {
+		if utf8.RuneCountInString(keyword) > term.MaxLength {
+			return fmt.Errorf("invalid keywords value (must be 1 ~ %d characters)", term.MaxLength)
+		}
+	}
+	if queryParameters.Category != nil {
+		if category := *queryParameters.Category; category == "" || utf8.RuneCountInString(category) > term.MaxLength {
+			return fmt.Errorf("invalid category value (must be 1 ~ %d characters)", term.MaxLength)
+		}
+	}
+
 	// offset
 	if queryParameters.Offset != nil && (*queryParameters.Offset < 0) {
 		return errors.New("invalid offset value (must be >= 0)")
 	}
 
 	// limit
-	if queryParameters.Limit != nil && (*queryParameters.Limit < 1 || *queryParameters.Limit > 100) {
-		return errors.New("invalid limit value (must be 1 ~ 100)")
+	if queryParameters.Limit != nil && (*queryParameters.Limit < 1 || *queryParameters.Limit > handler.api.MaxLimit) {
+		return fmt.Errorf("invalid limit value (must be 1 ~ %d)", handler.api.MaxLimit)
 	}
 
 	return nil
@@ -104,6 +288,33 @@ func (handler *Handler) buildDBParams(queryParameters QueryParameters) sqlc.GetA
 	params.Country = utils.NullStringFromStringPointer(queryParameters.Country)
 	params.Platform = utils.NullStringFromStringPointer(queryParameters.Platform)
 
+	// region (只有 region 時, country 是 region 的前兩碼)
+	params.Region = utils.NullStringFromStringPointer(queryParameters.Region)
+	if params.Region.Valid && !params.Country.Valid {
+		params.Country = sql.NullString{String: params.Region.String[:2], Valid: true}
+	}
+
+	// segments (沒有定義的 segment 不會符合任何條件, 不放進 cache key)
+	for _, segment := range queryParameters.Segments {
+		if handler.segmentSet.Contains(segment) {
+			params.Segments = append(params.Segments, segment)
+		}
+	}
+	slices.Sort(params.Segments)
+	params.Segments = slices.Compact(params.Segments)
+
+	// keywords (沒有廣告使用的 keyword 不會符合任何條件, 不放進 cache key; 已經正規化並排序)
+	for _, keyword := range queryParameters.Keywords {
+		if handler.keywordSet.Contains(keyword) {
+			params.Keywords = append(params.Keywords, keyword)
+		}
+	}
+
+	// category (沒有廣告使用的 category 不會符合任何條件, 不放進 cache key)
+	if queryParameters.Category != nil && handler.categorySet.Contains(*queryParameters.Category) {
+		params.Category = sql.NullString{String: *queryParameters.Category, Valid: true}
+	}
+
 	// offset
 	if queryParameters.Offset == nil {
 		params.Offset = 0
@@ -113,7 +324,7 @@ func (handler *Handler) buildDBParams(queryParameters QueryParameters) sqlc.GetA
 
 	// limit
 	if queryParameters.Limit == nil {
-		params.Limit = 5
+		params.Limit = handler.api.DefaultLimit
 	} else {
 		params.Limit = *queryParameters.Limit
 	}
@@ -121,33 +332,128 @@ func (handler *Handler) buildDBParams(queryParameters QueryParameters) sqlc.GetA
 	return params
 }
 
-// 從 cache/database 獲取符合條件的 advertisement
-func (handler *Handler) retrieveAdvertisements(params sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
-	var ads []sqlc.Advertisement
+// 重複的 query parameter 與逗號分隔的值合併成一個 list (去除空白與空值)
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// 包含 (lat, lng) 的 geofences (geohash prefix 找出候選, 再計算距離), ids 已排序
+func (handler *Handler) resolveGeofences(ctx context.Context, lat float64, lng float64) ([]int32, error) {
+	ctx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	return handler.databaseQueries.ResolveGeofences(ctx, sqlc.ResolveGeofencesParams{
+		Geohash:  geo.Encode(lat, lng, geo.MaxPrecision),
+		Lat:      lat,
+		Lng:      lng,
+		LngScale: geo.LngScale(lat),
+	})
+}
+
+var (
+	errDatabase = errors.New("database error")
+	errCache    = errors.New("cache error")
+)
+
+// 從 cache/database 獲取符合條件的 advertisement (包含 variants, 文字已換成 locale 的翻譯),
+// 錯誤包裝成 errDatabase 或 errCache
+func (handler *Handler) retrieveAdvertisements(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string) ([]servingAdvertisement, error) {
+	var ads []servingAdvertisement
 
 	// find in cache
-	ads, err := handler.cac.GetAdvertisementsFromCache(ctx, params)
-	if err == redis.Nil {
+	err := handler.getAdvertisementsFromCache(ctx, params, locale, &ads)
+	if errors.Is(err, cache.ErrCacheMiss) {
 		// 沒找到, 去 database 找
-		ads, err = handler.databaseQueries.GetActiveAdvertisements(ctx, params)
+		ads, err = handler.getActiveAdvertisements(ctx, params, locale)
 		if err != nil {
-			log.Println("Database Error: ", err.Error())
-			return nil, errors.New("database error")
+			return nil, fmt.Errorf("%w: %w", errDatabase, err)
 		}
 
 		// add cache
-		err = handler.cac.SetAdvertisementsToCache(ctx, params, ads)
+		err = handler.setAdvertisementsToCache(ctx, params, locale, ads)
 		if err != nil {
-			log.Println("Cache Error: ", err.Error())
-			return nil, errors.New("cache error")
+			return nil, fmt.Errorf("%w: %w", errCache, err)
 		}
 
 	} else if err != nil {
-		// redis error
-		log.Println("Cache Error: ", err.Error())
-		return nil, errors.New("cache error")
+		// cache error
+		return nil, fmt.Errorf("%w: %w", errCache, err)
 
 	}
 
 	return ads, nil
 }
+
+// 從 database 獲取符合條件的 advertisement 與它們的 variants 及 locale 的翻譯 (挑選 variant 之後才套用翻譯)
+func (handler *Handler) getActiveAdvertisements(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string) ([]servingAdvertisement, error) {
+	ctx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	rows, err := handler.databaseQueries.GetActiveAdvertisements(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []servingAdvertisement{}, nil
+	}
+
+	ads := make([]servingAdvertisement, len(rows))
+	index := make(map[int32]int, len(rows))
+	ids := make([]int32, len(rows))
+	for i, row := range rows {
+		ads[i].Advertisement = row
+		index[row.ID] = i
+		ids[i] = row.ID
+	}
+
+	variants, err := handler.databaseQueries.GetAdvertisementVariants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		i := index[variant.AdvertisementID]
+		ads[i].Variants = append(ads[i].Variants, variant)
+	}
+
+	chain := localeChain(locale)
+	if len(chain) == 0 {
+		return ads, nil
+	}
+	localizations, err := handler.databaseQueries.GetAdvertisementLocalizations(ctx, sqlc.GetAdvertisementLocalizationsParams{
+		AdvertisementIds: ids,
+		Locales:          chain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	byAdvertisement := make(map[int32]map[string]sqlc.GetAdvertisementLocalizationsRow)
+	for _, localization := range localizations {
+		if byAdvertisement[localization.AdvertisementID] == nil {
+			byAdvertisement[localization.AdvertisementID] = make(map[string]sqlc.GetAdvertisementLocalizationsRow)
+		}
+		byAdvertisement[localization.AdvertisementID][localization.Locale] = localization
+	}
+	for i := range ads {
+		ads[i].Localization = resolveLocalization(chain, byAdvertisement[ads[i].ID])
+	}
+
+	return ads, nil
+}
+
+func (handler *Handler) getAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	ctx, cancel := handler.cacheContext(ctx)
+	defer cancel()
+	return handler.cac.GetAdvertisementsFromCache(ctx, params, locale, ads)
+}
+
+func (handler *Handler) setAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	ctx, cancel := handler.cacheContext(ctx)
+	defer cancel()
+	return handler.cac.SetAdvertisementsToCache(ctx, params, locale, ads)
+}
@@ -1,11 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/lnfu/dcard-intern/app/config"
+	"github.com/lnfu/dcard-intern/app/geoip"
 	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
 )
 
@@ -14,6 +25,7 @@ func TestHandler_validateQueryParameters(t *testing.T) {
 		genderSet:   mapset.NewSet("M", "F"),
 		countrySet:  mapset.NewSet("TW", "US", "JP"),
 		platformSet: mapset.NewSet("android", "ios", "web"),
+		api:         config.Default().API,
 	}
 	testCases := []struct {
 		name            string
@@ -142,6 +154,41 @@ func TestHandler_validateQueryParameters(t *testing.T) {
 			},
 			expectedError: errors.New("invalid limit value (must be 1 ~ 100)"),
 		},
+		{
+			name:            "valid region and location",
+			queryParameters: QueryParameters{Country: StringPtr("TW"), Region: StringPtr("TW-TPE"), Lat: Float64Ptr(25.033), Lng: Float64Ptr(121.5654)},
+			expectedError:   nil,
+		},
+		{
+			name:            "invalid region",
+			queryParameters: QueryParameters{Region: StringPtr("TW_TPE")},
+			expectedError:   errors.New("invalid region value"),
+		},
+		{
+			name:            "region of unknown country",
+			queryParameters: QueryParameters{Region: StringPtr("AA-01")},
+			expectedError:   errors.New("invalid region value"),
+		},
+		{
+			name:            "region not in country",
+			queryParameters: QueryParameters{Country: StringPtr("JP"), Region: StringPtr("TW-TPE")},
+			expectedError:   errors.New("invalid region value (must be in country)"),
+		},
+		{
+			name:            "lat without lng",
+			queryParameters: QueryParameters{Lat: Float64Ptr(25.033)},
+			expectedError:   errors.New("invalid lat/lng value (must be given together)"),
+		},
+		{
+			name:            "invalid lat",
+			queryParameters: QueryParameters{Lat: Float64Ptr(91), Lng: Float64Ptr(0)},
+			expectedError:   errors.New("invalid lat value (must be -90 ~ 90)"),
+		},
+		{
+			name:            "invalid lng",
+			queryParameters: QueryParameters{Lat: Float64Ptr(0), Lng: Float64Ptr(-181)},
+			expectedError:   errors.New("invalid lng value (must be -180 ~ 180)"),
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -162,7 +209,7 @@ func TestHandler_validateQueryParameters(t *testing.T) {
 }
 
 func TestHandler_buildDBParams(t *testing.T) {
-	handler := &Handler{}
+	handler := &Handler{api: config.Default().API}
 
 	tests := []struct {
 		name            string
@@ -284,6 +331,18 @@ func TestHandler_buildDBParams(t *testing.T) {
 				Limit:    5, // 預設值
 			},
 		},
+		{
+			name: "only region",
+			queryParameters: QueryParameters{
+				Region: StringPtr("TW-TPE"),
+			},
+			expectedParams: sqlc.GetActiveAdvertisementsParams{
+				Country: sql.NullString{String: "TW", Valid: true}, // region 的前兩碼
+				Region:  sql.NullString{String: "TW-TPE", Valid: true},
+				Offset:  0, // 預設值
+				Limit:   5, // 預設值
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -293,6 +352,7 @@ func TestHandler_buildDBParams(t *testing.T) {
 				params.Gender != test.expectedParams.Gender ||
 				params.Country != test.expectedParams.Country ||
 				params.Platform != test.expectedParams.Platform ||
+				params.Region != test.expectedParams.Region ||
 				params.Offset != test.expectedParams.Offset ||
 				params.Limit != test.expectedParams.Limit {
 				t.Errorf("expected: %+v, got: %+v", test.expectedParams, params)
@@ -300,3 +360,458 @@ func TestHandler_buildDBParams(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetAdvertisementHandler(t *testing.T) {
+	ageStart, ageEnd := int32(20), int32(30)
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(24 * time.Hour), Conditions: []AdvertisementCondition{{AgeStart: &ageStart, AgeEnd: &ageEnd, Country: []string{"TW"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(48 * time.Hour), Conditions: []AdvertisementCondition{{Platform: []string{"ios"}}}},
+	}
+
+	testCases := []struct {
+		name          string
+		target        string
+		storeFailures map[string]error
+		cacheFailures map[string]error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:         "all",
+			target:       "/ad",
+			expectedCode: http.StatusOK,
+			expectedBody: `"title":"AD 2"`,
+		},
+		{
+			name:         "conditions",
+			target:       "/ad?age=25&country=TW&platform=android",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"items":[{"id":1,"title":"AD 1",`,
+		},
+		{
+			name:         "invalid age",
+			target:       "/ad?age=0",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid age value (must be 1 ~ 100)"}`,
+		},
+		{
+			name:         "malformed age",
+			target:       "/ad?age=abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:          "database error",
+			target:        "/ad",
+			storeFailures: map[string]error{"GetActiveAdvertisements": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"database error"}`,
+		},
+		{
+			name:          "variants database error",
+			target:        "/ad",
+			storeFailures: map[string]error{"GetAdvertisementVariants": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"database error"}`,
+		},
+		{
+			name:          "cache get error",
+			target:        "/ad",
+			cacheFailures: map[string]error{"GetAdvertisementsFromCache": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"cache error"}`,
+		},
+		{
+			name:          "cache set error",
+			target:        "/ad",
+			cacheFailures: map[string]error{"SetAdvertisementsToCache": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"cache error"}`,
+		},
+		{
+			name:          "database timeout",
+			target:        "/ad",
+			storeFailures: map[string]error{"GetAdvertisementVariants": errSlow},
+			expectedCode:  http.StatusGatewayTimeout,
+			expectedBody:  `{"error":"database timeout"}`,
+		},
+		{
+			name:          "database unavailable",
+			target:        "/ad",
+			storeFailures: map[string]error{"GetActiveAdvertisements": &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			expectedCode:  http.StatusServiceUnavailable,
+			expectedBody:  `{"error":"database unavailable"}`,
+		},
+		{
+			name:          "cache timeout",
+			target:        "/ad",
+			cacheFailures: map[string]error{"GetAdvertisementsFromCache": errSlow},
+			expectedCode:  http.StatusGatewayTimeout,
+			expectedBody:  `{"error":"cache timeout"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, cac := newTestHandler(t)
+			handler.SetTimeouts(Timeouts{Database: 20 * time.Millisecond, Cache: 20 * time.Millisecond})
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for method, err := range tc.storeFailures {
+				db.failures[method] = err
+			}
+			for method, err := range tc.cacheFailures {
+				cac.failures[method] = err
+			}
+
+			recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, tc.target, "")
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), tc.expectedBody) {
+				t.Errorf("expected body to contain: %s, got: %s", tc.expectedBody, recorder.Body.String())
+			}
+			if tc.expectedCode == http.StatusBadRequest && strings.Count(recorder.Body.String(), `"error"`) != 1 {
+				t.Errorf("expected a single error response, got: %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetAdvertisementHandler_cache(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+	if _, err := handler.insertAdvertisement(context.Background(), db, Advertisement{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first := serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?country=TW", "")
+	second := serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?country=TW", "")
+	if first.Code != http.StatusOK || first.Body.String() != second.Body.String() {
+		t.Errorf("expected identical responses, got: %s, %s", first.Body.String(), second.Body.String())
+	}
+	if calls := db.calls["GetActiveAdvertisements"]; calls != 1 {
+		t.Errorf("expected 1 database query, got: %d", calls)
+	}
+
+	// 不同的條件不會共用快取
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?country=JP", "")
+	if calls := db.calls["GetActiveAdvertisements"]; calls != 2 {
+		t.Errorf("expected 2 database queries, got: %d", calls)
+	}
+}
+
+// 先套用 variant 再套用翻譯 (快取中的廣告也一樣)
+func TestHandler_GetAdvertisementHandler_variantLocalization(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+	ad := Advertisement{
+		Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour),
+		Variants:      []Variant{{Name: "A", Weight: 1, Title: "AD 1 (A)", Creative: &Creative{CTALabel: "Go"}}},
+		Localizations: map[string]Localization{"zh-TW": {Title: "廣告 1"}},
+	}
+	if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		target   string
+		expected AdvertisementItem
+	}{
+		{target: "/ad", expected: AdvertisementItem{Advertisement: sqlc.Advertisement{Title: "AD 1 (A)", CtaLabel: "Go"}, VariantName: "A"}},
+		{target: "/ad?lang=zh-TW", expected: AdvertisementItem{Advertisement: sqlc.Advertisement{Title: "廣告 1", CtaLabel: "Go"}, VariantName: "A"}},
+		{target: "/ad?lang=zh-TW", expected: AdvertisementItem{Advertisement: sqlc.Advertisement{Title: "廣告 1", CtaLabel: "Go"}, VariantName: "A"}},
+	}
+	for _, tc := range testCases {
+		recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, tc.target, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got: %d (%s)", tc.target, recorder.Code, recorder.Body.String())
+		}
+		var body struct {
+			Items []AdvertisementItem `json:"items"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(body.Items) != 1 {
+			t.Fatalf("%s: expected 1 item, got: %d", tc.target, len(body.Items))
+		}
+		item := body.Items[0]
+		if item.Title != tc.expected.Title || item.CtaLabel != tc.expected.CtaLabel || item.VariantName != tc.expected.VariantName {
+			t.Errorf("%s: expected: %+v, got: %+v", tc.target, tc.expected, item)
+		}
+	}
+}
+
+func TestHandler_GetAdvertisementHandler_geo(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Region: []string{"TW-TPE"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Geofence: []Geofence{{Lat: 25.034, Lng: 121.5645, Radius: 2000}}}}},
+	}
+	for _, ad := range ads {
+		if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	titles := func(target string) []string {
+		t.Helper()
+		recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, target, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d (%s)", recorder.Code, recorder.Body.String())
+		}
+		var body struct {
+			Items []AdvertisementItem `json:"items"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		result := []string{}
+		for _, item := range body.Items {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		target   string
+		expected []string
+	}{
+		{target: "/ad", expected: []string{"AD 1"}}, // 與 country 相同, 沒有指定時不限制 region
+		{target: "/ad?region=TW-TPE", expected: []string{"AD 1"}},
+		{target: "/ad?region=TW-KHH", expected: []string{}},
+		{target: "/ad?country=TW", expected: []string{"AD 1"}},
+		{target: "/ad?country=JP&lat=25.0330&lng=121.5654", expected: []string{"AD 2"}},
+		{target: "/ad?country=JP&lat=25.0478&lng=121.5170", expected: []string{}},
+		{target: "/ad?region=TW-TPE&lat=25.0330&lng=121.5654", expected: []string{"AD 1", "AD 2"}},
+	}
+	for _, tc := range testCases {
+		if got := titles(tc.target); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: expected: %v, got: %v", tc.target, tc.expected, got)
+		}
+	}
+
+	// 同一個 geofence 內的不同位置共用快取
+	calls := db.calls["GetActiveAdvertisements"]
+	titles("/ad?country=JP&lat=25.0335&lng=121.5650")
+	if got := db.calls["GetActiveAdvertisements"]; got != calls {
+		t.Errorf("expected no database query, got: %d", got-calls)
+	}
+}
+
+func TestHandler_GetAdvertisementHandler_contextual(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Keyword: []string{"Nintendo Switch", "ＰＳ５"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(2 * time.Hour), Conditions: []AdvertisementCondition{{Category: []string{"Makeup", "美妝"}}}},
+	}
+	for _, ad := range ads {
+		if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// 其他 replica 新增的廣告: 重新讀取之後才認得它們的 keywords/categories
+	if err := handler.ReloadReferenceData(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		target       string
+		expectedCode int
+		expected     []string
+	}{
+		{target: "/ad", expectedCode: http.StatusOK, expected: []string{}},
+		{target: "/ad?keywords=ps5", expectedCode: http.StatusOK, expected: []string{"AD 1"}},
+		{target: "/ad?keywords=xbox,nintendo%20switch", expectedCode: http.StatusOK, expected: []string{"AD 1"}},
+		{target: "/ad?keywords=xbox&keywords=%EF%BC%AE%EF%BD%89%EF%BD%8E%EF%BD%94%EF%BD%85%EF%BD%8E%EF%BD%84%EF%BD%8F%E3%80%80Switch", expectedCode: http.StatusOK, expected: []string{"AD 1"}}, // Ｎｉｎｔｅｎｄｏ　Switch
+		{target: "/ad?keywords=switch", expectedCode: http.StatusOK, expected: []string{}},
+		{target: "/ad?category=MAKEUP", expectedCode: http.StatusOK, expected: []string{"AD 2"}},
+		{target: "/ad?category=%E7%BE%8E%E5%A6%9D&keywords=ps5", expectedCode: http.StatusOK, expected: []string{"AD 1", "AD 2"}}, // 美妝
+		{target: "/ad?category=%E3%80%80", expectedCode: http.StatusBadRequest},
+		{target: "/ad?keywords=" + strings.Repeat("a", 65), expectedCode: http.StatusBadRequest},
+		{target: "/ad?keywords=" + strings.Repeat("k,", 20) + "a,b,c,d,e,f,g,h,i,j,l,m,n,o,p,q,r,s,t,u,v", expectedCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, tc.target, "")
+		if recorder.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got: %d (%s)", tc.target, tc.expectedCode, recorder.Code, recorder.Body.String())
+			continue
+		}
+		if tc.expectedCode != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Items []AdvertisementItem `json:"items"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		titles := []string{}
+		for _, item := range body.Items {
+			titles = append(titles, item.Title)
+		}
+		if !reflect.DeepEqual(titles, tc.expected) {
+			t.Errorf("%s: expected: %v, got: %v", tc.target, tc.expected, titles)
+		}
+	}
+
+	// 正規化後相同的 keywords 共用快取
+	calls := db.calls["GetActiveAdvertisements"]
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?keywords=PS5,Nintendo%20Switch", "")
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?keywords=nintendo%20switch&keywords=%EF%BD%90%EF%BD%93%EF%BC%95", "") // ｐｓ５
+	if got := db.calls["GetActiveAdvertisements"]; got != calls+1 {
+		t.Errorf("expected 1 database query, got: %d", got-calls)
+	}
+
+	// 沒有廣告使用的 keywords/category 不放進 cache key (與 /ad 共用快取)
+	calls = db.calls["GetActiveAdvertisements"]
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?keywords=xbox", "")
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?keywords=wii,switch", "")
+	serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?category=news", "")
+	if got := db.calls["GetActiveAdvertisements"]; got != calls {
+		t.Errorf("expected no database query, got: %d", got-calls)
+	}
+}
+
+func TestHandler_GetAdvertisementHandler_geoIP(t *testing.T) {
+	reader, err := geoip.Open("../geoip/testdata/test.mmdb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Country: []string{"TW"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Country: []string{"JP"}}}},
+		{Title: "AD 3", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Region: []string{"TW-TPE"}}}},
+	}
+
+	testCases := []struct {
+		name             string
+		target           string
+		clientIP         string
+		disabled         bool
+		expectedTitles   []string
+		expectedInferred *Inferred
+	}{
+		{name: "country and region", target: "/ad", clientIP: "1.2.3.4", expectedTitles: []string{"AD 1", "AD 3"}, expectedInferred: &Inferred{Country: "TW", Region: "TW-TPE"}},
+		{name: "country only", target: "/ad", clientIP: "1.2.4.5", expectedTitles: []string{"AD 1", "AD 3"}, expectedInferred: &Inferred{Country: "TW"}},
+		{name: "other country", target: "/ad", clientIP: "5.6.7.8", expectedTitles: []string{"AD 2"}, expectedInferred: &Inferred{Country: "JP", Region: "JP-13"}},
+		{name: "country given", target: "/ad?country=JP", clientIP: "1.2.3.4", expectedTitles: []string{"AD 2"}},
+		{name: "region given", target: "/ad?region=TW-KHH", clientIP: "1.2.3.4", expectedTitles: []string{"AD 1"}},
+		{name: "unknown ip", target: "/ad", clientIP: "192.0.2.1", expectedTitles: []string{"AD 1", "AD 2", "AD 3"}},
+		{name: "disabled", target: "/ad", clientIP: "1.2.3.4", disabled: true, expectedTitles: []string{"AD 1", "AD 2", "AD 3"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if !tc.disabled {
+				handler.SetGeoIP(reader)
+			}
+
+			recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, tc.target, "", "X-Forwarded-For", tc.clientIP)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got: %d (%s)", recorder.Code, recorder.Body.String())
+			}
+			var body struct {
+				Items    []AdvertisementItem `json:"items"`
+				Inferred *Inferred           `json:"inferred"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			titles := []string{}
+			for _, item := range body.Items {
+				titles = append(titles, item.Title)
+			}
+			slices.Sort(titles)
+			if !reflect.DeepEqual(titles, tc.expectedTitles) {
+				t.Errorf("expected: %v, got: %v", tc.expectedTitles, titles)
+			}
+			if !reflect.DeepEqual(body.Inferred, tc.expectedInferred) {
+				t.Errorf("expected inferred: %+v, got: %+v", tc.expectedInferred, body.Inferred)
+			}
+		})
+	}
+}
+
+func TestHandler_GetAdvertisementHandler_inferPlatform(t *testing.T) {
+	const (
+		iosApp      = "Dcard/5.32.0 (iPhone; iOS 17.4; Scale/3.00)"
+		androidWeb  = "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.6312.40 Mobile Safari/537.36"
+		googlebot   = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+		desktopEdge = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36 Edg/123.0.2420.81"
+	)
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Platform: []string{"ios"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Platform: []string{"web"}}}},
+	}
+
+	testCases := []struct {
+		name             string
+		target           string
+		userAgent        string
+		disabled         bool
+		expectedCode     int
+		expectedTitles   []string
+		expectedInferred *Inferred
+	}{
+		{name: "ios app", target: "/ad", userAgent: iosApp, expectedCode: http.StatusOK, expectedTitles: []string{"AD 1"}, expectedInferred: &Inferred{Platform: "ios", Device: "mobile"}},
+		{name: "mobile browser", target: "/ad", userAgent: androidWeb, expectedCode: http.StatusOK, expectedTitles: []string{"AD 2"}, expectedInferred: &Inferred{Platform: "web", Device: "mobile"}},
+		{name: "auto", target: "/ad?platform=auto", userAgent: desktopEdge, expectedCode: http.StatusOK, expectedTitles: []string{"AD 2"}, expectedInferred: &Inferred{Platform: "web", Device: "desktop"}},
+		{name: "platform given", target: "/ad?platform=ios", userAgent: androidWeb, expectedCode: http.StatusOK, expectedTitles: []string{"AD 1"}},
+		{name: "bot", target: "/ad", userAgent: googlebot, expectedCode: http.StatusOK, expectedTitles: []string{"AD 1", "AD 2"}, expectedInferred: &Inferred{Device: "bot"}},
+		{name: "no user agent", target: "/ad", expectedCode: http.StatusOK, expectedTitles: []string{"AD 1", "AD 2"}},
+		{name: "disabled", target: "/ad", userAgent: iosApp, disabled: true, expectedCode: http.StatusOK, expectedTitles: []string{"AD 1", "AD 2"}},
+		{name: "auto disabled", target: "/ad?platform=auto", userAgent: iosApp, disabled: true, expectedCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			handler.api.InferPlatform = !tc.disabled
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, tc.target, "", "User-Agent", tc.userAgent)
+			if recorder.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Items    []AdvertisementItem `json:"items"`
+				Inferred *Inferred           `json:"inferred"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			titles := []string{}
+			for _, item := range body.Items {
+				titles = append(titles, item.Title)
+			}
+			slices.Sort(titles)
+			if !reflect.DeepEqual(titles, tc.expectedTitles) {
+				t.Errorf("expected: %v, got: %v", tc.expectedTitles, titles)
+			}
+			if !reflect.DeepEqual(body.Inferred, tc.expectedInferred) {
+				t.Errorf("expected inferred: %+v, got: %+v", tc.expectedInferred, body.Inferred)
+			}
+		})
+	}
+}
@@ -3,55 +3,145 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"log"
+	"net"
+	"sync"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
-	"github.com/lnfu/dcard-intern/app/cache"
+	"github.com/lnfu/dcard-intern/app/config"
+	"github.com/lnfu/dcard-intern/app/geoip"
 	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
 )
 
-func Int32Ptr(i int32) *int32    { return &i }
-func StringPtr(s string) *string { return &s }
+func Int32Ptr(i int32) *int32       { return &i }
+func Float64Ptr(f float64) *float64 { return &f }
+func StringPtr(s string) *string    { return &s }
 
-var ctx = context.Background()
+// handler 使用的資料庫操作 (store.MySQL, store.Postgres, store.SQLite 或 store.Memory),
+// 寫入多個 table 的操作在 InTx 中以 sqlc.Querier 執行
+type AdStore interface {
+	// GET /ad
+	GetActiveAdvertisements(ctx context.Context, arg sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error)
+	GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]sqlc.AdvertisementVariant, error)
+	GetAdvertisementLocalizations(ctx context.Context, arg sqlc.GetAdvertisementLocalizationsParams) ([]sqlc.GetAdvertisementLocalizationsRow, error)
+	ResolveGeofences(ctx context.Context, arg sqlc.ResolveGeofencesParams) ([]int32, error)
+
+	// 管理廣告與 status
+	ListAdvertisements(ctx context.Context, arg sqlc.ListAdvertisementsParams) ([]sqlc.Advertisement, error)
+	GetAdvertisementStatus(ctx context.Context, id int32) (sqlc.GetAdvertisementStatusRow, error)
+	UpdateAdvertisementStatus(ctx context.Context, arg sqlc.UpdateAdvertisementStatusParams) (int64, error)
+	ActivateScheduledAdvertisements(ctx context.Context, now time.Time) (int64, error)
+	ArchiveExpiredAdvertisements(ctx context.Context, now time.Time) (int64, error)
+	CountAdvertisementsByStatus(ctx context.Context) ([]sqlc.CountAdvertisementsByStatusRow, error)
+
+	// reference data
+	GetAllGenders(ctx context.Context) ([]string, error)
+	GetAllCountries(ctx context.Context) ([]string, error)
+	GetAllCountryGroups(ctx context.Context) ([]string, error)
+	GetAllPlatforms(ctx context.Context) ([]string, error)
+	GetAllSegments(ctx context.Context) ([]string, error)
+	GetAllLocales(ctx context.Context) ([]string, error)
+	GetAllKeywords(ctx context.Context) ([]string, error)
+	GetAllCategories(ctx context.Context) ([]string, error)
+	UpsertGender(ctx context.Context, arg sqlc.UpsertGenderParams) error
+	UpsertCountry(ctx context.Context, arg sqlc.UpsertCountryParams) error
+	UpsertPlatform(ctx context.Context, name string) error
+	UpsertSegment(ctx context.Context, arg sqlc.UpsertSegmentParams) error
+	ListCountryGroupMembers(ctx context.Context) ([]sqlc.ListCountryGroupMembersRow, error)
+	ListSegments(ctx context.Context) ([]sqlc.ListSegmentsRow, error)
+
+	// 逐列取出 ExportAdvertisements 的結果
+	ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error
+	// 在同一個 transaction 中執行 fn, fn 回傳 error 時 rollback
+	InTx(ctx context.Context, fn func(sqlc.Querier) error) error
+}
+
+// handler 使用的快取 (cache.Cache 或 cache.Memory), 沒有快取時回傳 cache.ErrCacheMiss
+type AdCache interface {
+	GetAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error
+	SetAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error
+	InvalidateAdvertisements(ctx context.Context) error
+}
+
+// 以 client IP 查詢位置 (geoip.Reader), 沒有設定 geoip.database 時為 nil
+type GeoIPLookup interface {
+	Lookup(ip net.IP) (geoip.Location, bool)
+}
 
 type Handler struct {
-	databaseQueries *sqlc.Queries
-	cac             *cache.Cache
+	databaseQueries AdStore
+	cac             AdCache
 	genderSet       mapset.Set[string]
 	countrySet      mapset.Set[string]
+	countryGroupSet mapset.Set[string]
 	platformSet     mapset.Set[string]
+	segmentSet      mapset.Set[string]
+	localeSet       mapset.Set[string]
+	keywordSet      mapset.Set[string] // archived 以外的廣告條件中的 keywords
+	categorySet     mapset.Set[string] // archived 以外的廣告條件中的 categories
+	api             config.API
+	geoIP           GeoIPLookup
+	timeouts        Timeouts
+	// admin API 更新 set 時與 ReloadReferenceData 互斥, 避免 reload 用較舊的結果蓋掉剛完成的變更
+	referenceMu sync.Mutex
 }
 
-func NewHandler(db *sqlc.Queries, cac *cache.Cache) *Handler {
-	genders, err := db.GetAllGenders(ctx)
+// 建立 Handler 並從 db 載入 reference data (gender/country/country group/platform/segment/locale/keyword/category)
+func NewHandler(ctx context.Context, db AdStore, cac AdCache, api config.API) (*Handler, error) {
+	genderSet, err := loadSet(ctx, db.GetAllGenders)
+	if err != nil {
+		return nil, fmt.Errorf("load genders: %w", err)
+	}
+
+	countrySet, err := loadSet(ctx, db.GetAllCountries)
+	if err != nil {
+		return nil, fmt.Errorf("load countries: %w", err)
+	}
+
+	countryGroupSet, err := loadSet(ctx, db.GetAllCountryGroups)
 	if err != nil {
-		log.Fatalln("Database error", err.Error())
+		return nil, fmt.Errorf("load country groups: %w", err)
 	}
-	genderSet := mapset.NewSet[string]()
-	for _, gender := range genders {
-		genderSet.Add(gender)
+
+	platformSet, err := loadSet(ctx, db.GetAllPlatforms)
+	if err != nil {
+		return nil, fmt.Errorf("load platforms: %w", err)
 	}
 
-	countries, err := db.GetAllCountries(ctx)
+	segmentSet, err := loadSet(ctx, db.GetAllSegments)
 	if err != nil {
-		log.Fatalln("Database error", err.Error())
+		return nil, fmt.Errorf("load segments: %w", err)
 	}
-	countrySet := mapset.NewSet[string]()
-	for _, country := range countries {
-		countrySet.Add(country)
+
+	localeSet, err := loadSet(ctx, db.GetAllLocales)
+	if err != nil {
+		return nil, fmt.Errorf("load locales: %w", err)
 	}
 
-	platforms, err := db.GetAllPlatforms(ctx)
+	keywordSet, err := loadSet(ctx, db.GetAllKeywords)
 	if err != nil {
-		log.Fatalln("Database error", err.Error())
+		return nil, fmt.Errorf("load keywords: %w", err)
 	}
-	platformSet := mapset.NewSet[string]()
-	for _, platform := range platforms {
-		platformSet.Add(platform)
+
+	categorySet, err := loadSet(ctx, db.GetAllCategories)
+	if err != nil {
+		return nil, fmt.Errorf("load categories: %w", err)
 	}
 
-	return &Handler{db, cac, genderSet, countrySet, platformSet}
+	return &Handler{db, cac, genderSet, countrySet, countryGroupSet, platformSet, segmentSet, localeSet, keywordSet, categorySet, api, nil, Timeouts{}, sync.Mutex{}}, nil
+}
+
+// 啟用 GET /ad 的 IP 定位 (沒有 country 與 region 時)
+func (handler *Handler) SetGeoIP(geoIP GeoIPLookup) {
+	handler.geoIP = geoIP
+}
+
+func loadSet(ctx context.Context, query func(context.Context) ([]string, error)) (mapset.Set[string], error) {
+	values, err := query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mapset.NewSet(values...), nil
 }
 
 type InvalidQueryParameterError struct {
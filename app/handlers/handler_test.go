@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/cache"
+	"github.com/lnfu/dcard-intern/app/config"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/store"
+)
+
+// failures 設定為 errSlow 的方法等到 ctx 結束 (timeout) 才回傳
+var errSlow = errors.New("slow")
+
+func fail(ctx context.Context, err error) error {
+	if err == errSlow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+// store.Memory 加上可以指定失敗的方法 (method 名稱 -> error) 與呼叫次數
+type fakeStore struct {
+	*store.Memory
+	failures map[string]error
+	calls    map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{store.NewMemory(), map[string]error{}, map[string]int{}}
+}
+
+func (s *fakeStore) call(ctx context.Context, method string) error {
+	s.calls[method]++
+	return fail(ctx, s.failures[method])
+}
+
+// InTx 在開始時失敗, InTx.commit 在 fn 執行完之後失敗 (rollback)
+func (s *fakeStore) InTx(ctx context.Context, fn func(sqlc.Querier) error) error {
+	if err := s.call(ctx, "InTx"); err != nil {
+		return err
+	}
+	return s.Memory.InTx(ctx, func(queries sqlc.Querier) error {
+		if err := fn(queries); err != nil {
+			return err
+		}
+		return s.call(ctx, "InTx.commit")
+	})
+}
+
+func (s *fakeStore) GetActiveAdvertisements(ctx context.Context, arg sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	if err := s.call(ctx, "GetActiveAdvertisements"); err != nil {
+		return nil, err
+	}
+	return s.Memory.GetActiveAdvertisements(ctx, arg)
+}
+
+func (s *fakeStore) GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]sqlc.AdvertisementVariant, error) {
+	if err := s.call(ctx, "GetAdvertisementVariants"); err != nil {
+		return nil, err
+	}
+	return s.Memory.GetAdvertisementVariants(ctx, advertisementIds)
+}
+
+func (s *fakeStore) ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error {
+	if err := s.call(ctx, "ExportAdvertisementsEach"); err != nil {
+		return err
+	}
+	return s.Memory.ExportAdvertisementsEach(ctx, arg, fn)
+}
+
+func (s *fakeStore) GetAllCountries(ctx context.Context) ([]string, error) {
+	if err := s.call(ctx, "GetAllCountries"); err != nil {
+		return nil, err
+	}
+	return s.Memory.GetAllCountries(ctx)
+}
+
+// cache.Memory 加上可以指定失敗的方法與呼叫次數
+type fakeCache struct {
+	*cache.Memory
+	failures map[string]error
+	calls    map[string]int
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{cache.NewMemory(time.Minute), map[string]error{}, map[string]int{}}
+}
+
+func (c *fakeCache) call(ctx context.Context, method string) error {
+	c.calls[method]++
+	return fail(ctx, c.failures[method])
+}
+
+func (c *fakeCache) GetAdvertisementsFromCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	if err := c.call(ctx, "GetAdvertisementsFromCache"); err != nil {
+		return err
+	}
+	return c.Memory.GetAdvertisementsFromCache(ctx, params, locale, ads)
+}
+
+func (c *fakeCache) SetAdvertisementsToCache(ctx context.Context, params sqlc.GetActiveAdvertisementsParams, locale string, ads any) error {
+	if err := c.call(ctx, "SetAdvertisementsToCache"); err != nil {
+		return err
+	}
+	return c.Memory.SetAdvertisementsToCache(ctx, params, locale, ads)
+}
+
+func newTestHandler(t *testing.T) (*Handler, *fakeStore, *fakeCache) {
+	t.Helper()
+	db := newFakeStore()
+	cac := newFakeCache()
+	handler, err := NewHandler(context.Background(), db, cac, config.Default().API)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return handler, db, cac
+}
+
+func serve(handlerFunc gin.HandlerFunc, method string, target string, body string, header ...string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, strings.SplitN(target, "?", 2)[0], handlerFunc)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestNewHandler(t *testing.T) {
+	db := newFakeStore()
+	db.failures["GetAllCountries"] = errors.New("connection refused")
+
+	_, err := NewHandler(context.Background(), db, newFakeCache(), config.Default().API)
+	expectedError := errors.New("load countries: connection refused")
+	if err == nil || err.Error() != expectedError.Error() {
+		t.Errorf("expected error: %v, got: %v", expectedError, err)
+	}
+
+	handler, _, _ := newTestHandler(t)
+	if !handler.countrySet.Contains("TW") || !handler.localeSet.Contains("zh-TW") {
+		t.Errorf("reference data not loaded")
+	}
+}
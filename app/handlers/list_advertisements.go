@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/utils"
+)
+
+type ListQueryParameters struct {
+	Status *string `form:"status" example:"active"`
+	Offset *int32  `form:"offset" example:"0"`
+	Limit  *int32  `form:"limit" example:"20"`
+}
+
+// @Summary		列出所有廣告 (管理用, 不論狀態與條件)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		status query string false "狀態條件" Enums(draft, scheduled, active, paused, archived)
+// @Param		offset query int false " "
+// @Param		limit query int false " "
+// @Produce		json
+// @Tags		advertisement
+// @Router		/ads [get]
+func (handler *Handler) ListAdvertisementsHandler(ctx *gin.Context) {
+	var queryParameters ListQueryParameters
+	if err := ctx.ShouldBindQuery(&queryParameters); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	if err := handler.validateListQueryParameters(queryParameters); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+
+	params := sqlc.ListAdvertisementsParams{
+		Status: utils.NullStringFromStringPointer(queryParameters.Status),
+		Offset: 0,
+		Limit:  handler.api.ListDefaultLimit,
+	}
+	if queryParameters.Offset != nil {
+		params.Offset = *queryParameters.Offset
+	}
+	if queryParameters.Limit != nil {
+		params.Limit = *queryParameters.Limit
+	}
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	ads, err := handler.databaseQueries.ListAdvertisements(dbCtx, params)
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	if ads == nil {
+		ads = []sqlc.Advertisement{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": ads,
+	})
+}
+
+// 判斷 list query parameters 是否 valid
+func (handler *Handler) validateListQueryParameters(queryParameters ListQueryParameters) error {
+	// status
+	if queryParameters.Status != nil && !isAdvertisementStatus(*queryParameters.Status) {
+		return errors.New("invalid status value")
+	}
+
+	// offset
+	if queryParameters.Offset != nil && (*queryParameters.Offset < 0) {
+		return errors.New("invalid offset value (must be >= 0)")
+	}
+
+	// limit
+	if queryParameters.Limit != nil && (*queryParameters.Limit < 1 || *queryParameters.Limit > handler.api.MaxLimit) {
+		return fmt.Errorf("invalid limit value (must be 1 ~ %d)", handler.api.MaxLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lnfu/dcard-intern/app/config"
+)
+
+func TestHandler_validateListQueryParameters(t *testing.T) {
+	handler := Handler{api: config.Default().API}
+	testCases := []struct {
+		name            string
+		queryParameters ListQueryParameters
+		expectedError   error
+	}{
+		{
+			name:            "valid (empty)",
+			queryParameters: ListQueryParameters{},
+			expectedError:   nil,
+		},
+		{
+			name: "valid (all)",
+			queryParameters: ListQueryParameters{
+				Status: StringPtr("paused"),
+				Offset: Int32Ptr(20),
+				Limit:  Int32Ptr(100),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid status",
+			queryParameters: ListQueryParameters{
+				Status: StringPtr("deleted"),
+			},
+			expectedError: errors.New("invalid status value"),
+		},
+		{
+			name: "invalid offset (negative)",
+			queryParameters: ListQueryParameters{
+				Offset: Int32Ptr(-1),
+			},
+			expectedError: errors.New("invalid offset value (must be >= 0)"),
+		},
+		{
+			name: "invalid limit (> 100)",
+			queryParameters: ListQueryParameters{
+				Limit: Int32Ptr(101),
+			},
+			expectedError: errors.New("invalid limit value (must be 1 ~ 100)"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := handler.validateListQueryParameters(tc.queryParameters)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"unicode/utf8"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"golang.org/x/text/language"
+)
+
+// 每個 advertisement 最多幾種語言
+const maxLocalizations = 50
+
+// 特定語言的 title/creative 文字, 沒有填的欄位依 fallback chain (zh-TW → zh → 預設) 往下找
+type Localization struct {
+	Title       string `json:"title,omitempty" example:"廣告 55" extensions:"x-order=0"`
+	Description string `json:"description,omitempty" example:"全館商品 5 折起" extensions:"x-order=1"`
+	CTALabel    string `json:"ctaLabel,omitempty" example:"立即購買" extensions:"x-order=2"`
+}
+
+// 判斷 localizations 是否 valid
+func (handler *Handler) validateLocalizations(localizations map[string]Localization) error {
+	if len(localizations) > maxLocalizations {
+		return errors.New("invalid localizations value (must be <= 50 locales)")
+	}
+
+	for locale, localization := range localizations {
+		// locale
+		if !handler.localeSet.Contains(locale) {
+			return errors.New("invalid locale value (" + locale + ")")
+		}
+
+		// 至少要有一個欄位
+		if localization == (Localization{}) {
+			return errors.New("invalid localization value (" + locale + " must not be empty)")
+		}
+
+		// title/description/ctaLabel
+		if utf8.RuneCountInString(localization.Title) > maxTitleLength {
+			return errors.New("invalid localization title value (must be <= 255 characters)")
+		}
+		if utf8.RuneCountInString(localization.Description) > maxDescriptionLength {
+			return errors.New("invalid localization description value (must be <= 1000 characters)")
+		}
+		if utf8.RuneCountInString(localization.CTALabel) > maxCTALabelLength {
+			return errors.New("invalid localization ctaLabel value (must be <= 32 characters)")
+		}
+	}
+
+	return nil
+}
+
+// 依 lang (優先) 或 Accept-Language 找出最符合且有支援的 locale, 都沒有時回傳 "" (使用預設內容)
+func (handler *Handler) resolveLocale(lang *string, acceptLanguage string) string {
+	var tags []language.Tag
+	if lang != nil {
+		if tag, err := language.Parse(*lang); err == nil {
+			tags = []language.Tag{tag}
+		}
+	} else if acceptLanguage != "" {
+		// 已依 q 值由高到低排序
+		tags, _, _ = language.ParseAcceptLanguage(acceptLanguage)
+	}
+
+	for _, tag := range tags {
+		base, _, region := tag.Raw()
+		candidates := []string{base.String()}
+		if region.IsCountry() {
+			candidates = []string{base.String() + "-" + region.String(), base.String()}
+		}
+		for _, candidate := range candidates {
+			if handler.localeSet.Contains(candidate) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// locale 的 fallback chain (不含預設內容), e.g. zh-TW → [zh-TW, zh]
+func localeChain(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return []string{locale}
+	}
+	base, _ := tag.Base()
+	if base.String() == locale {
+		return []string{locale}
+	}
+	return []string{locale, base.String()}
+}
+
+// 依 chain 的順序取各欄位第一個有值的翻譯 (都沒有時為 nil)
+func resolveLocalization(chain []string, localizations map[string]sqlc.GetAdvertisementLocalizationsRow) *Localization {
+	resolved := Localization{}
+	for _, locale := range chain {
+		localization, ok := localizations[locale]
+		if !ok {
+			continue
+		}
+		if resolved.Title == "" {
+			resolved.Title = localization.Title
+		}
+		if resolved.Description == "" {
+			resolved.Description = localization.Description
+		}
+		if resolved.CTALabel == "" {
+			resolved.CTALabel = localization.CtaLabel
+		}
+	}
+	if resolved == (Localization{}) {
+		return nil
+	}
+	return &resolved
+}
+
+// 把翻譯有填的欄位套用到廣告上 (沒有翻譯的欄位保留預設內容)
+func applyLocalization(ad sqlc.Advertisement, localization *Localization) sqlc.Advertisement {
+	if localization == nil {
+		return ad
+	}
+	if localization.Title != "" {
+		ad.Title = localization.Title
+	}
+	if localization.Description != "" {
+		ad.Description = localization.Description
+	}
+	if localization.CTALabel != "" {
+		ad.CtaLabel = localization.CTALabel
+	}
+	return ad
+}
+
+// JSON_OBJECTAGG 的結果 -> map[locale]Localization
+func localizationsFromJSON(value json.RawMessage) (map[string]Localization, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var localizations map[string]Localization
+	if err := json.Unmarshal(value, &localizations); err != nil {
+		return nil, err
+	}
+	return localizations, nil
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+func TestHandler_validateLocalizations(t *testing.T) {
+	handler := Handler{
+		localeSet: mapset.NewSet[string]("en", "zh", "zh-TW", "ja"),
+	}
+
+	testCases := []struct {
+		name          string
+		localizations map[string]Localization
+		expectedError error
+	}{
+		{
+			name:          "valid (empty)",
+			localizations: nil,
+			expectedError: nil,
+		},
+		{
+			name: "valid",
+			localizations: map[string]Localization{
+				"zh-TW": {Title: "廣告 55", CTALabel: "立即購買"},
+				"ja":    {Description: "全品 50% オフ"},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid locale",
+			localizations: map[string]Localization{
+				"xx": {Title: "AD 55"},
+			},
+			expectedError: errors.New("invalid locale value (xx)"),
+		},
+		{
+			name: "invalid locale (case sensitive)",
+			localizations: map[string]Localization{
+				"zh-tw": {Title: "廣告 55"},
+			},
+			expectedError: errors.New("invalid locale value (zh-tw)"),
+		},
+		{
+			name: "invalid localization (empty)",
+			localizations: map[string]Localization{
+				"en": {},
+			},
+			expectedError: errors.New("invalid localization value (en must not be empty)"),
+		},
+		{
+			name: "invalid title (too long)",
+			localizations: map[string]Localization{
+				"en": {Title: strings.Repeat("a", 256)},
+			},
+			expectedError: errors.New("invalid localization title value (must be <= 255 characters)"),
+		},
+		{
+			name: "invalid ctaLabel (too long)",
+			localizations: map[string]Localization{
+				"zh": {CTALabel: strings.Repeat("買", 33)},
+			},
+			expectedError: errors.New("invalid localization ctaLabel value (must be <= 32 characters)"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := handler.validateLocalizations(tc.localizations)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHandler_resolveLocale(t *testing.T) {
+	handler := Handler{
+		localeSet: mapset.NewSet[string]("en", "zh", "zh-TW", "ja"),
+	}
+
+	testCases := []struct {
+		name           string
+		lang           *string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			name:     "none",
+			expected: "",
+		},
+		{
+			name:     "lang (exact)",
+			lang:     StringPtr("zh-TW"),
+			expected: "zh-TW",
+		},
+		{
+			name:     "lang (normalized)",
+			lang:     StringPtr("zh-tw"),
+			expected: "zh-TW",
+		},
+		{
+			name:     "lang (fallback to language)",
+			lang:     StringPtr("zh-HK"),
+			expected: "zh",
+		},
+		{
+			name:     "lang (with script)",
+			lang:     StringPtr("zh-Hant-TW"),
+			expected: "zh-TW",
+		},
+		{
+			name:     "lang (unsupported)",
+			lang:     StringPtr("fr"),
+			expected: "",
+		},
+		{
+			name:     "lang (invalid)",
+			lang:     StringPtr("not a locale"),
+			expected: "",
+		},
+		{
+			name:           "lang has priority over Accept-Language",
+			lang:           StringPtr("ja"),
+			acceptLanguage: "zh-TW",
+			expected:       "ja",
+		},
+		{
+			name:           "Accept-Language (q order)",
+			acceptLanguage: "fr;q=0.9, ja;q=0.5, en;q=0.8",
+			expected:       "en",
+		},
+		{
+			name:           "Accept-Language (skip unsupported)",
+			acceptLanguage: "fr-FR, en-US;q=0.8",
+			expected:       "en",
+		},
+		{
+			name:           "Accept-Language (invalid)",
+			acceptLanguage: ";;;",
+			expected:       "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			locale := handler.resolveLocale(tc.lang, tc.acceptLanguage)
+			if locale != tc.expected {
+				t.Errorf("expected: %q, got: %q", tc.expected, locale)
+			}
+		})
+	}
+}
+
+func TestLocaleChain(t *testing.T) {
+	testCases := []struct {
+		locale   string
+		expected []string
+	}{
+		{locale: "", expected: nil},
+		{locale: "zh", expected: []string{"zh"}},
+		{locale: "zh-TW", expected: []string{"zh-TW", "zh"}},
+		{locale: "en-GB", expected: []string{"en-GB", "en"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			chain := localeChain(tc.locale)
+			if !reflect.DeepEqual(chain, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, chain)
+			}
+		})
+	}
+}
+
+func TestApplyLocalization(t *testing.T) {
+	ad := sqlc.Advertisement{ID: 1, Title: "AD 55", Description: "50% off", CtaLabel: "Buy now", Format: "text"}
+	localizations := map[string]sqlc.GetAdvertisementLocalizationsRow{
+		"zh-TW": {AdvertisementID: 1, Locale: "zh-TW", Title: "廣告 55"},
+		"zh":    {AdvertisementID: 1, Locale: "zh", Title: "广告 55", CtaLabel: "立即购买"},
+	}
+
+	testCases := []struct {
+		name     string
+		chain    []string
+		expected sqlc.Advertisement
+	}{
+		{
+			name:     "default",
+			chain:    nil,
+			expected: ad,
+		},
+		{
+			name:     "field level fallback (zh-TW → zh → default)",
+			chain:    []string{"zh-TW", "zh"},
+			expected: sqlc.Advertisement{ID: 1, Title: "廣告 55", Description: "50% off", CtaLabel: "立即购买", Format: "text"},
+		},
+		{
+			name:     "missing locale",
+			chain:    []string{"ja"},
+			expected: ad,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localized := applyLocalization(ad, resolveLocalization(tc.chain, localizations))
+			if localized != tc.expected {
+				t.Errorf("expected: %+v, got: %+v", tc.expected, localized)
+			}
+		})
+	}
+}
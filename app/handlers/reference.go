@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// 可以在執行期間新增/停用的 reference data (admin API 的 :kind)
+type referenceKind struct {
+	singular string
+	pattern  *regexp.Regexp
+	maxName  int // 0: 沒有 name (platform 的 code 就是 name)
+	set      func(handler *Handler) mapset.Set[string]
+	// method expressions (第一個參數是 AdStore, retire/count 在 transaction 中所以是 sqlc.Querier)
+	all    func(q AdStore, ctx context.Context) ([]string, error)
+	upsert func(q AdStore, ctx context.Context, code string, name string) error
+	retire func(q sqlc.Querier, ctx context.Context, code string) (int64, error)
+	count  func(q sqlc.Querier, ctx context.Context, code string) (int64, error)
+}
+
+var referenceKinds = map[string]referenceKind{
+	"genders": {
+		singular: "gender",
+		pattern:  regexp.MustCompile(`^[A-Z]$`),
+		maxName:  20,
+		set:      func(handler *Handler) mapset.Set[string] { return handler.genderSet },
+		all:      AdStore.GetAllGenders,
+		upsert: func(q AdStore, ctx context.Context, code string, name string) error {
+			return q.UpsertGender(ctx, sqlc.UpsertGenderParams{Code: code, Name: name})
+		},
+		retire: sqlc.Querier.RetireGender,
+		count:  sqlc.Querier.CountAdvertisementsUsingGender,
+	},
+	"countries": {
+		singular: "country",
+		pattern:  regexp.MustCompile(`^[A-Z]{2}$`),
+		maxName:  255,
+		set:      func(handler *Handler) mapset.Set[string] { return handler.countrySet },
+		all:      AdStore.GetAllCountries,
+		upsert: func(q AdStore, ctx context.Context, code string, name string) error {
+			return q.UpsertCountry(ctx, sqlc.UpsertCountryParams{Code: code, Name: name})
+		},
+		retire: sqlc.Querier.RetireCountry,
+		count:  sqlc.Querier.CountAdvertisementsUsingCountry,
+	},
+	"platforms": {
+		singular: "platform",
+		pattern:  regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`),
+		set:      func(handler *Handler) mapset.Set[string] { return handler.platformSet },
+		all:      AdStore.GetAllPlatforms,
+		upsert: func(q AdStore, ctx context.Context, code string, name string) error {
+			return q.UpsertPlatform(ctx, code)
+		},
+		retire: sqlc.Querier.RetirePlatform,
+		count:  sqlc.Querier.CountAdvertisementsUsingPlatform,
+	},
+}
+
+type ReferenceValue struct {
+	Name string `json:"name" example:"Non-binary"` // genders/countries 必填, platforms 不使用
+}
+
+// 從 path 取得 reference kind 與 code, 不合法時直接回應 400/404
+func referenceFromPath(ctx *gin.Context, withCode bool) (referenceKind, string, bool) {
+	kind, ok := referenceKinds[ctx.Param("kind")]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, "unknown reference kind"))
+		return kind, "", false
+	}
+	code := ctx.Param("code")
+	if withCode && !kind.pattern.MatchString(code) {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, fmt.Sprintf("invalid %s value (must match %s)", kind.singular, kind.pattern)))
+		return kind, "", false
+	}
+	return kind, code, true
+}
+
+// @Summary		列出可以使用的 reference data (不包含已停用的)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		kind path string true "種類" Enums(genders, countries, platforms)
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/{kind} [get]
+func (handler *Handler) ListReferenceValuesHandler(ctx *gin.Context) {
+	kind, _, ok := referenceFromPath(ctx, false)
+	if !ok {
+		return
+	}
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	values, err := kind.all(handler.databaseQueries, dbCtx)
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": values,
+	})
+}
+
+// @Summary		新增 reference data (已停用的會重新啟用)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		kind path string true "種類" Enums(genders, countries, platforms)
+// @Param		code path string true "gender/country 的 code 或 platform 的名稱" example(tv)
+// @Param		request body handlers.ReferenceValue false "genders/countries 必填"
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/{kind}/{code} [put]
+func (handler *Handler) PutReferenceValueHandler(ctx *gin.Context) {
+	kind, code, ok := referenceFromPath(ctx, true)
+	if !ok {
+		return
+	}
+
+	if kind.singular == "country" && handler.countryGroupSet.Contains(code) {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("country %s conflicts with a country group code", code)))
+		return
+	}
+
+	body := ReferenceValue{}
+	if kind.maxName > 0 {
+		if err := ctx.BindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+			return
+		}
+		if body.Name == "" || len(body.Name) > kind.maxName {
+			ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, fmt.Sprintf("invalid name value (must be 1 ~ %d characters)", kind.maxName)))
+			return
+		}
+	}
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	if err := kind.upsert(handler.databaseQueries, dbCtx, code, body.Name); err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	kind.set(handler).Add(code)
+
+	response := gin.H{"code": code}
+	if kind.maxName > 0 {
+		response["name"] = body.Name
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+var errReferenceInUse = errors.New("reference value in use")
+
+// @Summary		停用 reference data
+// @Description	archived 以外的廣告還在使用的值不能停用 (409, paused/draft 之後可能再變成 active), 已經存在的條件不受影響
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		kind path string true "種類" Enums(genders, countries, platforms)
+// @Param		code path string true "gender/country 的 code 或 platform 的名稱" example(tv)
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/{kind}/{code} [delete]
+func (handler *Handler) RetireReferenceValueHandler(ctx *gin.Context) {
+	kind, code, ok := referenceFromPath(ctx, true)
+	if !ok {
+		return
+	}
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	var count, retired int64
+	err := handler.databaseQueries.InTx(dbCtx, func(q sqlc.Querier) error {
+		var err error
+		count, err = kind.count(q, dbCtx, code)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errReferenceInUse
+		}
+		retired, err = kind.retire(q, dbCtx, code)
+		return err
+	})
+	if errors.Is(err, errReferenceInUse) {
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("%s %s is used by %d advertisements that are not archived", kind.singular, code, count)))
+		return
+	}
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	if retired == 0 {
+		ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, kind.singular+" not found"))
+		return
+	}
+	kind.set(handler).Remove(code)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    code,
+		"retired": true,
+	})
+}
+
+// 重新讀取 reference data (其他 replica 的變更), 只加入/移除有變動的值, 讀取中的 request 不會看到空的 set
+func (handler *Handler) ReloadReferenceData(ctx context.Context) error {
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	sets := []struct {
+		name  string
+		set   mapset.Set[string]
+		query func(context.Context) ([]string, error)
+	}{
+		{"genders", handler.genderSet, handler.databaseQueries.GetAllGenders},
+		{"countries", handler.countrySet, handler.databaseQueries.GetAllCountries},
+		{"country groups", handler.countryGroupSet, handler.databaseQueries.GetAllCountryGroups},
+		{"platforms", handler.platformSet, handler.databaseQueries.GetAllPlatforms},
+		{"segments", handler.segmentSet, handler.databaseQueries.GetAllSegments},
+		{"locales", handler.localeSet, handler.databaseQueries.GetAllLocales},
+		{"keywords", handler.keywordSet, handler.databaseQueries.GetAllKeywords},
+		{"categories", handler.categorySet, handler.databaseQueries.GetAllCategories},
+	}
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	for _, s := range sets {
+		values, err := s.query(dbCtx)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", s.name, err)
+		}
+		current := mapset.NewSet(values...)
+		s.set.Append(values...)
+		s.set.RemoveAll(s.set.Difference(current).ToSlice()...)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// 與 main.setupRouter 相同的 admin routes
+func serveAdmin(handler *Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/country-groups", handler.ListCountryGroupsHandler)
+	router.PUT("/admin/country-groups/:code", handler.PutCountryGroupHandler)
+	router.DELETE("/admin/country-groups/:code", handler.DeleteCountryGroupHandler)
+	router.GET("/admin/segments", handler.ListSegmentsHandler)
+	router.PUT("/admin/segments/:code", handler.PutSegmentHandler)
+	router.DELETE("/admin/segments/:code", handler.DeleteSegmentHandler)
+	router.GET("/admin/:kind", handler.ListReferenceValuesHandler)
+	router.PUT("/admin/:kind/:code", handler.PutReferenceValueHandler)
+	router.DELETE("/admin/:kind/:code", handler.RetireReferenceValueHandler)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestHandler_ReferenceValueHandlers(t *testing.T) {
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Platform: []string{"ios"}}}},
+		{Title: "AD 2", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Status: "paused", Conditions: []AdvertisementCondition{{Country: []string{"JP"}}}},
+		{Title: "AD 3", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Status: "archived", Conditions: []AdvertisementCondition{{Country: []string{"TW"}}}},
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		storeFailures map[string]error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{name: "list", method: http.MethodGet, target: "/admin/platforms", expectedCode: http.StatusOK, expectedBody: `{"items":["android","ios","web"]}`},
+		{name: "unknown kind", method: http.MethodGet, target: "/admin/locales", expectedCode: http.StatusNotFound, expectedBody: `{"error":"unknown reference kind"}`},
+		{name: "add platform", method: http.MethodPut, target: "/admin/platforms/tv", expectedCode: http.StatusOK, expectedBody: `{"code":"tv"}`},
+		{name: "add gender", method: http.MethodPut, target: "/admin/genders/X", body: `{"name":"Non-binary"}`, expectedCode: http.StatusOK, expectedBody: `{"code":"X","name":"Non-binary"}`},
+		{name: "add gender without name", method: http.MethodPut, target: "/admin/genders/X", body: `{}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid name value (must be 1 ~ 20 characters)"}`},
+		{name: "invalid country", method: http.MethodPut, target: "/admin/countries/tw", body: `{"name":"Taiwan"}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid country value (must match ^[A-Z]{2}$)"}`},
+		{name: "retire unused", method: http.MethodDelete, target: "/admin/platforms/web", expectedCode: http.StatusOK, expectedBody: `{"code":"web","retired":true}`},
+		{name: "retire used by paused ad", method: http.MethodDelete, target: "/admin/countries/JP", expectedCode: http.StatusConflict, expectedBody: `{"error":"country JP is used by 1 advertisements that are not archived"}`},
+		{name: "retire used by archived ad", method: http.MethodDelete, target: "/admin/countries/TW", expectedCode: http.StatusOK, expectedBody: `{"code":"TW","retired":true}`},
+		{name: "retire in use", method: http.MethodDelete, target: "/admin/platforms/ios", expectedCode: http.StatusConflict, expectedBody: `{"error":"platform ios is used by 1 advertisements that are not archived"}`},
+		{name: "retire unknown", method: http.MethodDelete, target: "/admin/platforms/tv", expectedCode: http.StatusNotFound, expectedBody: `{"error":"platform not found"}`},
+		{
+			name:          "database error",
+			method:        http.MethodDelete,
+			target:        "/admin/platforms/web",
+			storeFailures: map[string]error{"InTx": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"database error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for method, err := range tc.storeFailures {
+				db.failures[method] = err
+			}
+
+			recorder := serveAdmin(handler, tc.method, tc.target, tc.body)
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if recorder.Body.String() != tc.expectedBody {
+				t.Errorf("expected body: %s, got: %s", tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ReferenceValueHandlers_sets(t *testing.T) {
+	handler, _, _ := newTestHandler(t)
+
+	// 新增後馬上可以在條件中使用, 停用後不行
+	serveAdmin(handler, http.MethodPut, "/admin/platforms/tv", "")
+	if err := handler.validateCondition(AdvertisementCondition{Platform: []string{"tv"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	serveAdmin(handler, http.MethodDelete, "/admin/platforms/tv", "")
+	if err := handler.validateCondition(AdvertisementCondition{Platform: []string{"tv"}}); err == nil {
+		t.Error("expected error for retired platform")
+	}
+}
+
+func TestHandler_ReloadReferenceData(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+
+	// 其他 replica 的變更
+	if err := db.UpsertCountry(context.Background(), sqlc.UpsertCountryParams{Code: "XK", Name: "Kosovo"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.RetireGender(context.Background(), "F"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if handler.countrySet.Contains("XK") || !handler.genderSet.Contains("F") {
+		t.Fatal("expected sets to be unchanged before reload")
+	}
+
+	if err := handler.ReloadReferenceData(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handler.countrySet.Contains("XK") {
+		t.Error("expected XK after reload")
+	}
+	if handler.genderSet.Contains("F") || !handler.genderSet.Contains("M") {
+		t.Errorf("expected [M] after reload, got: %v", handler.genderSet.ToSlice())
+	}
+
+	db.failures["GetAllCountries"] = errors.New("connection refused")
+	if err := handler.ReloadReferenceData(context.Background()); err == nil || !strings.HasPrefix(err.Error(), "load countries") {
+		t.Errorf("expected load countries error, got: %v", err)
+	}
+	if !handler.countrySet.Contains("XK") {
+		t.Error("expected sets to be kept on error")
+	}
+}
+
+// 讀取 countries 之後停住, 直到 release 被 close
+type pausedReloadStore struct {
+	*fakeStore
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (s *pausedReloadStore) GetAllCountries(ctx context.Context) ([]string, error) {
+	values, err := s.fakeStore.GetAllCountries(ctx)
+	close(s.loaded)
+	<-s.release
+	return values, err
+}
+
+func TestHandler_ReloadReferenceData_concurrentPut(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	paused := &pausedReloadStore{db, make(chan struct{}), make(chan struct{})}
+	handler.databaseQueries = paused
+
+	// reload 讀到的 countries 還沒有 XK
+	reloaded := make(chan error)
+	go func() { reloaded <- handler.ReloadReferenceData(context.Background()) }()
+	<-paused.loaded
+
+	put := make(chan int)
+	go func() {
+		put <- serveAdmin(handler, http.MethodPut, "/admin/countries/XK", `{"name": "Kosovo"}`).Code
+	}()
+	select {
+	case <-put:
+		t.Fatal("expected PUT to wait for the reload")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(paused.release)
+
+	if err := <-reloaded; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code := <-put; code != http.StatusOK {
+		t.Fatalf("expected status %d, got: %d", http.StatusOK, code)
+	}
+	if !handler.countrySet.Contains("XK") {
+		t.Error("expected XK to be kept after the reload")
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/logging"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// segment 的 code (GET /ad 的 segments 與 AdvertisementCondition.Segment)
+var segmentPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+// 一個 request 最多帶的 segments
+const maxRequestSegments = 50
+
+type Segment struct {
+	Code       string `json:"code" example:"gamer" extensions:"x-order=0"`
+	Name       string `json:"name" example:"Gamers" extensions:"x-order=1"`
+	Conditions int64  `json:"conditions" extensions:"x-order=2"` // 使用這個 segment 的條件數
+}
+
+type SegmentRequest struct {
+	Name string `json:"name" example:"Gamers" extensions:"x-order=0"`
+}
+
+var (
+	errSegmentNotFound = errors.New("segment not found")
+	errSegmentInUse    = errors.New("segment in use")
+)
+
+// @Summary		列出 audience segments
+// @BasePath	/api/v1
+// @Version		1.0
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/segments [get]
+func (handler *Handler) ListSegmentsHandler(ctx *gin.Context) {
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	rows, err := handler.databaseQueries.ListSegments(dbCtx)
+	if err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+
+	segments := make([]Segment, len(rows))
+	for i, row := range rows {
+		segments[i] = Segment{Code: row.Code, Name: row.Name, Conditions: row.Conditions}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": segments,
+	})
+}
+
+// 從 path 取得 segment 的 code, 不合法時直接回應 400
+func segmentFromPath(ctx *gin.Context) (string, bool) {
+	code := ctx.Param("code")
+	if !segmentPattern.MatchString(code) {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, fmt.Sprintf("invalid segment value (must match %s)", segmentPattern)))
+		return "", false
+	}
+	return code, true
+}
+
+// @Summary		新增 audience segment 或修改它的 name
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		code path string true "segment 的 code" example(gamer)
+// @Param		request body handlers.SegmentRequest true "name"
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/segments/{code} [put]
+func (handler *Handler) PutSegmentHandler(ctx *gin.Context) {
+	code, ok := segmentFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	body := SegmentRequest{}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, err.Error()))
+		return
+	}
+	if body.Name == "" || len(body.Name) > 255 {
+		ctx.JSON(http.StatusBadRequest, logging.ErrorBody(ctx, "invalid name value (must be 1 ~ 255 characters)"))
+		return
+	}
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	if err := handler.databaseQueries.UpsertSegment(dbCtx, sqlc.UpsertSegmentParams{Code: code, Name: body.Name}); err != nil {
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	handler.segmentSet.Add(code)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": code,
+		"name": body.Name,
+	})
+}
+
+// @Summary		刪除 audience segment
+// @Description	還有條件使用的 segment 不能刪除 (409)
+// @BasePath	/api/v1
+// @Version		1.0
+// @Param		code path string true "segment 的 code" example(gamer)
+// @Produce		json
+// @Tags		admin
+// @Router		/admin/segments/{code} [delete]
+func (handler *Handler) DeleteSegmentHandler(ctx *gin.Context) {
+	code, ok := segmentFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	handler.referenceMu.Lock()
+	defer handler.referenceMu.Unlock()
+
+	dbCtx, cancel := handler.databaseContext(ctx)
+	defer cancel()
+	var count int64
+	err := handler.databaseQueries.InTx(dbCtx, func(q sqlc.Querier) error {
+		var err error
+		count, err = q.CountConditionsUsingSegment(dbCtx, code)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errSegmentInUse
+		}
+		deleted, err := q.DeleteSegment(dbCtx, code)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return errSegmentNotFound
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errSegmentNotFound):
+		ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, "segment not found"))
+		return
+	case errors.Is(err, errSegmentInUse):
+		ctx.JSON(http.StatusConflict, logging.ErrorBody(ctx, fmt.Sprintf("segment %s is used by %d conditions", code, count)))
+		return
+	case err != nil:
+		respondDependencyError(ctx, "database", err)
+		return
+	}
+	handler.segmentSet.Remove(code)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    code,
+		"deleted": true,
+	})
+}
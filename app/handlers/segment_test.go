@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_SegmentHandlers(t *testing.T) {
+	now := time.Now()
+	ads := []Advertisement{
+		{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Segment: []string{"gamer"}}}},
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		storeFailures map[string]error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{name: "list", method: http.MethodGet, target: "/admin/segments", expectedCode: http.StatusOK, expectedBody: `{"items":[{"code":"gamer","name":"Gamers","conditions":1},{"code":"traveler","name":"Travelers","conditions":0}]}`},
+		{name: "add", method: http.MethodPut, target: "/admin/segments/new_parent", body: `{"name":"New Parents"}`, expectedCode: http.StatusOK, expectedBody: `{"code":"new_parent","name":"New Parents"}`},
+		{name: "rename", method: http.MethodPut, target: "/admin/segments/gamer", body: `{"name":"Video Gamers"}`, expectedCode: http.StatusOK, expectedBody: `{"code":"gamer","name":"Video Gamers"}`},
+		{name: "invalid code", method: http.MethodPut, target: "/admin/segments/Gamer", body: `{"name":"Gamers"}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid segment value (must match ^[a-z][a-z0-9_]{1,31}$)"}`},
+		{name: "without name", method: http.MethodPut, target: "/admin/segments/gamer", body: `{}`, expectedCode: http.StatusBadRequest, expectedBody: `{"error":"invalid name value (must be 1 ~ 255 characters)"}`},
+		{name: "delete", method: http.MethodDelete, target: "/admin/segments/traveler", expectedCode: http.StatusOK, expectedBody: `{"code":"traveler","deleted":true}`},
+		{name: "delete in use", method: http.MethodDelete, target: "/admin/segments/gamer", expectedCode: http.StatusConflict, expectedBody: `{"error":"segment gamer is used by 1 conditions"}`},
+		{name: "delete unknown", method: http.MethodDelete, target: "/admin/segments/sports", expectedCode: http.StatusNotFound, expectedBody: `{"error":"segment not found"}`},
+		{
+			name:          "database error",
+			method:        http.MethodDelete,
+			target:        "/admin/segments/traveler",
+			storeFailures: map[string]error{"InTx": errors.New("connection refused")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"error":"database error"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, db, _ := newTestHandler(t)
+			for _, code := range []string{"gamer", "traveler"} {
+				if recorder := serveAdmin(handler, http.MethodPut, "/admin/segments/"+code, `{"name":"`+strings.ToUpper(code[:1])+code[1:]+`s"}`); recorder.Code != http.StatusOK {
+					t.Fatalf("expected status 200, got: %d (%s)", recorder.Code, recorder.Body.String())
+				}
+			}
+			for _, ad := range ads {
+				if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for method, err := range tc.storeFailures {
+				db.failures[method] = err
+			}
+
+			recorder := serveAdmin(handler, tc.method, tc.target, tc.body)
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), tc.expectedBody) {
+				t.Errorf("expected body to contain: %s, got: %s", tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_SegmentHandlers_matching(t *testing.T) {
+	handler, db, _ := newTestHandler(t)
+	now := time.Now()
+
+	// 新增的 segment 馬上可以在條件中使用
+	ad := Advertisement{Title: "AD 1", StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour), Conditions: []AdvertisementCondition{{Segment: []string{"gamer", "traveler"}}}}
+	if err := handler.validateAdvertisement(ad); err == nil || err.Error() != "invalid segment value" {
+		t.Fatalf("expected invalid segment value, got: %v", err)
+	}
+	for _, code := range []string{"gamer", "traveler", "sports"} {
+		serveAdmin(handler, http.MethodPut, "/admin/segments/"+code, `{"name":"`+code+`"}`)
+	}
+	if err := handler.validateAdvertisement(ad); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := handler.insertAdvertisement(context.Background(), db, ad); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		query        string
+		expectedCode int
+		matches      bool
+	}{
+		{query: "", expectedCode: http.StatusOK, matches: false},
+		{query: "segments=gamer", expectedCode: http.StatusOK, matches: true},
+		{query: "segments=sports,traveler", expectedCode: http.StatusOK, matches: true},
+		{query: "segments=sports&segments=gamer", expectedCode: http.StatusOK, matches: true},
+		{query: "segments=sports", expectedCode: http.StatusOK, matches: false},
+		{query: "segments=unknown_segment", expectedCode: http.StatusOK, matches: false},
+		{query: "segments=Gamer", expectedCode: http.StatusBadRequest},
+		{query: "segments=" + strings.Repeat("gamer,", 51), expectedCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			recorder := serve(handler.GetAdvertisementHandler, http.MethodGet, "/ad?"+tc.query, "")
+			if recorder.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got: %d (%s)", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedCode == http.StatusOK && strings.Contains(recorder.Body.String(), "AD 1") != tc.matches {
+				t.Errorf("expected matches: %v, got: %s", tc.matches, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"unicode/utf8"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+const (
+	maxVariants          = 10
+	maxVariantNameLength = 64
+	maxVariantWeight     = 100
+)
+
+// 同一個廣告的 creative 版本 (A/B test), 沒有填的欄位沿用廣告本身的 title/creative
+type Variant struct {
+	Name     string    `json:"name" example:"A" extensions:"x-order=0"`
+	Weight   int32     `json:"weight" example:"50" extensions:"x-order=1"`
+	Title    string    `json:"title,omitempty" example:"AD 55 (A)" extensions:"x-order=2"`
+	Creative *Creative `json:"creative,omitempty" extensions:"x-order=3"`
+}
+
+// 投放的廣告 (如果有 variants, 會是挑中的 variant 套用到廣告上的結果)
+type AdvertisementItem struct {
+	sqlc.Advertisement
+	VariantID   *int32 `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+}
+
+// 快取/資料庫取出的廣告, 包含所有 variants (每個 request 再各自挑一個) 與 locale 的翻譯
+type servingAdvertisement struct {
+	sqlc.Advertisement
+	Variants     []sqlc.AdvertisementVariant `json:"variants,omitempty"`
+	Localization *Localization               `json:"localization,omitempty"`
+}
+
+// 判斷 variants 是否 valid (creative 以套用到廣告之後的結果驗證)
+func validateVariants(ad Advertisement) error {
+	if len(ad.Variants) > maxVariants {
+		return errors.New("invalid variants value (must be <= 10 variants)")
+	}
+
+	base := Creative{}
+	if ad.Creative != nil {
+		base = *ad.Creative
+	}
+
+	names := make(map[string]bool, len(ad.Variants))
+	for _, variant := range ad.Variants {
+		// name
+		if variant.Name == "" || utf8.RuneCountInString(variant.Name) > maxVariantNameLength {
+			return errors.New("invalid variant name value (must be 1 ~ 64 characters)")
+		}
+		if names[variant.Name] {
+			return errors.New("invalid variant name value (must be unique)")
+		}
+		names[variant.Name] = true
+
+		// weight
+		if variant.Weight < 1 || variant.Weight > maxVariantWeight {
+			return errors.New("invalid variant weight value (must be 1 ~ 100)")
+		}
+
+		// title
+		if utf8.RuneCountInString(variant.Title) > maxTitleLength {
+			return errors.New("invalid variant title value (must be <= 255 characters)")
+		}
+
+		// creative
+		if err := validateCreative(mergeCreative(base, variant.Creative)); err != nil {
+			return errors.New("invalid variant " + variant.Name + ": " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// 用 override 中有填的欄位覆蓋 base
+func mergeCreative(base Creative, override *Creative) Creative {
+	if override == nil {
+		return base
+	}
+	if override.Description != "" {
+		base.Description = override.Description
+	}
+	if override.ImageURL != "" {
+		base.ImageURL = override.ImageURL
+		base.ImageWidth = override.ImageWidth
+		base.ImageHeight = override.ImageHeight
+	}
+	if override.LandingURL != "" {
+		base.LandingURL = override.LandingURL
+	}
+	if override.CTALabel != "" {
+		base.CTALabel = override.CTALabel
+	}
+	if override.Format != "" {
+		base.Format = override.Format
+	}
+	return base
+}
+
+// 挑一個 variant 投放: 有 userId 時同一個使用者對同一個廣告永遠拿到同一個 variant, 否則依權重隨機.
+// 先套用 variant 再套用翻譯 (翻譯優先於 variant 有填的 title/description/ctaLabel)
+func pickVariant(ad servingAdvertisement, userID *string) AdvertisementItem {
+	item := AdvertisementItem{Advertisement: ad.Advertisement}
+	if len(ad.Variants) == 0 {
+		item.Advertisement = applyLocalization(item.Advertisement, ad.Localization)
+		return item
+	}
+
+	var total int64
+	for _, variant := range ad.Variants {
+		total += int64(variant.Weight)
+	}
+
+	var bucket int64
+	if userID != nil {
+		hash := fnv.New64a()
+		hash.Write([]byte(strconv.Itoa(int(ad.ID)) + ":" + *userID))
+		bucket = int64(hash.Sum64() % uint64(total))
+	} else {
+		bucket = rand.Int64N(total)
+	}
+
+	for _, variant := range ad.Variants {
+		bucket -= int64(variant.Weight)
+		if bucket < 0 {
+			item.Advertisement = applyVariant(ad.Advertisement, variant)
+			item.VariantID = &variant.ID
+			item.VariantName = variant.Name
+			break
+		}
+	}
+	item.Advertisement = applyLocalization(item.Advertisement, ad.Localization)
+	return item
+}
+
+// 把 variant 有填的欄位套用到廣告上
+func applyVariant(ad sqlc.Advertisement, variant sqlc.AdvertisementVariant) sqlc.Advertisement {
+	if variant.Title != "" {
+		ad.Title = variant.Title
+	}
+	if variant.Description != "" {
+		ad.Description = variant.Description
+	}
+	if variant.ImageUrl != "" {
+		ad.ImageUrl = variant.ImageUrl
+		ad.ImageWidth = variant.ImageWidth
+		ad.ImageHeight = variant.ImageHeight
+	}
+	if variant.LandingUrl != "" {
+		ad.LandingUrl = variant.LandingUrl
+	}
+	if variant.CtaLabel != "" {
+		ad.CtaLabel = variant.CtaLabel
+	}
+	if variant.Format != "" {
+		ad.Format = variant.Format
+	}
+	return ad
+}
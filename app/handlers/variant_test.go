@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+func TestValidateVariants(t *testing.T) {
+	testCases := []struct {
+		name          string
+		advertisement Advertisement
+		expectedError error
+	}{
+		{
+			name:          "valid (no variants)",
+			advertisement: Advertisement{},
+			expectedError: nil,
+		},
+		{
+			name: "valid variants",
+			advertisement: Advertisement{
+				Variants: []Variant{
+					{Name: "A", Weight: 50, Title: "AD 55 (A)"},
+					{Name: "B", Weight: 50, Creative: &Creative{ImageURL: "https://cdn.example.com/b.png", ImageWidth: 1200, ImageHeight: 628, Format: "image"}},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid variant (format override uses advertisement image)",
+			advertisement: Advertisement{
+				Creative: &Creative{ImageURL: "https://cdn.example.com/a.png", ImageWidth: 1200, ImageHeight: 628},
+				Variants: []Variant{
+					{Name: "A", Weight: 1, Creative: &Creative{Format: "image"}},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "invalid variants (too many)",
+			advertisement: Advertisement{
+				Variants: make([]Variant, 11),
+			},
+			expectedError: errors.New("invalid variants value (must be <= 10 variants)"),
+		},
+		{
+			name: "invalid name (empty)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Weight: 50}},
+			},
+			expectedError: errors.New("invalid variant name value (must be 1 ~ 64 characters)"),
+		},
+		{
+			name: "invalid name (duplicated)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Name: "A", Weight: 50}, {Name: "A", Weight: 50}},
+			},
+			expectedError: errors.New("invalid variant name value (must be unique)"),
+		},
+		{
+			name: "invalid weight (zero)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Name: "A", Weight: 0}},
+			},
+			expectedError: errors.New("invalid variant weight value (must be 1 ~ 100)"),
+		},
+		{
+			name: "invalid weight (> 100)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Name: "A", Weight: 101}},
+			},
+			expectedError: errors.New("invalid variant weight value (must be 1 ~ 100)"),
+		},
+		{
+			name: "invalid title (too long)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Name: "A", Weight: 50, Title: strings.Repeat("a", 256)}},
+			},
+			expectedError: errors.New("invalid variant title value (must be <= 255 characters)"),
+		},
+		{
+			name: "invalid creative (image format without image)",
+			advertisement: Advertisement{
+				Variants: []Variant{{Name: "B", Weight: 50, Creative: &Creative{Format: "image"}}},
+			},
+			expectedError: errors.New("invalid variant B: invalid format value (image format requires imageUrl)"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVariants(tc.advertisement)
+			if err != nil && tc.expectedError == nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil && tc.expectedError != nil {
+				t.Errorf("expected error: %v, but got nil", tc.expectedError)
+				return
+			}
+			if err != nil && tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
+				t.Errorf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestPickVariant(t *testing.T) {
+	ad := servingAdvertisement{
+		Advertisement: sqlc.Advertisement{ID: 55, Title: "AD 55", Description: "base", Format: "text"},
+		Variants: []sqlc.AdvertisementVariant{
+			{ID: 1, AdvertisementID: 55, Name: "A", Weight: 70, Title: "AD 55 (A)"},
+			{ID: 2, AdvertisementID: 55, Name: "B", Weight: 30, ImageUrl: "https://cdn.example.com/b.png", ImageWidth: 1200, ImageHeight: 628, Format: "image"},
+		},
+	}
+
+	t.Run("no variants", func(t *testing.T) {
+		item := pickVariant(servingAdvertisement{Advertisement: ad.Advertisement}, StringPtr("u_1"))
+		if item.VariantID != nil || item.VariantName != "" || item.Advertisement != ad.Advertisement {
+			t.Errorf("unexpected item: %+v", item)
+		}
+	})
+
+	t.Run("sticky per user", func(t *testing.T) {
+		for _, userID := range []string{"u_1", "u_2", "u_3", "u_4"} {
+			first := pickVariant(ad, StringPtr(userID))
+			for i := 0; i < 10; i++ {
+				if item := pickVariant(ad, StringPtr(userID)); *item.VariantID != *first.VariantID {
+					t.Errorf("user %s: expected variant %d, got: %d", userID, *first.VariantID, *item.VariantID)
+				}
+			}
+		}
+	})
+
+	t.Run("variant is applied", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			item := pickVariant(ad, nil)
+			switch item.VariantName {
+			case "A":
+				if item.Title != "AD 55 (A)" || item.Description != "base" || item.Format != "text" {
+					t.Errorf("unexpected item for variant A: %+v", item)
+				}
+			case "B":
+				if item.Title != "AD 55" || item.ImageUrl != "https://cdn.example.com/b.png" || item.Format != "image" {
+					t.Errorf("unexpected item for variant B: %+v", item)
+				}
+			default:
+				t.Fatalf("unexpected variant: %+v", item)
+			}
+		}
+	})
+
+	t.Run("localization is applied after the variant", func(t *testing.T) {
+		localized := ad
+		localized.Localization = &Localization{Title: "廣告 55", CTALabel: "立即購買"}
+		for i := 0; i < 100; i++ {
+			item := pickVariant(localized, nil)
+			// 翻譯覆蓋 variant A 的 title, variant B 的 image 保留
+			if item.Title != "廣告 55" || item.CtaLabel != "立即購買" || item.Description != "base" {
+				t.Errorf("unexpected item for variant %s: %+v", item.VariantName, item)
+			}
+			if item.VariantName == "B" && item.ImageUrl != "https://cdn.example.com/b.png" {
+				t.Errorf("unexpected item for variant B: %+v", item)
+			}
+		}
+		if item := pickVariant(servingAdvertisement{Advertisement: ad.Advertisement, Localization: localized.Localization}, nil); item.Title != "廣告 55" {
+			t.Errorf("unexpected item without variants: %+v", item)
+		}
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := 0; i < 10000; i++ {
+			counts[pickVariant(ad, StringPtr("u_"+strconv.Itoa(i))).VariantName]++
+		}
+		// 70/30 split, allow generous tolerance
+		if counts["A"] < 6000 || counts["A"] > 8000 {
+			t.Errorf("unexpected distribution: %v", counts)
+		}
+	})
+}
+
+func TestMergeCreative(t *testing.T) {
+	base := Creative{
+		Description: "base",
+		ImageURL:    "https://cdn.example.com/a.png",
+		ImageWidth:  100,
+		ImageHeight: 100,
+		CTALabel:    "Buy",
+		Format:      "image",
+	}
+	merged := mergeCreative(base, &Creative{
+		ImageURL:    "https://cdn.example.com/b.png",
+		ImageWidth:  200,
+		ImageHeight: 50,
+		LandingURL:  "https://shop.example.com",
+	})
+	expected := Creative{
+		Description: "base",
+		ImageURL:    "https://cdn.example.com/b.png",
+		ImageWidth:  200,
+		ImageHeight: 50,
+		LandingURL:  "https://shop.example.com",
+		CTALabel:    "Buy",
+		Format:      "image",
+	}
+	if merged != expected {
+		t.Errorf("expected: %+v, got: %+v", expected, merged)
+	}
+	if mergeCreative(base, nil) != base {
+		t.Errorf("expected base creative when override is nil")
+	}
+}
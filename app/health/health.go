@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 一個 dependency 的檢查, 回傳 nil 表示可以使用
+type Check func(ctx context.Context) error
+
+// warmup 還沒完成
+var ErrWarmingUp = errors.New("warming up")
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// /healthz 與 /readyz, readiness 列出每個 dependency 的結果
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+// timeout 是每次 /readyz 所有 checks 的時間上限
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// 加入 readiness 的 check (開始 serve 之前呼叫)
+func (checker *Checker) Add(name string, check Check) {
+	checker.checks = append(checker.checks, namedCheck{name, check})
+}
+
+type Result struct {
+	Status     string  `json:"status"` // ok 或 error
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+type Report struct {
+	Status string            `json:"status"` // ready 或 not ready
+	Checks map[string]Result `json:"checks"`
+}
+
+// 同時執行所有 checks
+func (checker *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	results := make([]Result, len(checker.checks))
+	var wg sync.WaitGroup
+	for i, c := range checker.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := c.check(ctx)
+			results[i] = Result{Status: "ok", DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[i].Status = "error"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ready", Checks: make(map[string]Result, len(results))}
+	for i, result := range results {
+		if result.Status != "ok" {
+			report.Status = "not ready"
+		}
+		report.Checks[checker.checks[i].name] = result
+	}
+	return report
+}
+
+// GET /healthz: process 還活著 (不檢查 dependencies)
+func (checker *Checker) LivenessHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz: 所有 checks 都通過時 200, 否則 503
+func (checker *Checker) ReadinessHandler(ctx *gin.Context) {
+	report := checker.Check(ctx)
+	status := http.StatusOK
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
+
+// warmup 完成 (Done) 之前回傳 ErrWarmingUp 的 check
+type Warmup struct {
+	done atomic.Bool
+}
+
+func (warmup *Warmup) Done() {
+	warmup.done.Store(true)
+}
+
+func (warmup *Warmup) Check(ctx context.Context) error {
+	if !warmup.done.Load() {
+		return ErrWarmingUp
+	}
+	return nil
+}
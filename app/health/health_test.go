@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_Check(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	var warmup Warmup
+	checker.Add("database", func(ctx context.Context) error { return nil })
+	checker.Add("redis", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.Add("reference_data", warmup.Check)
+
+	report := checker.Check(context.Background())
+	if report.Status != "not ready" {
+		t.Errorf("expected not ready, got: %s", report.Status)
+	}
+	expected := map[string]string{"database": "", "redis": context.DeadlineExceeded.Error(), "reference_data": ErrWarmingUp.Error()}
+	for name, message := range expected {
+		result, ok := report.Checks[name]
+		if !ok {
+			t.Errorf("expected a %s check", name)
+			continue
+		}
+		if result.Error != message || (result.Status == "ok") != (message == "") {
+			t.Errorf("%s: expected error %q, got: %+v", name, message, result)
+		}
+	}
+
+	warmup.Done()
+	checker = NewChecker(time.Second)
+	checker.Add("database", func(ctx context.Context) error { return nil })
+	checker.Add("reference_data", warmup.Check)
+	if report := checker.Check(context.Background()); report.Status != "ready" {
+		t.Errorf("expected ready, got: %+v", report)
+	}
+
+	checker.Add("schema", func(ctx context.Context) error { return errors.New("database version 3 is behind 4") })
+	if report := checker.Check(context.Background()); report.Status != "not ready" || report.Checks["schema"].Error != "database version 3 is behind 4" {
+		t.Errorf("expected schema to fail, got: %+v", report)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lnfu/dcard-intern/app/config"
+)
+
+// app healthcheck: 查詢本機的 /readyz 並輸出結果, 不是 ready 時回傳錯誤
+// (docker compose 的 healthcheck, scratch image 中沒有 curl/wget)
+func runHealthcheck(args []string, out io.Writer) error {
+	conf, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	host, port, err := net.SplitHostPort(conf.Address)
+	if err != nil {
+		return err
+	}
+	// 監聽所有 interface 時連到 loopback
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	response, err := client.Get("http://" + net.JoinHostPort(host, port) + "/readyz")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if _, err := io.Copy(out, response.Body); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("not ready (%s)", response.Status)
+	}
+	return nil
+}
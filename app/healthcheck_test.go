@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunHealthcheck(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"..."}`))
+	}))
+	t.Cleanup(server.Close)
+	args := []string{"-mode", "test", "-metrics.address", "", "-addr", strings.TrimPrefix(server.URL, "http://")}
+
+	var out bytes.Buffer
+	err := runHealthcheck(args, &out)
+	if err == nil || err.Error() != "not ready (503 Service Unavailable)" {
+		t.Errorf("expected not ready, got: %v", err)
+	}
+	if out.String() != "{\"status\":\"...\"}\n" {
+		t.Errorf("expected the response body, got: %q", out.String())
+	}
+
+	status = http.StatusOK
+	if err := runHealthcheck(args, &out); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// 沒有在監聽的 address
+	server.Close()
+	if err := runHealthcheck(args, &out); err == nil {
+		t.Errorf("expected an error when the server is down")
+	}
+}
@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// request ID 的 header (沒有時產生一個, 並在 response 中回傳)
+const RequestIDHeader = "X-Request-ID"
+
+// client 帶來的 request ID 只接受這些字元 (避免寫入 log 的內容被竄改)
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// JSON 格式的 logger, 每一行都帶上 context 中的 request_id (與 trace_id/span_id)
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(requestContext(ctx)); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{handler.Handler.WithGroup(name)}
+}
+
+// *gin.Context 的 request context (沒有開啟 ContextWithFallback 時 gin.Context 不會查詢它)
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok {
+		if c.Request == nil {
+			return context.Background()
+		}
+		return c.Request.Context()
+	}
+	return ctx
+}
+
+// ctx 的 request ID ("" 表示不是 request 中), 也可以直接傳入 *gin.Context
+func RequestID(ctx context.Context) string {
+	id, _ := requestContext(ctx).Value(requestIDKey{}).(string)
+	return id
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 錯誤的 response body: {"error": message, "requestId": ...}
+func ErrorBody(ctx context.Context, message string) gin.H {
+	body := gin.H{"error": message}
+	if id := RequestID(ctx); id != "" {
+		body["requestId"] = id
+	}
+	return body
+}
+
+// 取得 (或產生) request ID 並記錄每個 request, 取代 gin 預設的 logger
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		ctx.Request = ctx.Request.WithContext(WithRequestID(ctx.Request.Context(), id))
+		ctx.Header(RequestIDHeader, id)
+		trace.SpanFromContext(ctx.Request.Context()).SetAttributes(attribute.String("http.request_id", id))
+
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "request",
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"route", ctx.FullPath(),
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes", ctx.Writer.Size(),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
+
+// panic 時回應 500 並記錄 stack, 取代 gin 預設的 recovery
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, err any) {
+		slog.ErrorContext(ctx, "panic recovered", "error", err, "stack", string(debug.Stack()))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ErrorBody(ctx, "internal server error"))
+	})
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 把 default logger 換成寫到 buffer 的 JSON logger
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf, slog.LevelDebug))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(), Recovery())
+	router.GET("/ad/:id", func(ctx *gin.Context) {
+		slog.InfoContext(ctx, "handler")
+		ctx.JSON(http.StatusNotFound, ErrorBody(ctx, "advertisement not found"))
+	})
+	router.GET("/panic", func(ctx *gin.Context) { panic("boom") })
+
+	testCases := []struct {
+		name       string
+		path       string
+		requestID  string
+		expectedID string // "" 表示產生新的
+		status     int
+	}{
+		{name: "client request id", path: "/ad/1", requestID: "req-123", expectedID: "req-123", status: http.StatusNotFound},
+		{name: "generated request id", path: "/ad/1", status: http.StatusNotFound},
+		{name: "invalid request id", path: "/ad/1", requestID: "bad id\n{}", status: http.StatusNotFound},
+		{name: "panic", path: "/panic", requestID: "req-456", expectedID: "req-456", status: http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.requestID != "" {
+				request.Header.Set(RequestIDHeader, tc.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			id := recorder.Header().Get(RequestIDHeader)
+			if tc.expectedID != "" && id != tc.expectedID {
+				t.Errorf("expected request id: %q, got: %q", tc.expectedID, id)
+			}
+			if !requestIDPattern.MatchString(id) {
+				t.Errorf("invalid request id: %q", id)
+			}
+			if recorder.Code != tc.status {
+				t.Errorf("expected status: %d, got: %d", tc.status, recorder.Code)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if body["requestId"] != id {
+				t.Errorf("expected requestId: %q, got: %q", id, body["requestId"])
+			}
+
+			// 每一行 log 都是 JSON 並帶有 request_id
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			if len(lines) < 2 {
+				t.Fatalf("expected at least 2 log lines, got: %q", logs.String())
+			}
+			for _, line := range lines {
+				var record map[string]any
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("unexpected error: %v (%s)", err, line)
+				}
+				if record["request_id"] != id {
+					t.Errorf("expected request_id: %q, got: %v", id, record["request_id"])
+				}
+			}
+		})
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	body := ErrorBody(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "database error")
+	if len(body) != 1 || body["error"] != "database error" {
+		t.Errorf("expected only the error outside a request, got: %v", body)
+	}
+}
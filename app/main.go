@@ -1,19 +1,40 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	"github.com/lnfu/dcard-intern/app/cache"
 	"github.com/lnfu/dcard-intern/app/config"
 	docs "github.com/lnfu/dcard-intern/app/docs"
+	"github.com/lnfu/dcard-intern/app/geoip"
 	"github.com/lnfu/dcard-intern/app/handlers"
-	models "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/health"
+	"github.com/lnfu/dcard-intern/app/logging"
+	"github.com/lnfu/dcard-intern/app/metrics"
+	"github.com/lnfu/dcard-intern/app/migrations"
+	"github.com/lnfu/dcard-intern/app/store"
+	"github.com/lnfu/dcard-intern/app/tracing"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	_ "modernc.org/sqlite"
 )
 
 // @title Dcard Backend Intern 2024
@@ -21,43 +42,392 @@ import (
 // @Description 請⽤ Golang 設計並且實作⼀個簡化的廣告投放服務，該服務應該有兩個 API，⼀個⽤於產⽣廣告，⼀個⽤於列出廣告。每個廣告都有它出現的條件(例如跟據使⽤者的年齡)，產⽣廣告的 API ⽤來產⽣與設定條件。投放廣告的 API 就要跟據條件列出符合使⽤條件的廣告
 // @Host localhost:8080
 func main() {
-	// Command-Line Flags
-	addr := flag.String("addr", ":8080", "HTTP network address")
-	mode := flag.String("mode", "dev", "Mode (dev/test/prod)")
-	flag.Parse()
+	// 讀取設定之前使用 info level
+	slog.SetDefault(logging.New(os.Stderr, slog.LevelInfo))
 
-	// Config
-	conf := config.Init(*mode)
+	// app migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
+	// app healthcheck (docker compose 的 healthcheck)
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := runHealthcheck(os.Args[2:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+			fatal("healthcheck failed", err)
+		}
+		return
+	}
+
+	// app [flags]: 啟動 server (收到 SIGINT/SIGTERM 後 graceful shutdown)
+	if err := runServer(os.Args[1:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+		fatal("server failed", err)
+	}
+}
+
+// 讀取設定並啟動 server, 直到收到 signal 或 listener 失敗. 回傳前會執行 defer (關閉 GeoIP, Redis 與資料庫)
+func runServer(args []string) error {
+	// Config (設定檔 < 環境變數 < command-line flags)
+	conf, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	slog.SetDefault(logging.New(os.Stderr, conf.Log.SlogLevel()))
+	if conf.Mode == config.ModeProd {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	// SIGINT/SIGTERM 之後開始 shutdown (第二次 signal 直接結束), 啟動中 (連線/載入 reference data) 也會中斷
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Tracing (exporter 為 none 時只傳遞 traceparent)
+	shutdownTracing, err := tracing.Setup(ctx, conf.Tracing)
+	if err != nil {
+		return fmt.Errorf("tracing setup failed: %w", err)
+	}
+
+	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		slog.Debug("route", "method", httpMethod, "path", absolutePath, "handler", handlerName)
+	}
+
+	// Health checks (/readyz 列出每個 dependency 的結果)
+	checker := health.NewChecker(2 * time.Second)
+	var warmup health.Warmup
+
+	// Database & Cache (test mode 使用記憶體, 不需要 MySQL/Redis)
+	var db handlers.AdStore
+	var cac handlers.AdCache
+	if conf.Mode == config.ModeTest {
+		db = store.NewMemory()
+		cac = cache.NewMemory(conf.Cache.TTL)
+	} else {
+		// Database (MySQL/PostgreSQL/SQLite)
+		dbConnection, err := sql.Open(conf.Database.Driver, conf.Database.DSN())
+		if err != nil {
+			return fmt.Errorf("database connection failed: %w", err)
+		}
+		defer dbConnection.Close()
+		dbConnection.SetMaxOpenConns(conf.Database.MaxOpenConns)
+		dbConnection.SetMaxIdleConns(conf.Database.MaxIdleConns)
+		dbConnection.SetConnMaxLifetime(conf.Database.ConnMaxLifetime)
+		dbConnection.SetConnMaxIdleTime(conf.Database.ConnMaxIdleTime)
+		metrics.Registry.MustRegister(collectors.NewDBStatsCollector(dbConnection, conf.Database.Name))
+		// schema 版本與 sqlc 產生的程式碼不一致時不啟動
+		if err := prepareDatabase(ctx, conf.Database, dbConnection); err != nil {
+			return fmt.Errorf("database not ready: %w", err)
+		}
+		switch conf.Database.Driver {
+		case config.DriverPostgres:
+			db = store.NewPostgres(dbConnection)
+		case config.DriverSQLite:
+			db = store.NewSQLite(dbConnection)
+		default:
+			db = store.NewMySQL(dbConnection)
+		}
+		checker.Add("database", dbConnection.PingContext)
+		checker.Add("schema", func(ctx context.Context) error {
+			return migrations.Check(ctx, dbConnection, conf.Database.Driver)
+		})
+
+		// Redis (sqlite 沒有設定 redis.addr 時使用記憶體)
+		if conf.Redis.Addr == "" {
+			cac = cache.NewMemory(conf.Cache.TTL)
+		} else {
+			redisCache, err := cache.NewCache(ctx, conf.Redis, conf.Cache.TTL)
+			if err != nil {
+				return fmt.Errorf("redis connection failed: %w", err)
+			}
+			defer redisCache.Close()
+			cac = redisCache
+			checker.Add("redis", redisCache.Ping)
+		}
+	}
+	// NewHandler 載入 reference data 之前不是 ready
+	checker.Add("reference_data", warmup.Check)
+
+	// GeoIP (沒有 country 的 GET /ad 以 client IP 推測)
+	var geoIP *geoip.Reader
+	if conf.GeoIP.Database != "" {
+		geoIP, err = geoip.Open(conf.GeoIP.Database)
+		if err != nil {
+			return fmt.Errorf("geoip database failed: %w", err)
+		}
+		defer geoIP.Close()
+	}
+
+	// warmup 期間只回應 /healthz 與 /readyz, 完成後換成完整的 router
+	var routes routerSwitch
+	routes.router.Store(newWarmupRouter(checker))
+
+	servers := []*http.Server{{
+		Addr:              conf.Address,
+		Handler:           &routes,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+	}}
+	slog.Info("listening and serving HTTP", "address", conf.Address, "mode", conf.Mode)
+
+	// Metrics (另一個 listener, 不對外開放)
+	if conf.Metrics.Address != "" {
+		metrics.Registry.MustRegister(metrics.NewAdvertisementsCollector(countAdvertisements(db), 5*time.Second))
+		servers = append(servers, newMetricsServer(conf.Metrics.Address))
+		slog.Info("serving metrics", "address", conf.Metrics.Address, "path", "/metrics")
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, conf.Server.ShutdownTimeout, servers...)
+	}()
 
-	// MySQL Database
-	dbConnection, err := sql.Open(conf.Database.Driver, conf.Database.Source)
+	// Handlers (warmup: 載入 reference data)
+	handler, err := handlers.NewHandler(ctx, db, cac, conf.API)
 	if err != nil {
-		log.Fatalf("MySQL: 無法連接 (%v)\n", err)
+		if ctx.Err() != nil {
+			// warmup 期間收到 signal: 等待 servers 停止
+			return <-served
+		}
+		return fmt.Errorf("load reference data: %w", err)
 	}
-	defer dbConnection.Close()
+	// 超過時回應 504, 不讓變慢的 dependency 累積 requests
+	handler.SetTimeouts(handlers.Timeouts{Database: conf.Database.QueryTimeout, Cache: conf.Redis.CommandTimeout})
+	if geoIP != nil {
+		handler.SetGeoIP(geoIP)
+	}
+	routes.router.Store(setupRouter(handler, checker))
+	warmup.Done()
+	slog.Info("ready")
+
+	// Background jobs (shutdown 時等待執行中的一次完成)
+	var jobs sync.WaitGroup
+	jobs.Add(2)
+	// Scheduled advertisements
+	go func() {
+		defer jobs.Done()
+		updateAdvertisementStatuses(ctx, handler, time.Minute)
+	}()
+	// Reference data (其他 replica 透過 admin API 的變更)
+	go func() {
+		defer jobs.Done()
+		reloadReferenceData(ctx, handler, conf.API.ReferenceRefreshInterval)
+	}()
 
-	// Redis
-	cac := cache.NewCache(conf.Redis.Addr, conf.Redis.Password, conf.Redis.DB)
+	err = <-served
+	signaled := ctx.Err() != nil
+	stop()
+	if !signaled {
+		// 沒有收到 signal 就結束: listener 失敗 (例如 port 已被使用), 回傳之前先等待 background jobs
+		jobs.Wait()
+		return err
+	}
+	if err != nil {
+		slog.Error("requests not drained before shutdown timeout", "error", err)
+	}
+
+	// 等待 background jobs, 再送出還沒 export 的 spans, 最後 (defer) 關閉 GeoIP, Redis 與資料庫
+	jobs.Wait()
+	flushCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("tracing flush failed", "error", err)
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
+
+// 啟動 servers 直到 ctx 結束 (SIGTERM), 之後停止接受新的連線並等待進行中的 requests 完成,
+// 超過 timeout 的連線直接中斷. 任何一個 listener 失敗時停止所有 servers 並回傳錯誤
+func serve(ctx context.Context, timeout time.Duration, servers ...*http.Server) error {
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		slog.Info("shutting down", "timeout", timeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			server.Close()
+			err = errors.Join(err, shutdownErr)
+		}
+	}
+	return err
+}
 
+// 記錄錯誤並結束程式
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+// 每隔 interval 執行一次 job, ctx 結束時停止 (執行中的 job 不會被中斷)
+func every(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// 定期把 startAt 已經到了的 scheduled 廣告轉成 active, endAt 已經過了的轉成 archived
+func updateAdvertisementStatuses(ctx context.Context, handler *handlers.Handler, interval time.Duration) {
+	every(ctx, interval, func(ctx context.Context) {
+		activated, err := handler.ActivateScheduledAdvertisements(ctx)
+		if err != nil {
+			slog.Error("database error", "error", err)
+			return
+		}
+		if activated > 0 {
+			slog.Info("activated scheduled advertisements", "count", activated)
+		}
+
+		archived, err := handler.ArchiveExpiredAdvertisements(ctx)
+		if err != nil {
+			slog.Error("database error", "error", err)
+			return
+		}
+		if archived > 0 {
+			slog.Info("archived expired advertisements", "count", archived)
+		}
+	})
+}
+
+// 定期重新讀取 gender/country/platform/locale
+func reloadReferenceData(ctx context.Context, handler *handlers.Handler, interval time.Duration) {
+	every(ctx, interval, func(ctx context.Context) {
+		if err := handler.ReloadReferenceData(ctx); err != nil {
+			slog.Error("database error", "error", err)
+		}
+	})
+}
+
+// 各 status 的廣告數量 (advertisements gauge)
+func countAdvertisements(db handlers.AdStore) metrics.AdvertisementCounter {
+	return func(ctx context.Context) (map[string]int64, error) {
+		rows, err := db.CountAdvertisementsByStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			counts[row.Status] = row.Count
+		}
+		return counts, nil
+	}
+}
+
+func newMetricsServer(address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// gin 會把 "ads:bulk" 的 ":bulk" 當成 path parameter, 所以同一個 resource 的 custom methods 共用一個 route 再依名稱分派
+func customMethods(methods map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		method, ok := methods[strings.TrimPrefix(ctx.Param("method"), ":")]
+		if !ok {
+			ctx.JSON(http.StatusNotFound, logging.ErrorBody(ctx, "unknown method"))
+			return
+		}
+		method(ctx)
+	}
+}
+
+func newRouter() *gin.Engine {
+	router := gin.New()
+	// handler 把 *gin.Context 傳給 store/cache, 需要從它取得 request context 中的 span
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware(tracing.ServiceName), logging.Middleware(), metrics.Middleware(), logging.Recovery())
+	router.ForwardedByClientIP = true
+	router.SetTrustedProxies([]string{"127.0.0.1"})
+	return router
+}
+
+// 啟動中 (NewHandler 還在載入 reference data) 的 router, health 以外的 requests 回應 503
+func newWarmupRouter(checker *health.Checker) *gin.Engine {
+	router := newRouter()
+	registerHealthRoutes(router, checker)
+	router.NoRoute(func(ctx *gin.Context) {
+		ctx.JSON(http.StatusServiceUnavailable, logging.ErrorBody(ctx, "warming up"))
+	})
+	return router
+}
+
+// liveness 與 readiness (docker compose 的 healthcheck 與 orchestrator 的 probes)
+func registerHealthRoutes(router *gin.Engine, checker *health.Checker) {
+	router.GET("healthz", checker.LivenessHandler)
+	router.GET("readyz", checker.ReadinessHandler)
+}
+
+// warmup 完成時換成完整的 router (serve 中可以安全地替換)
+type routerSwitch struct {
+	router atomic.Pointer[gin.Engine]
+}
+
+func (routes *routerSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	routes.router.Load().ServeHTTP(w, r)
+}
+
+// 建立 router 並註冊所有 routes
+func setupRouter(handler *handlers.Handler, checker *health.Checker) *gin.Engine {
 	// Gin Engine (router)
 	router := newRouter()
+	registerHealthRoutes(router, checker)
 
-	// Handlers
-	handler := handlers.NewHandler(models.New(dbConnection), cac)
 	apiV1 := router.Group("api/v1/")
 	apiV1.POST("ad", handler.CreateAdvertisementHandler)
 	apiV1.GET("ad", handler.GetAdvertisementHandler)
+	apiV1.PATCH("ad/:id/status", handler.UpdateAdvertisementStatusHandler)
+	apiV1.GET("ads", handler.ListAdvertisementsHandler)
+	apiV1.POST("ads:method", customMethods(map[string]gin.HandlerFunc{
+		"bulk": handler.BulkCreateAdvertisementsHandler,
+	}))
+	apiV1.GET("ads:method", customMethods(map[string]gin.HandlerFunc{
+		"export": handler.ExportAdvertisementsHandler,
+	}))
+
+	// Reference data (genders/countries/platforms)
+	admin := apiV1.Group("admin/")
+	admin.GET("country-groups", handler.ListCountryGroupsHandler)
+	admin.PUT("country-groups/:code", handler.PutCountryGroupHandler)
+	admin.DELETE("country-groups/:code", handler.DeleteCountryGroupHandler)
+	admin.GET("segments", handler.ListSegmentsHandler)
+	admin.PUT("segments/:code", handler.PutSegmentHandler)
+	admin.DELETE("segments/:code", handler.DeleteSegmentHandler)
+	admin.GET(":kind", handler.ListReferenceValuesHandler)
+	admin.PUT(":kind/:code", handler.PutReferenceValueHandler)
+	admin.DELETE(":kind/:code", handler.RetireReferenceValueHandler)
 
 	// Swagger handler
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	apiV1.GET("swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	router.Run(*addr)
-}
-
-func newRouter() *gin.Engine {
-	router := gin.Default()
-	router.ForwardedByClientIP = true
-	router.SetTrustedProxies([]string{"127.0.0.1"})
 	return router
 }
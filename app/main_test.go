@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lnfu/dcard-intern/app/cache"
+	"github.com/lnfu/dcard-intern/app/config"
+	"github.com/lnfu/dcard-intern/app/handlers"
+	"github.com/lnfu/dcard-intern/app/health"
+	"github.com/lnfu/dcard-intern/app/migrations"
+	"github.com/lnfu/dcard-intern/app/store"
+	"github.com/lnfu/dcard-intern/app/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testResponse struct {
+	code   int
+	header http.Header
+	body   string
+}
+
+func request(t *testing.T, router http.Handler, method string, path string, body string, header ...string) testResponse {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return testResponse{recorder.Code, recorder.Header(), recorder.Body.String()}
+}
+
+func titles(t *testing.T, body string) []string {
+	t.Helper()
+	var response struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("invalid response: %s", body)
+	}
+	titles := make([]string, len(response.Items))
+	for i, item := range response.Items {
+		titles[i] = item.Title
+	}
+	return titles
+}
+
+// -mode test: 整個 HTTP API 使用記憶體中的 store/cache
+func TestAPI_testMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, err := handlers.NewHandler(context.Background(), store.NewMemory(), cache.NewMemory(time.Minute), config.Default().API)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router := setupRouter(handler, health.NewChecker(time.Second))
+
+	startAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	endAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	// create
+	response := request(t, router, http.MethodPost, "/api/v1/ad", `{
+		"title": "AD 1", "startAt": "`+startAt+`", "endAt": "`+endAt+`",
+		"localizations": {"zh": {"title": "广告 1"}},
+		"conditions": [{"ageStart": 20, "ageEnd": 30, "country": ["TW", "JP"]}]
+	}`)
+	if response.code != http.StatusOK {
+		t.Fatalf("POST /ad: expected status %d, got: %d (%s)", http.StatusOK, response.code, response.body)
+	}
+	response = request(t, router, http.MethodPost, "/api/v1/ad", `{"title": "AD 2", "startAt": "`+startAt+`", "endAt": "`+endAt+`", "conditions": [{"gender": ["X"]}]}`)
+	if response.code != http.StatusBadRequest {
+		t.Fatalf("POST /ad: expected status %d, got: %d (%s)", http.StatusBadRequest, response.code, response.body)
+	}
+
+	// bulk
+	var jsonl bytes.Buffer
+	jsonl.WriteString(`{"title": "AD 2", "startAt": "` + startAt + `", "endAt": "` + endAt + `", "conditions": [{"platform": ["ios"]}]}` + "\n")
+	jsonl.WriteString(`{"title": "", "startAt": "` + startAt + `", "endAt": "` + endAt + `", "conditions": []}` + "\n")
+	response = request(t, router, http.MethodPost, "/api/v1/ads:bulk", jsonl.String(), "Content-Type", "application/x-ndjson")
+	if response.code != http.StatusOK || !strings.Contains(response.body, `"created":1`) || !strings.Contains(response.body, `"invalid":1`) {
+		t.Fatalf("POST /ads:bulk: unexpected response: %d %s", response.code, response.body)
+	}
+
+	// get (conditions)
+	response = request(t, router, http.MethodGet, "/api/v1/ad?age=25&country=TW&platform=android", "")
+	if got := titles(t, response.body); response.code != http.StatusOK || len(got) != 1 || got[0] != "AD 1" {
+		t.Fatalf("GET /ad: unexpected response: %d %s", response.code, response.body)
+	}
+
+	// get (localization)
+	response = request(t, router, http.MethodGet, "/api/v1/ad?age=25&country=TW&platform=android", "", "Accept-Language", "zh-TW, en;q=0.5")
+	if got := titles(t, response.body); len(got) != 1 || got[0] != "广告 1" || response.header.Get("Content-Language") != "zh-TW" {
+		t.Fatalf("GET /ad: unexpected localized response: %s (Content-Language: %s)", response.body, response.header.Get("Content-Language"))
+	}
+
+	// status (快取的結果也要一起失效)
+	response = request(t, router, http.MethodPatch, "/api/v1/ad/1/status", `{"status": "paused"}`)
+	if response.code != http.StatusOK {
+		t.Fatalf("PATCH /ad/1/status: expected status %d, got: %d (%s)", http.StatusOK, response.code, response.body)
+	}
+	response = request(t, router, http.MethodGet, "/api/v1/ad?age=25&country=TW&platform=android", "")
+	if got := titles(t, response.body); len(got) != 0 {
+		t.Fatalf("GET /ad: expected no advertisements, got: %v", got)
+	}
+	response = request(t, router, http.MethodPatch, "/api/v1/ad/1/status", `{"status": "scheduled"}`)
+	if response.code != http.StatusConflict {
+		t.Fatalf("PATCH /ad/1/status: expected status %d, got: %d (%s)", http.StatusConflict, response.code, response.body)
+	}
+
+	// list
+	response = request(t, router, http.MethodGet, "/api/v1/ads?status=paused", "")
+	if got := titles(t, response.body); response.code != http.StatusOK || len(got) != 1 || got[0] != "AD 1" {
+		t.Fatalf("GET /ads: unexpected response: %d %s", response.code, response.body)
+	}
+
+	// export
+	response = request(t, router, http.MethodGet, "/api/v1/ads:export", "")
+	lines := strings.Split(strings.TrimSpace(response.body), "\n")
+	if response.code != http.StatusOK || len(lines) != 2 || !strings.Contains(lines[0], `"localizations":{"zh":{"title":"广告 1"}}`) {
+		t.Fatalf("GET /ads:export: unexpected response: %d %s", response.code, response.body)
+	}
+}
+
+// traceparent 的 trace 延續到 HTTP server span, 以及 handler 中的 query 與 response encoding
+func TestAPI_tracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db?_pragma=foreign_keys(1)&_time_format=sqlite&_txlock=immediate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(context.Background(), db, config.DriverSQLite); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler, err := handlers.NewHandler(context.Background(), store.NewSQLite(db), cache.NewMemory(time.Minute), config.Default().API)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 只記錄 request 中的 spans (不包含 NewHandler 的 queries)
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	if _, err := tracing.Setup(context.Background(), config.Tracing{Exporter: config.TracingNone}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	router := setupRouter(handler, health.NewChecker(time.Second))
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	response := request(t, router, http.MethodGet, "/api/v1/ad?country=TW", "", "traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if response.code != http.StatusOK {
+		t.Fatalf("GET /ad: expected status %d, got: %d (%s)", http.StatusOK, response.code, response.body)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("span %s: expected trace id: %s, got: %s", span.Name(), traceID, span.SpanContext().TraceID())
+		}
+		spans[span.Name()] = span
+	}
+	server, ok := spans["/api/v1/ad"]
+	if !ok {
+		t.Fatalf("expected a server span, got: %v", spans)
+	}
+	if parent := server.Parent().SpanID().String(); parent != "00f067aa0ba902b7" {
+		t.Errorf("expected the server span to continue the client span, got parent: %s", parent)
+	}
+	for _, name := range []string{"GetActiveAdvertisements", "encode response"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("expected a %s span", name)
+			continue
+		}
+		if span.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the server span", name)
+		}
+	}
+}
+
+// warmup 期間 /readyz 回應 503, NewHandler 完成後換成完整的 router, 資料庫無法使用時又回到 503
+func TestAPI_health(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db?_pragma=foreign_keys(1)&_time_format=sqlite&_txlock=immediate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(context.Background(), db, config.DriverSQLite); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checker := health.NewChecker(time.Second)
+	var warmup health.Warmup
+	checker.Add("database", db.PingContext)
+	checker.Add("schema", func(ctx context.Context) error {
+		return migrations.Check(ctx, db, config.DriverSQLite)
+	})
+	checker.Add("reference_data", warmup.Check)
+	var routes routerSwitch
+	readiness := func(expectedCode int) health.Report {
+		t.Helper()
+		response := request(t, &routes, http.MethodGet, "/readyz", "")
+		if response.code != expectedCode {
+			t.Fatalf("GET /readyz: expected status %d, got: %d (%s)", expectedCode, response.code, response.body)
+		}
+		var report health.Report
+		if err := json.Unmarshal([]byte(response.body), &report); err != nil {
+			t.Fatalf("invalid response: %s", response.body)
+		}
+		return report
+	}
+
+	routes.router.Store(newWarmupRouter(checker))
+	if response := request(t, &routes, http.MethodGet, "/healthz", ""); response.code != http.StatusOK {
+		t.Errorf("GET /healthz: expected status %d, got: %d", http.StatusOK, response.code)
+	}
+	report := readiness(http.StatusServiceUnavailable)
+	if report.Checks["reference_data"].Error != health.ErrWarmingUp.Error() || report.Checks["database"].Status != "ok" || report.Checks["schema"].Status != "ok" {
+		t.Errorf("expected only reference_data to fail, got: %+v", report)
+	}
+	if response := request(t, &routes, http.MethodGet, "/api/v1/ad", ""); response.code != http.StatusServiceUnavailable {
+		t.Errorf("GET /ad during warmup: expected status %d, got: %d", http.StatusServiceUnavailable, response.code)
+	}
+
+	handler, err := handlers.NewHandler(context.Background(), store.NewSQLite(db), cache.NewMemory(time.Minute), config.Default().API)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	routes.router.Store(setupRouter(handler, checker))
+	warmup.Done()
+	if report := readiness(http.StatusOK); report.Status != "ready" || len(report.Checks) != 3 {
+		t.Errorf("expected ready with 3 checks, got: %+v", report)
+	}
+	if response := request(t, &routes, http.MethodGet, "/api/v1/ad", ""); response.code != http.StatusOK {
+		t.Errorf("GET /ad: expected status %d, got: %d", http.StatusOK, response.code)
+	}
+
+	db.Close()
+	if report := readiness(http.StatusServiceUnavailable); report.Checks["database"].Status != "error" {
+		t.Errorf("expected database to fail, got: %+v", report)
+	}
+	if response := request(t, &routes, http.MethodGet, "/healthz", ""); response.code != http.StatusOK {
+		t.Errorf("GET /healthz: expected status %d, got: %d", http.StatusOK, response.code)
+	}
+}
+
+// 可以使用的 localhost address
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// shutdown 時等待進行中的 request 完成, 超過 timeout 的 request 直接中斷
+func TestServe(t *testing.T) {
+	testCases := []struct {
+		name     string
+		delay    time.Duration
+		timeout  time.Duration
+		hasError bool
+	}{
+		{name: "drain", delay: 200 * time.Millisecond, timeout: 5 * time.Second},
+		{name: "timeout", delay: 5 * time.Second, timeout: 100 * time.Millisecond, hasError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := make(chan struct{})
+			address := freeAddress(t)
+			server := &http.Server{Addr: address, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tc.delay):
+				case <-r.Context().Done():
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			served := make(chan error, 1)
+			go func() { served <- serve(ctx, tc.timeout, server) }()
+
+			responses := make(chan *http.Response, 1)
+			go func() {
+				for {
+					response, err := http.Get("http://" + address)
+					if err == nil {
+						responses <- response
+						return
+					}
+					if errors.Is(err, syscall.ECONNREFUSED) {
+						// server 還沒開始 listen
+						time.Sleep(10 * time.Millisecond)
+						continue
+					}
+					responses <- nil
+					return
+				}
+			}()
+
+			<-started
+			cancel()
+			err := <-served
+			if tc.hasError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.hasError, err)
+			}
+			response := <-responses
+			if !tc.hasError && (response == nil || response.StatusCode != http.StatusNoContent) {
+				t.Errorf("expected the in-flight request to complete, got: %v", response)
+			}
+			if _, err := http.Get("http://" + address); err == nil {
+				t.Errorf("expected new connections to be refused after shutdown")
+			}
+		})
+	}
+}
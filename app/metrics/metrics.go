@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 所有 metrics 都註冊在這裡 (不使用 prometheus 的 global registry), 由 Handler() 輸出
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Advertisement cache operations by result (get: hit/miss/error, set and invalidate: ok/error).",
+	}, []string{"operation", "result"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by sqlc query name.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		cacheRequests,
+		dbQueryDuration,
+	)
+}
+
+// GET /metrics (Prometheus text format)
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// 記錄每個 request 的數量與時間, route 是註冊的 path (例如 /api/v1/ad/:id/status), 沒有符合的 route 為 "unmatched"
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		httpRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// cache 操作的結果
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+	CacheOK    = "ok"
+)
+
+func ObserveCache(operation string, result string) {
+	cacheRequests.WithLabelValues(operation, result).Inc()
+}
+
+func ObserveQuery(query string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	dbQueryDuration.WithLabelValues(query, result).Observe(duration.Seconds())
+}
+
+// 取得各 status 的廣告數量, scrape 時才查詢
+type AdvertisementCounter func(ctx context.Context) (map[string]int64, error)
+
+// advertisements{status} gauge (每次 scrape 查詢一次資料庫)
+type advertisementsCollector struct {
+	count   AdvertisementCounter
+	timeout time.Duration
+	desc    *prometheus.Desc
+}
+
+func NewAdvertisementsCollector(count AdvertisementCounter, timeout time.Duration) prometheus.Collector {
+	return &advertisementsCollector{
+		count:   count,
+		timeout: timeout,
+		desc:    prometheus.NewDesc("advertisements", "Advertisements by status.", []string{"status"}, nil),
+	}
+}
+
+func (collector *advertisementsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+func (collector *advertisementsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collector.timeout)
+	defer cancel()
+
+	counts, err := collector.count(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "database error", "error", err)
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(count), status)
+	}
+}
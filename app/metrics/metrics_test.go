@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/ad/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+	testCases := []struct {
+		name   string
+		path   string
+		route  string
+		status string
+	}{
+		{name: "matched route", path: "/ad/1", route: "/ad/:id", status: "204"},
+		{name: "unmatched route", path: "/nope", route: "unmatched", status: "404"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			counter := httpRequests.WithLabelValues(http.MethodGet, tc.route, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("expected: 1 request, got: %v", got)
+			}
+		})
+	}
+}
+
+func TestObserveCache(t *testing.T) {
+	counter := cacheRequests.WithLabelValues("get", CacheHit)
+	before := testutil.ToFloat64(counter)
+	ObserveCache("get", CacheHit)
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("expected: 1, got: %v", got)
+	}
+}
+
+func TestAdvertisementsCollector(t *testing.T) {
+	collector := NewAdvertisementsCollector(func(ctx context.Context) (map[string]int64, error) {
+		return map[string]int64{"active": 3, "paused": 1}, nil
+	}, time.Second)
+
+	expected := `
+# HELP advertisements Advertisements by status.
+# TYPE advertisements gauge
+advertisements{status="active"} 3
+advertisements{status="paused"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	failing := NewAdvertisementsCollector(func(ctx context.Context) (map[string]int64, error) {
+		return nil, errors.New("connection refused")
+	}, time.Second)
+	if _, err := testutil.CollectAndLint(failing); err == nil {
+		t.Errorf("expected error, but got nil")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/lnfu/dcard-intern/app/config"
+	"github.com/lnfu/dcard-intern/app/migrations"
+)
+
+const migrateUsage = `usage: app migrate up|down [N]|status [flags]
+
+  up      apply all pending migrations
+  down    revert the last N migrations (default 1)
+  status  print the database version and the pending migrations
+
+flags are the same as the server (e.g. -database.driver postgres)`
+
+// app migrate up|down|status, 使用與 server 相同的設定
+func runMigrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command, args := args[0], args[1:]
+
+	steps := 1
+	if command == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n < 1 {
+				return errors.New("invalid N value (must be >= 1)")
+			}
+			steps, args = n, args[1:]
+		}
+	}
+	if command != "up" && command != "down" && command != "status" {
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+
+	conf, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	if conf.Mode == config.ModeTest {
+		return errors.New("test mode has no database to migrate")
+	}
+
+	db, err := sql.Open(conf.Database.Driver, conf.Database.MigrateDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrations.Up(ctx, db, conf.Database.Driver)
+		fmt.Fprintf(out, "Applied %d migrations\n", applied)
+		if err != nil {
+			return err
+		}
+	case "down":
+		reverted, err := migrations.Down(ctx, db, conf.Database.Driver, steps)
+		fmt.Fprintf(out, "Reverted %d migrations\n", reverted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return printMigrateStatus(ctx, db, conf.Database.Driver, out)
+}
+
+func printMigrateStatus(ctx context.Context, db *sql.DB, dialect string, out io.Writer) error {
+	status, err := migrations.GetStatus(ctx, db, dialect)
+	if err != nil {
+		return err
+	}
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Fprintf(out, "Version: %d%s\nLatest: %d\n", status.Version, dirty, status.Latest)
+	for _, migration := range status.Pending() {
+		fmt.Fprintf(out, "Pending: %d_%s\n", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+// 啟動時檢查 schema 的版本, auto_migrate (或 sqlite) 時先套用還沒套用的 migrations
+func prepareDatabase(ctx context.Context, database config.Database, db *sql.DB) error {
+	if database.AutoMigrate || database.Driver == config.DriverSQLite {
+		migrateDB, err := sql.Open(database.Driver, database.MigrateDSN())
+		if err != nil {
+			return err
+		}
+		defer migrateDB.Close()
+
+		applied, err := migrations.Up(ctx, migrateDB, database.Driver)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			slog.InfoContext(ctx, "applied migrations", "count", applied)
+		}
+	}
+	return migrations.Check(ctx, db, database.Driver)
+}
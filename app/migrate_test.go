@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lnfu/dcard-intern/app/migrations"
+)
+
+func TestRunMigrate(t *testing.T) {
+	all, err := migrations.Load("sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flags := []string{"-database.driver", "sqlite", "-database.name", t.TempDir() + "/test.db", "-redis.addr", ""}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string // 輸出的第一行
+		err      string
+	}{
+		{"status (empty database)", []string{"status"}, "Version: 0", ""},
+		{"up", []string{"up"}, fmt.Sprintf("Applied %d migrations", len(all)), ""},
+		{"up (nothing pending)", []string{"up"}, "Applied 0 migrations", ""},
+		{"down", []string{"down"}, "Reverted 1 migrations", ""},
+		{"down N", []string{"down", "2"}, "Reverted 2 migrations", ""},
+		{"invalid N", []string{"down", "0"}, "", "invalid N value (must be >= 1)"},
+		{"unknown command", []string{"drop"}, "", `unknown migrate command "drop"`},
+		{"no command", nil, "", "usage: app migrate"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+			args := testCase.args
+			if len(args) > 0 {
+				args = append(args, flags...)
+			}
+			err := runMigrate(args, &out)
+			if testCase.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), testCase.err) {
+					t.Errorf("expected error: %s, got: %v", testCase.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if line, _, _ := strings.Cut(out.String(), "\n"); line != testCase.expected {
+				t.Errorf("expected: %s, got: %s", testCase.expected, line)
+			}
+		})
+	}
+}
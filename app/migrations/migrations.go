@@ -0,0 +1,327 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 與 golang-migrate 相同的檔名 ({version}_{name}.up.sql/.down.sql) 與 schema_migrations table,
+// 之前用 migrate CLI 套用過的資料庫可以直接沿用, 也可以繼續用 migrate CLI 操作
+//
+// 目錄名稱 (dialect) 與 config 的 database.driver 相同
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var ErrDirty = errors.New("dirty database version")
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// 讀取 dialect (目錄名稱) 的所有 migration, 依 version 排序
+func Load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("unknown migration dialect %q", dialect)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, title, ok := strings.Cut(strings.TrimSuffix(name, "."+direction+".sql"), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		content, err := fs.ReadFile(files, path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: title}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// 資料庫目前的 version 與這個 binary 內的 migrations
+type Status struct {
+	Version    uint64 // 0: 還沒有套用任何 migration
+	Dirty      bool
+	Latest     uint64
+	Migrations []Migration
+}
+
+// 還沒套用的 migrations
+func (status Status) Pending() []Migration {
+	var pending []Migration
+	for _, migration := range status.Migrations {
+		if migration.Version > status.Version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// 只讀取 schema_migrations (沒有這個 table 時為 version 0), 不需要 DDL 權限
+func GetStatus(ctx context.Context, db *sql.DB, dialect string) (Status, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return Status{}, err
+	}
+	exists, err := tableExists(ctx, db, dialect)
+	if err != nil {
+		return Status{}, err
+	}
+	var version uint64
+	var dirty bool
+	if exists {
+		version, dirty, err = currentVersion(ctx, db)
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	status := Status{Version: version, Dirty: dirty, Migrations: migrations}
+	if len(migrations) > 0 {
+		status.Latest = migrations[len(migrations)-1].Version
+	}
+	return status, nil
+}
+
+// 啟動時檢查 schema 是不是 sqlc 產生的程式碼需要的版本 (最新的 migration)
+func Check(ctx context.Context, db *sql.DB, dialect string) error {
+	status, err := GetStatus(ctx, db, dialect)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w %d (fix the schema and the schema_migrations table by hand)", ErrDirty, status.Version)
+	}
+	if pending := status.Pending(); len(pending) > 0 {
+		return fmt.Errorf("database version %d is behind %d (%d pending migrations, run: app migrate up)", status.Version, status.Latest, len(pending))
+	}
+	return nil
+}
+
+// 執行所有還沒套用的 up migration, 回傳套用的數量
+func Up(ctx context.Context, db *sql.DB, dialect string) (int, error) {
+	unlock, err := lock(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := createTable(ctx, db); err != nil {
+		return 0, err
+	}
+	status, err := GetStatus(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+	if status.Dirty {
+		return 0, fmt.Errorf("%w %d (fix the schema and the schema_migrations table by hand)", ErrDirty, status.Version)
+	}
+
+	applied := 0
+	for _, migration := range status.Pending() {
+		if err := apply(ctx, db, migration.Version, migration.Version, migration.Up); err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// 依序執行最後 steps 個已經套用的 down migration, 回傳執行的數量
+func Down(ctx context.Context, db *sql.DB, dialect string, steps int) (int, error) {
+	unlock, err := lock(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := createTable(ctx, db); err != nil {
+		return 0, err
+	}
+	status, err := GetStatus(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+	if status.Dirty {
+		return 0, fmt.Errorf("%w %d (fix the schema and the schema_migrations table by hand)", ErrDirty, status.Version)
+	}
+
+	// 已經套用的 migrations (由新到舊)
+	var done []Migration
+	for i := len(status.Migrations) - 1; i >= 0; i-- {
+		if status.Migrations[i].Version <= status.Version {
+			done = append(done, status.Migrations[i])
+		}
+	}
+	if len(done) > 0 && done[0].Version != status.Version {
+		return 0, fmt.Errorf("database version %d not found in migrations", status.Version)
+	}
+
+	reverted := 0
+	for i := 0; i < steps && i < len(done); i++ {
+		// 回到前一個 migration 的 version, 全部還原時為 0 (刪除 version)
+		var previous uint64
+		if i+1 < len(done) {
+			previous = done[i+1].Version
+		}
+		if err := apply(ctx, db, done[i].Version, previous, done[i].Down); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", done[i].Version, done[i].Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// 等待其他 process 的 Up/Down 的時間
+const lockTimeout = time.Minute
+
+// 與 golang-migrate 相同, 用 advisory lock 避免多個 replica (auto_migrate) 同時套用同一個 migration.
+// lock 屬於 session, 所以使用一個專用的連線直到 unlock; sqlite 只有一個 process 使用, 不需要 lock
+func lock(ctx context.Context, db *sql.DB, dialect string) (func(), error) {
+	var acquire, release string
+	switch dialect {
+	case "mysql":
+		acquire = fmt.Sprintf("SELECT GET_LOCK(CONCAT(DATABASE(), '.schema_migrations'), %d)", int(lockTimeout.Seconds()))
+		release = "SELECT RELEASE_LOCK(CONCAT(DATABASE(), '.schema_migrations'))"
+	case "postgres":
+		acquire = "SELECT pg_advisory_lock(hashtext(current_database() || '.schema_migrations'))"
+		release = "SELECT pg_advisory_unlock(hashtext(current_database() || '.schema_migrations'))"
+	default:
+		return func() {}, nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// pg_advisory_lock 沒有 timeout, 由 context 限制
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	// mysql 的 GET_LOCK: 1 表示取得, 0 表示 timeout, NULL 表示錯誤; postgres 的 pg_advisory_lock 回傳 void
+	var acquired sql.NullBool
+	if dialect == "mysql" {
+		err = conn.QueryRowContext(lockCtx, acquire).Scan(&acquired)
+	} else {
+		_, err = conn.ExecContext(lockCtx, acquire)
+		acquired.Bool = true
+	}
+	if err == nil && !acquired.Bool {
+		err = errors.New("timeout (another migration is running)")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+
+	return func() {
+		// context 已經結束時也要釋放 lock
+		conn.ExecContext(context.WithoutCancel(ctx), release)
+		conn.Close()
+	}, nil
+}
+
+// schema_migrations 是否存在 (GetStatus 不建立它)
+func tableExists(ctx context.Context, db *sql.DB, dialect string) (bool, error) {
+	var query string
+	switch dialect {
+	case "mysql":
+		query = "SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'"
+	case "postgres":
+		query = "SELECT to_regclass('schema_migrations') IS NOT NULL"
+	default:
+		query = "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'"
+	}
+	var exists bool
+	err := db.QueryRowContext(ctx, query).Scan(&exists)
+	return exists, err
+}
+
+func createTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)")
+	return err
+}
+
+// 目前的 version (還沒有任何 migration 時為 0)
+func currentVersion(ctx context.Context, db *sql.DB) (uint64, bool, error) {
+	var version uint64
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// 先把 version 標記成 dirty, 成功後才改成 target (DDL 不一定能 rollback)
+func apply(ctx context.Context, db *sql.DB, version uint64, target uint64, statements string) error {
+	if err := setVersion(ctx, db, version, true); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	return setVersion(ctx, db, target, false)
+}
+
+func setVersion(ctx context.Context, db *sql.DB, version uint64, dirty bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	// 與 golang-migrate 相同, 沒有任何 migration 時不留 version
+	if version == 0 {
+		return tx.Commit()
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO schema_migrations (version, dirty) VALUES (%d, %t)", version, dirty)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,198 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load("sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d_%s: missing up or down", migration.Version, migration.Name)
+		}
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d_%s: not sorted", migration.Version, migration.Name)
+		}
+	}
+
+	// 每個 dialect 的 migrations 要一致 (同樣的 version)
+	for _, dialect := range []string{"mysql", "postgres"} {
+		other, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(other) != len(migrations) {
+			t.Fatalf("%s: expected %d migrations, got %d", dialect, len(migrations), len(other))
+		}
+		for i := range other {
+			if other[i].Version != migrations[i].Version {
+				t.Errorf("%s: expected version %d, got %d", dialect, migrations[i].Version, other[i].Version)
+			}
+		}
+	}
+
+	if _, err := Load("oracle"); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	migrations, _ := Load("sqlite")
+	applied, err := Up(ctx, db, "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("expected: %d, got: %d", len(migrations), applied)
+	}
+
+	// 已經是最新的 version, 不會重複套用
+	applied, err = Up(ctx, db, "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("expected: 0, got: %d", applied)
+	}
+
+	version, dirty, err := currentVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := migrations[len(migrations)-1].Version; version != last || dirty {
+		t.Errorf("expected: %d (clean), got: %d (dirty: %t)", last, version, dirty)
+	}
+
+	// seed data
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM country").Scan(&count); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count == 0 {
+		t.Error("expected seeded countries")
+	}
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	migrations, _ := Load("sqlite")
+	if _, err := Up(ctx, db, "sqlite"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 還原最後一個 migration 後回到前一個 version, schema 落後
+	reverted, err := Down(ctx, db, "sqlite", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverted != 1 {
+		t.Errorf("expected: 1, got: %d", reverted)
+	}
+	status, err := GetStatus(ctx, db, "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := migrations[len(migrations)-2].Version; status.Version != expected {
+		t.Errorf("expected: %d, got: %d", expected, status.Version)
+	}
+	if len(status.Pending()) != 1 {
+		t.Errorf("expected: 1 pending, got: %d", len(status.Pending()))
+	}
+	if err := Check(ctx, db, "sqlite"); err == nil {
+		t.Error("expected error for outdated schema")
+	}
+
+	// 全部還原 (多的 steps 忽略)
+	reverted, err = Down(ctx, db, "sqlite", len(migrations)+1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverted != len(migrations)-1 {
+		t.Errorf("expected: %d, got: %d", len(migrations)-1, reverted)
+	}
+	if status, _ := GetStatus(ctx, db, "sqlite"); status.Version != 0 {
+		t.Errorf("expected: 0, got: %d", status.Version)
+	}
+
+	// 再套用一次
+	if _, err := Up(ctx, db, "sqlite"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Check(ctx, db, "sqlite"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheck_dirty(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := Up(ctx, db, "sqlite"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE schema_migrations SET dirty = true"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Check(ctx, db, "sqlite"); !errors.Is(err, ErrDirty) {
+		t.Errorf("expected: %v, got: %v", ErrDirty, err)
+	}
+	if _, err := Up(ctx, db, "sqlite"); !errors.Is(err, ErrDirty) {
+		t.Errorf("expected: %v, got: %v", ErrDirty, err)
+	}
+}
+
+// GetStatus 與 Check 只讀取, 不建立 schema_migrations (readiness check 不需要 DDL 權限)
+func TestCheck_readOnly(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	status, err := GetStatus(ctx, db, "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Version != 0 || len(status.Pending()) != len(status.Migrations) {
+		t.Errorf("expected version 0 with all migrations pending, got: %d (%d pending)", status.Version, len(status.Pending()))
+	}
+	if err := Check(ctx, db, "sqlite"); err == nil {
+		t.Error("expected error for pending migrations")
+	}
+	exists, err := tableExists(ctx, db, "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exists {
+		t.Error("expected schema_migrations not to be created")
+	}
+}
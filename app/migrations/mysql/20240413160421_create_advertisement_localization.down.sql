@@ -0,0 +1,3 @@
+DROP TABLE `advertisement_localization`;
+
+DROP TABLE `locale`;
@@ -0,0 +1,13 @@
+ALTER TABLE `gender` ADD COLUMN `retired` boolean NOT NULL DEFAULT false;
+
+ALTER TABLE `country` ADD COLUMN `retired` boolean NOT NULL DEFAULT false;
+
+ALTER TABLE `platform` ADD COLUMN `retired` boolean NOT NULL DEFAULT false;
+
+DROP INDEX idx_gender_code ON gender;
+DROP INDEX idx_country_code ON country;
+DROP INDEX idx_platform_name ON platform;
+
+CREATE UNIQUE INDEX idx_gender_code ON gender (code);
+CREATE UNIQUE INDEX idx_country_code ON country (code);
+CREATE UNIQUE INDEX idx_platform_name ON platform (name);
@@ -0,0 +1,33 @@
+CREATE TABLE `country_group` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `code` varchar(32) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `builtin` boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE `country_group_member` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `country_group_id` int NOT NULL,
+  `country_id` int NOT NULL
+);
+
+CREATE TABLE `cond_country_group` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `cond_id` int NOT NULL,
+  `country_group_id` int NOT NULL
+);
+
+ALTER TABLE `country_group_member` ADD FOREIGN KEY (`country_group_id`) REFERENCES `country_group` (`id`);
+
+ALTER TABLE `country_group_member` ADD FOREIGN KEY (`country_id`) REFERENCES `country` (`id`);
+
+ALTER TABLE `cond_country_group` ADD FOREIGN KEY (`cond_id`) REFERENCES `cond` (`id`);
+
+ALTER TABLE `cond_country_group` ADD FOREIGN KEY (`country_group_id`) REFERENCES `country_group` (`id`);
+
+CREATE UNIQUE INDEX idx_country_group_code ON country_group (code);
+
+CREATE UNIQUE INDEX idx_country_group_member_country_group_id_country_id ON country_group_member (country_group_id, country_id);
+
+CREATE INDEX idx_cond_country_group_cond_id ON cond_country_group (cond_id);
+CREATE INDEX idx_cond_country_group_country_group_id ON cond_country_group (country_group_id);
@@ -0,0 +1,20 @@
+CREATE TABLE `segment` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `code` varchar(32) NOT NULL,
+  `name` varchar(255) NOT NULL
+);
+
+CREATE TABLE `cond_segment` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `cond_id` int NOT NULL,
+  `segment_id` int NOT NULL
+);
+
+ALTER TABLE `cond_segment` ADD FOREIGN KEY (`cond_id`) REFERENCES `cond` (`id`);
+
+ALTER TABLE `cond_segment` ADD FOREIGN KEY (`segment_id`) REFERENCES `segment` (`id`);
+
+CREATE UNIQUE INDEX idx_segment_code ON segment (code);
+
+CREATE INDEX idx_cond_segment_cond_id ON cond_segment (cond_id);
+CREATE INDEX idx_cond_segment_segment_id ON cond_segment (segment_id);
@@ -0,0 +1,3 @@
+DROP TABLE `cond_category`;
+
+DROP TABLE `cond_keyword`;
@@ -0,0 +1,21 @@
+CREATE TABLE `cond_keyword` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `cond_id` int NOT NULL,
+  `term` varchar(64) NOT NULL
+);
+
+CREATE TABLE `cond_category` (
+  `id` int PRIMARY KEY AUTO_INCREMENT,
+  `cond_id` int NOT NULL,
+  `term` varchar(64) NOT NULL
+);
+
+ALTER TABLE `cond_keyword` ADD FOREIGN KEY (`cond_id`) REFERENCES `cond` (`id`);
+
+ALTER TABLE `cond_category` ADD FOREIGN KEY (`cond_id`) REFERENCES `cond` (`id`);
+
+CREATE INDEX idx_cond_keyword_cond_id ON cond_keyword (cond_id);
+CREATE INDEX idx_cond_keyword_term ON cond_keyword (term, cond_id);
+
+CREATE INDEX idx_cond_category_cond_id ON cond_category (cond_id);
+CREATE INDEX idx_cond_category_term ON cond_category (term, cond_id);
@@ -0,0 +1,69 @@
+CREATE TABLE advertisement (
+  id serial PRIMARY KEY,
+  title varchar(255) NOT NULL,
+  start_at timestamptz NOT NULL,
+  end_at timestamptz NOT NULL
+);
+
+CREATE TABLE gender (
+  id serial PRIMARY KEY,
+  name varchar(20) NOT NULL,
+  code char(1) NOT NULL
+);
+
+CREATE TABLE country (
+  id serial PRIMARY KEY,
+  name varchar(255) NOT NULL,
+  code char(2) NOT NULL
+);
+
+CREATE TABLE platform (
+  id serial PRIMARY KEY,
+  name varchar(255) NOT NULL
+);
+
+CREATE TABLE cond (
+  id serial PRIMARY KEY,
+  age_start int,
+  age_end int
+);
+
+CREATE TABLE advertisement_cond (
+  id serial PRIMARY KEY,
+  advertisement_id int NOT NULL,
+  cond_id int NOT NULL
+);
+
+CREATE TABLE cond_gender (
+  id serial PRIMARY KEY,
+  cond_id int NOT NULL,
+  gender_id int NOT NULL
+);
+
+CREATE TABLE cond_country (
+  id serial PRIMARY KEY,
+  cond_id int NOT NULL,
+  country_id int NOT NULL
+);
+
+CREATE TABLE cond_platform (
+  id serial PRIMARY KEY,
+  cond_id int NOT NULL,
+  platform_id int NOT NULL
+);
+
+ALTER TABLE advertisement_cond ADD FOREIGN KEY (advertisement_id) REFERENCES advertisement (id);
+
+ALTER TABLE advertisement_cond ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_gender ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_gender ADD FOREIGN KEY (gender_id) REFERENCES gender (id);
+
+ALTER TABLE cond_country ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_country ADD FOREIGN KEY (country_id) REFERENCES country (id);
+
+ALTER TABLE cond_platform ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_platform ADD FOREIGN KEY (platform_id) REFERENCES platform (id);
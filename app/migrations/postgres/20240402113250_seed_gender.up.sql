@@ -0,0 +1,5 @@
+INSERT INTO
+    gender (name, code)
+VALUES
+    ('Male', 'M'),
+    ('Female', 'F');
\ No newline at end of file
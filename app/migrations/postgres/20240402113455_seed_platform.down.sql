@@ -0,0 +1,2 @@
+DELETE FROM cond_platform;
+DELETE FROM platform;
@@ -0,0 +1,11 @@
+DROP INDEX idx_advertisement_cond_advertisement_id;
+DROP INDEX idx_advertisement_cond_cond_id;
+
+DROP INDEX idx_cond_gender_cond_id;
+DROP INDEX idx_cond_gender_gender_id;
+
+DROP INDEX idx_cond_country_cond_id;
+DROP INDEX idx_cond_country_country_id;
+
+DROP INDEX idx_cond_platform_cond_id;
+DROP INDEX idx_cond_platform_platform_id;
\ No newline at end of file
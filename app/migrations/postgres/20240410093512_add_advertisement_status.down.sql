@@ -0,0 +1,3 @@
+DROP INDEX idx_advertisement_status;
+
+ALTER TABLE advertisement DROP COLUMN status;
@@ -0,0 +1,3 @@
+ALTER TABLE advertisement ADD COLUMN status varchar(16) NOT NULL DEFAULT 'active';
+
+CREATE INDEX idx_advertisement_status ON advertisement (status);
@@ -0,0 +1,8 @@
+ALTER TABLE advertisement
+  DROP COLUMN description,
+  DROP COLUMN image_url,
+  DROP COLUMN image_width,
+  DROP COLUMN image_height,
+  DROP COLUMN landing_url,
+  DROP COLUMN cta_label,
+  DROP COLUMN format;
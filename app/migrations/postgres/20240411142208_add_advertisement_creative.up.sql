@@ -0,0 +1,8 @@
+ALTER TABLE advertisement
+  ADD COLUMN description varchar(1000) NOT NULL DEFAULT '',
+  ADD COLUMN image_url varchar(2048) NOT NULL DEFAULT '',
+  ADD COLUMN image_width int NOT NULL DEFAULT 0,
+  ADD COLUMN image_height int NOT NULL DEFAULT 0,
+  ADD COLUMN landing_url varchar(2048) NOT NULL DEFAULT '',
+  ADD COLUMN cta_label varchar(32) NOT NULL DEFAULT '',
+  ADD COLUMN format varchar(16) NOT NULL DEFAULT 'text';
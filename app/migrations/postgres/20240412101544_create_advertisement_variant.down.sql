@@ -0,0 +1 @@
+DROP TABLE advertisement_variant;
@@ -0,0 +1,18 @@
+CREATE TABLE advertisement_variant (
+  id serial PRIMARY KEY,
+  advertisement_id int NOT NULL,
+  name varchar(64) NOT NULL,
+  weight int NOT NULL,
+  title varchar(255) NOT NULL DEFAULT '',
+  description varchar(1000) NOT NULL DEFAULT '',
+  image_url varchar(2048) NOT NULL DEFAULT '',
+  image_width int NOT NULL DEFAULT 0,
+  image_height int NOT NULL DEFAULT 0,
+  landing_url varchar(2048) NOT NULL DEFAULT '',
+  cta_label varchar(32) NOT NULL DEFAULT '',
+  format varchar(16) NOT NULL DEFAULT ''
+);
+
+ALTER TABLE advertisement_variant ADD FOREIGN KEY (advertisement_id) REFERENCES advertisement (id);
+
+CREATE INDEX idx_advertisement_variant_advertisement_id ON advertisement_variant (advertisement_id);
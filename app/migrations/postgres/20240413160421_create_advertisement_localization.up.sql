@@ -0,0 +1,42 @@
+CREATE TABLE locale (
+  id serial PRIMARY KEY,
+  name varchar(255) NOT NULL,
+  code varchar(16) NOT NULL
+);
+
+CREATE TABLE advertisement_localization (
+  id serial PRIMARY KEY,
+  advertisement_id int NOT NULL,
+  locale_id int NOT NULL,
+  title varchar(255) NOT NULL DEFAULT '',
+  description varchar(1000) NOT NULL DEFAULT '',
+  cta_label varchar(32) NOT NULL DEFAULT ''
+);
+
+ALTER TABLE advertisement_localization ADD FOREIGN KEY (advertisement_id) REFERENCES advertisement (id);
+
+ALTER TABLE advertisement_localization ADD FOREIGN KEY (locale_id) REFERENCES locale (id);
+
+CREATE UNIQUE INDEX idx_advertisement_localization_advertisement_id_locale_id ON advertisement_localization (advertisement_id, locale_id);
+
+CREATE INDEX idx_locale_code ON locale (code);
+
+INSERT INTO
+    locale (name, code)
+VALUES
+    ('English', 'en'),
+    ('English (United States)', 'en-US'),
+    ('English (United Kingdom)', 'en-GB'),
+    ('Chinese', 'zh'),
+    ('Chinese (Taiwan)', 'zh-TW'),
+    ('Chinese (Hong Kong)', 'zh-HK'),
+    ('Chinese (China)', 'zh-CN'),
+    ('Japanese', 'ja'),
+    ('Korean', 'ko'),
+    ('Thai', 'th'),
+    ('Vietnamese', 'vi'),
+    ('Indonesian', 'id'),
+    ('Malay', 'ms'),
+    ('French', 'fr'),
+    ('German', 'de'),
+    ('Spanish', 'es');
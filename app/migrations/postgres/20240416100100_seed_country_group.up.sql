@@ -0,0 +1,75 @@
+INSERT INTO
+    country_group (code, name, builtin)
+VALUES
+    ('EU', 'European Union', true),
+    ('EEA', 'European Economic Area', true),
+    ('DACH', 'Germany, Austria and Switzerland', true),
+    ('NORDICS', 'Nordic countries', true),
+    ('ASEAN', 'Association of Southeast Asian Nations', true),
+    ('APAC', 'Asia-Pacific', true),
+    ('GCC', 'Gulf Cooperation Council', true),
+    ('LATAM', 'Latin America', true);
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('AT', 'BE', 'BG', 'HR', 'CY', 'CZ', 'DK', 'EE', 'FI', 'FR', 'DE', 'GR', 'HU', 'IE', 'IT', 'LV', 'LT', 'LU', 'MT', 'NL', 'PL', 'PT', 'RO', 'SK', 'SI', 'ES', 'SE')
+WHERE country_group.code = 'EU';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('AT', 'BE', 'BG', 'HR', 'CY', 'CZ', 'DK', 'EE', 'FI', 'FR', 'DE', 'GR', 'HU', 'IE', 'IT', 'LV', 'LT', 'LU', 'MT', 'NL', 'PL', 'PT', 'RO', 'SK', 'SI', 'ES', 'SE', 'IS', 'LI', 'NO')
+WHERE country_group.code = 'EEA';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('DE', 'AT', 'CH')
+WHERE country_group.code = 'DACH';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('DK', 'FI', 'IS', 'NO', 'SE')
+WHERE country_group.code = 'NORDICS';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('BN', 'KH', 'ID', 'LA', 'MY', 'MM', 'PH', 'SG', 'TH', 'VN')
+WHERE country_group.code = 'ASEAN';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('AU', 'BD', 'BN', 'KH', 'CN', 'FJ', 'HK', 'IN', 'ID', 'JP', 'KR', 'LA', 'MO', 'MY', 'MV', 'MN', 'MM', 'NP', 'NZ', 'PK', 'PG', 'PH', 'SG', 'LK', 'TW', 'TH', 'TL', 'VN')
+WHERE country_group.code = 'APAC';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('AE', 'BH', 'KW', 'OM', 'QA', 'SA')
+WHERE country_group.code = 'GCC';
+
+INSERT INTO
+    country_group_member (country_group_id, country_id)
+SELECT country_group.id,
+    country.id
+FROM country_group
+    JOIN country ON country.code IN ('AR', 'BO', 'BR', 'CL', 'CO', 'CR', 'CU', 'DO', 'EC', 'SV', 'GT', 'HN', 'MX', 'NI', 'PA', 'PY', 'PE', 'PR', 'UY', 'VE')
+WHERE country_group.code = 'LATAM';
@@ -0,0 +1,33 @@
+CREATE TABLE cond_region (
+  id serial PRIMARY KEY,
+  cond_id int NOT NULL,
+  code varchar(6) NOT NULL,
+  country varchar(2) NOT NULL
+);
+
+CREATE TABLE cond_geofence (
+  id serial PRIMARY KEY,
+  cond_id int NOT NULL,
+  lat double precision NOT NULL,
+  lng double precision NOT NULL,
+  radius int NOT NULL
+);
+
+CREATE TABLE cond_geofence_cell (
+  id serial PRIMARY KEY,
+  geofence_id int NOT NULL,
+  geohash varchar(12) NOT NULL
+);
+
+ALTER TABLE cond_region ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_geofence ADD FOREIGN KEY (cond_id) REFERENCES cond (id);
+
+ALTER TABLE cond_geofence_cell ADD FOREIGN KEY (geofence_id) REFERENCES cond_geofence (id);
+
+CREATE INDEX idx_cond_region_cond_id ON cond_region (cond_id);
+
+CREATE INDEX idx_cond_geofence_cond_id ON cond_geofence (cond_id);
+
+CREATE INDEX idx_cond_geofence_cell_geohash ON cond_geofence_cell (geohash);
+CREATE INDEX idx_cond_geofence_cell_geofence_id ON cond_geofence_cell (geofence_id);
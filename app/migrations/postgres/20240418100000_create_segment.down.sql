@@ -0,0 +1,3 @@
+DROP TABLE cond_segment;
+
+DROP TABLE segment;
@@ -0,0 +1,54 @@
+CREATE TABLE advertisement (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  title varchar(255) NOT NULL,
+  start_at datetime NOT NULL,
+  end_at datetime NOT NULL
+);
+
+CREATE TABLE gender (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(20) NOT NULL,
+  code varchar(1) NOT NULL
+);
+
+CREATE TABLE country (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(255) NOT NULL,
+  code varchar(2) NOT NULL
+);
+
+CREATE TABLE platform (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(255) NOT NULL
+);
+
+CREATE TABLE cond (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  age_start int,
+  age_end int
+);
+
+-- SQLite 沒有 ALTER TABLE ... ADD FOREIGN KEY, foreign key 直接寫在 CREATE TABLE
+CREATE TABLE advertisement_cond (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  advertisement_id int NOT NULL REFERENCES advertisement (id),
+  cond_id int NOT NULL REFERENCES cond (id)
+);
+
+CREATE TABLE cond_gender (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  gender_id int NOT NULL REFERENCES gender (id)
+);
+
+CREATE TABLE cond_country (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  country_id int NOT NULL REFERENCES country (id)
+);
+
+CREATE TABLE cond_platform (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  platform_id int NOT NULL REFERENCES platform (id)
+);
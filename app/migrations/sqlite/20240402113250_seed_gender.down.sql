@@ -0,0 +1,2 @@
+DELETE FROM cond_gender;
+DELETE FROM gender;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM cond_country;
+DELETE FROM country;
\ No newline at end of file
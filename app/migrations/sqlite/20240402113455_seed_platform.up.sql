@@ -0,0 +1,6 @@
+INSERT INTO
+    platform (name)
+VALUES
+    ('android'),
+    ('ios'),
+    ('web');
@@ -0,0 +1,5 @@
+DROP INDEX idx_cond_age_start;
+DROP INDEX idx_cond_age_end;
+DROP INDEX idx_gender_code;
+DROP INDEX idx_country_code;
+DROP INDEX idx_platform_name;
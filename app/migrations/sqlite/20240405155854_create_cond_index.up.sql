@@ -0,0 +1,5 @@
+CREATE INDEX idx_cond_age_start ON cond (age_start);
+CREATE INDEX idx_cond_age_end ON cond (age_end);
+CREATE INDEX idx_gender_code ON gender (code);
+CREATE INDEX idx_country_code ON country (code);
+CREATE INDEX idx_platform_name ON platform (name);
\ No newline at end of file
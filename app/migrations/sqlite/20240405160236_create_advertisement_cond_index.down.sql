@@ -0,0 +1 @@
+DROP INDEX idx_adv_cond_covering;
@@ -0,0 +1,7 @@
+ALTER TABLE advertisement DROP COLUMN description;
+ALTER TABLE advertisement DROP COLUMN image_url;
+ALTER TABLE advertisement DROP COLUMN image_width;
+ALTER TABLE advertisement DROP COLUMN image_height;
+ALTER TABLE advertisement DROP COLUMN landing_url;
+ALTER TABLE advertisement DROP COLUMN cta_label;
+ALTER TABLE advertisement DROP COLUMN format;
@@ -0,0 +1,13 @@
+DROP INDEX idx_gender_code;
+DROP INDEX idx_country_code;
+DROP INDEX idx_platform_name;
+
+CREATE INDEX idx_gender_code ON gender (code);
+CREATE INDEX idx_country_code ON country (code);
+CREATE INDEX idx_platform_name ON platform (name);
+
+ALTER TABLE platform DROP COLUMN retired;
+
+ALTER TABLE country DROP COLUMN retired;
+
+ALTER TABLE gender DROP COLUMN retired;
@@ -0,0 +1,5 @@
+DROP TABLE cond_country_group;
+
+DROP TABLE country_group_member;
+
+DROP TABLE country_group;
@@ -0,0 +1,26 @@
+-- SQLite 沒有 ALTER TABLE ... ADD FOREIGN KEY, foreign key 直接寫在 CREATE TABLE
+CREATE TABLE country_group (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  code varchar(32) NOT NULL,
+  name varchar(255) NOT NULL,
+  builtin boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE country_group_member (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  country_group_id int NOT NULL REFERENCES country_group (id),
+  country_id int NOT NULL REFERENCES country (id)
+);
+
+CREATE TABLE cond_country_group (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  country_group_id int NOT NULL REFERENCES country_group (id)
+);
+
+CREATE UNIQUE INDEX idx_country_group_code ON country_group (code);
+
+CREATE UNIQUE INDEX idx_country_group_member_country_group_id_country_id ON country_group_member (country_group_id, country_id);
+
+CREATE INDEX idx_cond_country_group_cond_id ON cond_country_group (cond_id);
+CREATE INDEX idx_cond_country_group_country_group_id ON cond_country_group (country_group_id);
@@ -0,0 +1,16 @@
+DELETE FROM cond_country_group
+WHERE country_group_id IN (
+        SELECT id
+        FROM country_group
+        WHERE builtin = true
+    );
+
+DELETE FROM country_group_member
+WHERE country_group_id IN (
+        SELECT id
+        FROM country_group
+        WHERE builtin = true
+    );
+
+DELETE FROM country_group
+WHERE builtin = true;
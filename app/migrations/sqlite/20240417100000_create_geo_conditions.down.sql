@@ -0,0 +1,5 @@
+DROP TABLE cond_geofence_cell;
+
+DROP TABLE cond_geofence;
+
+DROP TABLE cond_region;
@@ -0,0 +1,28 @@
+-- SQLite 沒有 ALTER TABLE ... ADD FOREIGN KEY, foreign key 直接寫在 CREATE TABLE
+CREATE TABLE cond_region (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  code varchar(6) NOT NULL,
+  country varchar(2) NOT NULL
+);
+
+CREATE TABLE cond_geofence (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  lat real NOT NULL,
+  lng real NOT NULL,
+  radius int NOT NULL
+);
+
+CREATE TABLE cond_geofence_cell (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  geofence_id int NOT NULL REFERENCES cond_geofence (id),
+  geohash varchar(12) NOT NULL
+);
+
+CREATE INDEX idx_cond_region_cond_id ON cond_region (cond_id);
+
+CREATE INDEX idx_cond_geofence_cond_id ON cond_geofence (cond_id);
+
+CREATE INDEX idx_cond_geofence_cell_geohash ON cond_geofence_cell (geohash);
+CREATE INDEX idx_cond_geofence_cell_geofence_id ON cond_geofence_cell (geofence_id);
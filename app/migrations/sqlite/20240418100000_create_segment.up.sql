@@ -0,0 +1,17 @@
+-- SQLite 沒有 ALTER TABLE ... ADD FOREIGN KEY, foreign key 直接寫在 CREATE TABLE
+CREATE TABLE segment (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  code varchar(32) NOT NULL,
+  name varchar(255) NOT NULL
+);
+
+CREATE TABLE cond_segment (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  segment_id int NOT NULL REFERENCES segment (id)
+);
+
+CREATE UNIQUE INDEX idx_segment_code ON segment (code);
+
+CREATE INDEX idx_cond_segment_cond_id ON cond_segment (cond_id);
+CREATE INDEX idx_cond_segment_segment_id ON cond_segment (segment_id);
@@ -0,0 +1,18 @@
+-- SQLite 沒有 ALTER TABLE ... ADD FOREIGN KEY, foreign key 直接寫在 CREATE TABLE
+CREATE TABLE cond_keyword (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  term varchar(64) NOT NULL
+);
+
+CREATE TABLE cond_category (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  cond_id int NOT NULL REFERENCES cond (id),
+  term varchar(64) NOT NULL
+);
+
+CREATE INDEX idx_cond_keyword_cond_id ON cond_keyword (cond_id);
+CREATE INDEX idx_cond_keyword_term ON cond_keyword (term, cond_id);
+
+CREATE INDEX idx_cond_category_cond_id ON cond_category (cond_id);
+CREATE INDEX idx_cond_category_term ON cond_category (term, cond_id);
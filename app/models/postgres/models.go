@@ -0,0 +1,169 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Advertisement struct {
+	ID          int32     `json:"id"`
+	Title       string    `json:"title"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	ImageUrl    string    `json:"image_url"`
+	ImageWidth  int32     `json:"image_width"`
+	ImageHeight int32     `json:"image_height"`
+	LandingUrl  string    `json:"landing_url"`
+	CtaLabel    string    `json:"cta_label"`
+	Format      string    `json:"format"`
+}
+
+type AdvertisementCond struct {
+	ID              int32 `json:"id"`
+	AdvertisementID int32 `json:"advertisement_id"`
+	CondID          int32 `json:"cond_id"`
+}
+
+type AdvertisementLocalization struct {
+	ID              int32  `json:"id"`
+	AdvertisementID int32  `json:"advertisement_id"`
+	LocaleID        int32  `json:"locale_id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	CtaLabel        string `json:"cta_label"`
+}
+
+type AdvertisementVariant struct {
+	ID              int32  `json:"id"`
+	AdvertisementID int32  `json:"advertisement_id"`
+	Name            string `json:"name"`
+	Weight          int32  `json:"weight"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	ImageUrl        string `json:"image_url"`
+	ImageWidth      int32  `json:"image_width"`
+	ImageHeight     int32  `json:"image_height"`
+	LandingUrl      string `json:"landing_url"`
+	CtaLabel        string `json:"cta_label"`
+	Format          string `json:"format"`
+}
+
+type Cond struct {
+	ID       int32         `json:"id"`
+	AgeStart sql.NullInt32 `json:"age_start"`
+	AgeEnd   sql.NullInt32 `json:"age_end"`
+}
+
+type CondCategory struct {
+	ID     int32  `json:"id"`
+	CondID int32  `json:"cond_id"`
+	Term   string `json:"term"`
+}
+
+type CondCountry struct {
+	ID        int32 `json:"id"`
+	CondID    int32 `json:"cond_id"`
+	CountryID int32 `json:"country_id"`
+}
+
+type CondCountryGroup struct {
+	ID             int32 `json:"id"`
+	CondID         int32 `json:"cond_id"`
+	CountryGroupID int32 `json:"country_group_id"`
+}
+
+type CondGender struct {
+	ID       int32 `json:"id"`
+	CondID   int32 `json:"cond_id"`
+	GenderID int32 `json:"gender_id"`
+}
+
+type CondGeofence struct {
+	ID     int32   `json:"id"`
+	CondID int32   `json:"cond_id"`
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Radius int32   `json:"radius"`
+}
+
+type CondGeofenceCell struct {
+	ID         int32  `json:"id"`
+	GeofenceID int32  `json:"geofence_id"`
+	Geohash    string `json:"geohash"`
+}
+
+type CondKeyword struct {
+	ID     int32  `json:"id"`
+	CondID int32  `json:"cond_id"`
+	Term   string `json:"term"`
+}
+
+type CondPlatform struct {
+	ID         int32 `json:"id"`
+	CondID     int32 `json:"cond_id"`
+	PlatformID int32 `json:"platform_id"`
+}
+
+type CondRegion struct {
+	ID      int32  `json:"id"`
+	CondID  int32  `json:"cond_id"`
+	Code    string `json:"code"`
+	Country string `json:"country"`
+}
+
+type CondSegment struct {
+	ID        int32 `json:"id"`
+	CondID    int32 `json:"cond_id"`
+	SegmentID int32 `json:"segment_id"`
+}
+
+type Country struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Retired bool   `json:"retired"`
+}
+
+type CountryGroup struct {
+	ID      int32  `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Builtin bool   `json:"builtin"`
+}
+
+type CountryGroupMember struct {
+	ID             int32 `json:"id"`
+	CountryGroupID int32 `json:"country_group_id"`
+	CountryID      int32 `json:"country_id"`
+}
+
+type Gender struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Retired bool   `json:"retired"`
+}
+
+type Locale struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+type Platform struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Retired bool   `json:"retired"`
+}
+
+type Segment struct {
+	ID   int32  `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
@@ -3,7 +3,15 @@
 SELECT DISTINCT adv.id,
     adv.title,
     adv.start_at,
-    adv.end_at
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format
 FROM advertisement adv
     LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
     LEFT JOIN cond ON adc.cond_id = cond.id
@@ -13,44 +21,157 @@ FROM advertisement adv
     LEFT JOIN country ON cond_country.country_id = country.id
     LEFT JOIN cond_platform ON cond.id = cond_platform.cond_id
     LEFT JOIN platform ON cond_platform.platform_id = platform.id
-WHERE (
-        sqlc.narg(age) IS NULL
-        OR (
-            (
-                cond.age_start IS NULL
-                OR cond.age_start <= sqlc.narg(age)
+WHERE adv.status = 'active'
+    AND adv.start_at <= UTC_TIMESTAMP()
+    AND adv.end_at >= UTC_TIMESTAMP()
+    AND (
+        (
+            sqlc.narg(age) IS NULL
+            OR (
+                (
+                    cond.age_start IS NULL
+                    OR cond.age_start <= sqlc.narg(age)
+                )
+                AND (
+                    cond.age_end IS NULL
+                    OR cond.age_end >= sqlc.narg(age)
+                )
             )
-            AND (
-                cond.age_end IS NULL
-                OR cond.age_end >= sqlc.narg(age)
+        )
+        AND (
+            sqlc.narg(gender) IS NULL
+            OR gender.code = sqlc.narg(gender)
+            OR cond_gender.cond_id IS NULL
+        )
+        AND (
+            sqlc.narg(country) IS NULL
+            OR country.code = sqlc.narg(country)
+            OR EXISTS (
+                SELECT 1
+                FROM cond_country_group ccg
+                    JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+                    JOIN country group_country ON cgm.country_id = group_country.id
+                WHERE ccg.cond_id = cond.id
+                    AND group_country.code = sqlc.narg(country)
+            )
+            OR (
+                cond_country.cond_id IS NULL
+                AND NOT EXISTS (
+                    SELECT 1
+                    FROM cond_country_group ccg
+                    WHERE ccg.cond_id = cond.id
+                )
             )
         )
+        AND (
+            sqlc.narg(platform) IS NULL
+            OR platform.name = sqlc.narg(platform)
+            OR cond_platform.cond_id IS NULL
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+            )
+            OR sqlc.narg(country) IS NULL
+            OR EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+                    AND (
+                        r.code = sqlc.narg(region)
+                        OR (
+                            sqlc.narg(region) IS NULL
+                            AND r.country = sqlc.narg(country)
+                        )
+                    )
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+                    AND g.id IN (sqlc.slice(geofence_ids))
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                WHERE cs.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                    JOIN segment s ON cs.segment_id = s.id
+                WHERE cs.cond_id = cond.id
+                    AND s.code IN (sqlc.slice(segments))
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+                    AND ck.term IN (sqlc.slice(keywords))
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+                    AND cc.term = sqlc.narg(category)
+            )
+        )
+        OR adc.id IS NULL
     )
-    AND (
-        sqlc.narg(gender) IS NULL
-        OR gender.code = sqlc.narg(gender)
-        OR cond_gender.cond_id IS NULL
-    )
-    AND (
-        sqlc.narg(country) IS NULL
-        OR country.code = sqlc.narg(country)
-        OR cond_country.cond_id IS NULL
-    )
-    AND (
-        sqlc.narg(platform) IS NULL
-        OR platform.name = sqlc.narg(platform)
-        OR cond_platform.cond_id IS NULL
-    )
-    OR adc.id IS NULL
 ORDER BY end_at ASC
 LIMIT ?, ?;
 --
 -- name: CreateAdvertisement :execlastid
-INSERT INTO advertisement (title, start_at, end_at)
+INSERT INTO advertisement (
+        title,
+        start_at,
+        end_at,
+        status,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
 VALUES (
         sqlc.arg(title),
         sqlc.arg(start_at),
-        sqlc.arg(end_at)
+        sqlc.arg(end_at),
+        sqlc.arg(status),
+        sqlc.arg(description),
+        sqlc.arg(image_url),
+        sqlc.arg(image_width),
+        sqlc.arg(image_height),
+        sqlc.arg(landing_url),
+        sqlc.arg(cta_label),
+        sqlc.arg(format)
     );
 --
 -- name: CreateCondition :execlastid
@@ -89,6 +210,41 @@ VALUES (
         )
     );
 --
+-- name: CreateConditionCountryGroup :exec
+INSERT INTO cond_country_group (cond_id, country_group_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM country_group
+            WHERE code = sqlc.arg(country_group)
+        )
+    );
+--
+-- name: CreateConditionRegion :exec
+INSERT INTO cond_region (cond_id, code, country)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(region),
+        sqlc.arg(country)
+    );
+--
+-- name: CreateConditionGeofence :execlastid
+INSERT INTO cond_geofence (cond_id, lat, lng, radius)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(lat),
+        sqlc.arg(lng),
+        sqlc.arg(radius)
+    );
+--
+-- name: CreateConditionGeofenceCell :exec
+INSERT INTO cond_geofence_cell (geofence_id, geohash)
+VALUES (
+        sqlc.arg(geofence_id),
+        sqlc.arg(geohash)
+    );
+--
 -- name: CreateConditionPlatform :exec
 INSERT INTO cond_platform (cond_id, platform_id)
 VALUES (
@@ -100,14 +256,550 @@ VALUES (
         )
     );
 --
+-- name: CreateConditionSegment :exec
+INSERT INTO cond_segment (cond_id, segment_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM segment
+            WHERE code = sqlc.arg(segment)
+        )
+    );
+--
+-- name: CreateConditionKeyword :exec
+INSERT INTO cond_keyword (cond_id, term)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(term)
+    );
+--
+-- name: CreateConditionCategory :exec
+INSERT INTO cond_category (cond_id, term)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(term)
+    );
+--
 -- name: GetAllGenders :many
 SELECT code
-FROM gender;
+FROM gender
+WHERE retired = false;
 --
 -- name: GetAllCountries :many
 SELECT code
-FROM country;
+FROM country
+WHERE retired = false;
+--
+-- name: GetAllCountryGroups :many
+SELECT code
+FROM country_group;
+--
+-- name: GetAllSegments :many
+SELECT code
+FROM segment;
+--
+-- name: GetAllKeywords :many
+SELECT DISTINCT cond_keyword.term
+FROM cond_keyword
+    JOIN advertisement_cond adc ON cond_keyword.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived';
+--
+-- name: GetAllCategories :many
+SELECT DISTINCT cond_category.term
+FROM cond_category
+    JOIN advertisement_cond adc ON cond_category.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived';
 --
 -- name: GetAllPlatforms :many
 SELECT name
-FROM platform;
\ No newline at end of file
+FROM platform
+WHERE retired = false;
+--
+-- name: GetAllLocales :many
+SELECT code
+FROM locale;--
+-- name: ExportAdvertisements :many
+SELECT adv.id,
+    adv.title,
+    adv.start_at,
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(
+                        JSON_OBJECT(
+                            'name',
+                            v.name,
+                            'weight',
+                            v.weight,
+                            'title',
+                            v.title,
+                            'creative',
+                            JSON_OBJECT(
+                                'description',
+                                v.description,
+                                'imageUrl',
+                                v.image_url,
+                                'imageWidth',
+                                v.image_width,
+                                'imageHeight',
+                                v.image_height,
+                                'landingUrl',
+                                v.landing_url,
+                                'ctaLabel',
+                                v.cta_label,
+                                'format',
+                                v.format
+                            )
+                        )
+                    )
+                FROM advertisement_variant v
+                WHERE v.advertisement_id = adv.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS variants,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_OBJECTAGG(
+                        locale.code,
+                        JSON_OBJECT(
+                            'title',
+                            l.title,
+                            'description',
+                            l.description,
+                            'ctaLabel',
+                            l.cta_label
+                        )
+                    )
+                FROM advertisement_localization l
+                    JOIN locale ON l.locale_id = locale.id
+                WHERE l.advertisement_id = adv.id
+            ),
+            JSON_OBJECT()
+        ) AS JSON
+    ) AS localizations,
+    cond.id AS cond_id,
+    cond.age_start,
+    cond.age_end,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(gender.code)
+                FROM cond_gender
+                    JOIN gender ON cond_gender.gender_id = gender.id
+                WHERE cond_gender.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS genders,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(country.code)
+                FROM cond_country
+                    JOIN country ON cond_country.country_id = country.id
+                WHERE cond_country.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS countries,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(country_group.code)
+                FROM cond_country_group
+                    JOIN country_group ON cond_country_group.country_group_id = country_group.id
+                WHERE cond_country_group.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS country_groups,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_region.code)
+                FROM cond_region
+                WHERE cond_region.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS regions,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(
+                        JSON_OBJECT('lat', g.lat, 'lng', g.lng, 'radius', g.radius)
+                    )
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS geofences,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(platform.name)
+                FROM cond_platform
+                    JOIN platform ON cond_platform.platform_id = platform.id
+                WHERE cond_platform.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS platforms,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(segment.code)
+                FROM cond_segment
+                    JOIN segment ON cond_segment.segment_id = segment.id
+                WHERE cond_segment.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS segments,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_keyword.term)
+                FROM cond_keyword
+                WHERE cond_keyword.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS keywords,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_category.term)
+                FROM cond_category
+                WHERE cond_category.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS categories
+FROM advertisement adv
+    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    LEFT JOIN cond ON adc.cond_id = cond.id
+WHERE (
+        sqlc.narg(status) IS NULL
+        OR adv.status = sqlc.narg(status)
+    )
+    AND (
+        sqlc.narg(window_start) IS NULL
+        OR adv.end_at >= sqlc.narg(window_start)
+    )
+    AND (
+        sqlc.narg(window_end) IS NULL
+        OR adv.start_at <= sqlc.narg(window_end)
+    )
+ORDER BY adv.id ASC,
+    cond.id ASC;
+--
+-- name: ListAdvertisements :many
+SELECT *
+FROM advertisement
+WHERE (
+        sqlc.narg(status) IS NULL
+        OR status = sqlc.narg(status)
+    )
+ORDER BY id ASC
+LIMIT ?, ?;
+--
+-- name: GetAdvertisementStatus :one
+SELECT status,
+    start_at
+FROM advertisement
+WHERE id = sqlc.arg(id);
+--
+-- name: UpdateAdvertisementStatus :execrows
+UPDATE advertisement
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+    AND status = sqlc.arg(current_status);
+--
+-- name: ActivateScheduledAdvertisements :execrows
+UPDATE advertisement
+SET status = 'active'
+WHERE status = 'scheduled'
+    AND start_at <= sqlc.arg(now);
+--
+-- name: ArchiveExpiredAdvertisements :execrows
+UPDATE advertisement
+SET status = 'archived'
+WHERE status IN ('active', 'paused')
+    AND end_at < sqlc.arg(now);
+--
+-- name: CreateAdvertisementVariant :exec
+INSERT INTO advertisement_variant (
+        advertisement_id,
+        name,
+        weight,
+        title,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
+VALUES (
+        sqlc.arg(advertisement_id),
+        sqlc.arg(name),
+        sqlc.arg(weight),
+        sqlc.arg(title),
+        sqlc.arg(description),
+        sqlc.arg(image_url),
+        sqlc.arg(image_width),
+        sqlc.arg(image_height),
+        sqlc.arg(landing_url),
+        sqlc.arg(cta_label),
+        sqlc.arg(format)
+    );
+--
+-- name: GetAdvertisementVariants :many
+SELECT *
+FROM advertisement_variant
+WHERE advertisement_id IN (sqlc.slice(advertisement_ids))
+ORDER BY advertisement_id ASC,
+    id ASC;
+--
+-- name: CreateAdvertisementLocalization :exec
+INSERT INTO advertisement_localization (
+        advertisement_id,
+        locale_id,
+        title,
+        description,
+        cta_label
+    )
+VALUES (
+        sqlc.arg(advertisement_id),
+        (
+            SELECT id
+            FROM locale
+            WHERE code = sqlc.arg(locale)
+        ),
+        sqlc.arg(title),
+        sqlc.arg(description),
+        sqlc.arg(cta_label)
+    );
+--
+-- name: GetAdvertisementLocalizations :many
+SELECT l.advertisement_id,
+    locale.code AS locale,
+    l.title,
+    l.description,
+    l.cta_label
+FROM advertisement_localization l
+    JOIN locale ON l.locale_id = locale.id
+WHERE l.advertisement_id IN (sqlc.slice(advertisement_ids))
+    AND locale.code IN (sqlc.slice(locales));
+--
+-- name: UpsertGender :exec
+INSERT INTO gender (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name),
+    retired = false;
+--
+-- name: RetireGender :execrows
+UPDATE gender
+SET retired = true
+WHERE code = sqlc.arg(code)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingGender :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_gender ON adc.cond_id = cond_gender.cond_id
+    JOIN gender ON cond_gender.gender_id = gender.id
+WHERE gender.code = sqlc.arg(code)
+    AND adv.status <> 'archived';
+--
+-- name: UpsertCountry :exec
+INSERT INTO country (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name),
+    retired = false;
+--
+-- name: RetireCountry :execrows
+UPDATE country
+SET retired = true
+WHERE code = sqlc.arg(code)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingCountry :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN country ON country.code = sqlc.arg(code)
+WHERE adv.status <> 'archived'
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM cond_country
+            WHERE cond_country.cond_id = adc.cond_id
+                AND cond_country.country_id = country.id
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_region
+            WHERE cond_region.cond_id = adc.cond_id
+                AND cond_region.country = country.code
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_country_group ccg
+                JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+            WHERE ccg.cond_id = adc.cond_id
+                AND cgm.country_id = country.id
+        )
+    );
+--
+-- name: UpsertPlatform :exec
+INSERT INTO platform (name)
+VALUES (
+        sqlc.arg(name)
+    ) ON DUPLICATE KEY UPDATE retired = false;
+--
+-- name: RetirePlatform :execrows
+UPDATE platform
+SET retired = true
+WHERE name = sqlc.arg(name)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingPlatform :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_platform ON adc.cond_id = cond_platform.cond_id
+    JOIN platform ON cond_platform.platform_id = platform.id
+WHERE platform.name = sqlc.arg(name)
+    AND adv.status <> 'archived';
+--
+-- name: ListCountryGroupMembers :many
+SELECT country_group.code,
+    country_group.name,
+    country_group.builtin,
+    country.code AS country
+FROM country_group
+    LEFT JOIN country_group_member cgm ON country_group.id = cgm.country_group_id
+    LEFT JOIN country ON cgm.country_id = country.id
+ORDER BY country_group.code,
+    country.code;
+--
+-- name: GetCountryGroupBuiltin :one
+SELECT builtin
+FROM country_group
+WHERE code = sqlc.arg(code);
+--
+-- name: UpsertCountryGroup :exec
+INSERT INTO country_group (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name);
+--
+-- name: DeleteCountryGroupMembers :exec
+DELETE FROM country_group_member
+WHERE country_group_id = (
+        SELECT id
+        FROM country_group
+        WHERE code = sqlc.arg(code)
+    );
+--
+-- name: CreateCountryGroupMember :exec
+INSERT INTO country_group_member (country_group_id, country_id)
+VALUES (
+        (
+            SELECT id
+            FROM country_group
+            WHERE country_group.code = sqlc.arg(country_group)
+        ),
+        (
+            SELECT id
+            FROM country
+            WHERE country.code = sqlc.arg(country)
+        )
+    );
+--
+-- name: DeleteCountryGroup :execrows
+DELETE FROM country_group
+WHERE code = sqlc.arg(code)
+    AND builtin = false;
+--
+-- name: CountConditionsUsingCountryGroup :one
+SELECT COUNT(*)
+FROM cond_country_group
+    JOIN country_group ON cond_country_group.country_group_id = country_group.id
+WHERE country_group.code = sqlc.arg(code);
+--
+-- name: ResolveGeofences :many
+SELECT DISTINCT g.id
+FROM cond_geofence_cell cell
+    JOIN cond_geofence g ON cell.geofence_id = g.id
+    JOIN advertisement_cond adc ON g.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE cell.geohash IN (
+        SUBSTRING(sqlc.arg(geohash), 1, 2),
+        SUBSTRING(sqlc.arg(geohash), 1, 3),
+        SUBSTRING(sqlc.arg(geohash), 1, 4),
+        SUBSTRING(sqlc.arg(geohash), 1, 5),
+        SUBSTRING(sqlc.arg(geohash), 1, 6)
+    )
+    AND ((sqlc.arg(lat) - g.lat) * (sqlc.arg(lat) - g.lat) + (sqlc.arg(lng) - g.lng) * (sqlc.arg(lng) - g.lng) * sqlc.arg(lng_scale)) * 111195 * 111195 <= 1.0 * g.radius * g.radius
+    AND adv.status = 'active'
+ORDER BY g.id;
+--
+-- name: ListSegments :many
+SELECT segment.code,
+    segment.name,
+    COUNT(cond_segment.id) AS conditions
+FROM segment
+    LEFT JOIN cond_segment ON segment.id = cond_segment.segment_id
+GROUP BY segment.id,
+    segment.code,
+    segment.name
+ORDER BY segment.code;
+--
+-- name: UpsertSegment :exec
+INSERT INTO segment (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name);
+--
+-- name: DeleteSegment :execrows
+DELETE FROM segment
+WHERE code = sqlc.arg(code);
+--
+-- name: CountConditionsUsingSegment :one
+SELECT COUNT(*)
+FROM cond_segment
+    JOIN segment ON cond_segment.segment_id = segment.id
+WHERE segment.code = sqlc.arg(code);
+--
+-- name: CountAdvertisementsByStatus :many
+SELECT status,
+    COUNT(*) AS count
+FROM advertisement
+GROUP BY status
+ORDER BY status;
@@ -8,12 +8,198 @@ package models
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 )
 
-const createAdvertisement = `-- name: CreateAdvertisement :execlastid
-INSERT INTO advertisement (title, start_at, end_at)
+const ActivateScheduledAdvertisements = `-- name: ActivateScheduledAdvertisements :execrows
+UPDATE advertisement
+SET status = 'active'
+WHERE status = 'scheduled'
+    AND start_at <= ?
+`
+
+func (q *Queries) ActivateScheduledAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, ActivateScheduledAdvertisements, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ArchiveExpiredAdvertisements = `-- name: ArchiveExpiredAdvertisements :execrows
+UPDATE advertisement
+SET status = 'archived'
+WHERE status IN ('active', 'paused')
+    AND end_at < ?
+`
+
+func (q *Queries) ArchiveExpiredAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, ArchiveExpiredAdvertisements, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const CountAdvertisementsByStatus = `-- name: CountAdvertisementsByStatus :many
+SELECT status,
+    COUNT(*) AS count
+FROM advertisement
+GROUP BY status
+ORDER BY status
+`
+
+type CountAdvertisementsByStatusRow struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountAdvertisementsByStatus(ctx context.Context) ([]CountAdvertisementsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, CountAdvertisementsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountAdvertisementsByStatusRow
+	for rows.Next() {
+		var i CountAdvertisementsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const CountAdvertisementsUsingCountry = `-- name: CountAdvertisementsUsingCountry :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN country ON country.code = ?
+WHERE adv.status <> 'archived'
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM cond_country
+            WHERE cond_country.cond_id = adc.cond_id
+                AND cond_country.country_id = country.id
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_region
+            WHERE cond_region.cond_id = adc.cond_id
+                AND cond_region.country = country.code
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_country_group ccg
+                JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+            WHERE ccg.cond_id = adc.cond_id
+                AND cgm.country_id = country.id
+        )
+    )
+`
+
+func (q *Queries) CountAdvertisementsUsingCountry(ctx context.Context, code string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, CountAdvertisementsUsingCountry, code)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CountAdvertisementsUsingGender = `-- name: CountAdvertisementsUsingGender :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_gender ON adc.cond_id = cond_gender.cond_id
+    JOIN gender ON cond_gender.gender_id = gender.id
+WHERE gender.code = ?
+    AND adv.status <> 'archived'
+`
+
+func (q *Queries) CountAdvertisementsUsingGender(ctx context.Context, code string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, CountAdvertisementsUsingGender, code)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CountAdvertisementsUsingPlatform = `-- name: CountAdvertisementsUsingPlatform :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_platform ON adc.cond_id = cond_platform.cond_id
+    JOIN platform ON cond_platform.platform_id = platform.id
+WHERE platform.name = ?
+    AND adv.status <> 'archived'
+`
+
+func (q *Queries) CountAdvertisementsUsingPlatform(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, CountAdvertisementsUsingPlatform, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CountConditionsUsingCountryGroup = `-- name: CountConditionsUsingCountryGroup :one
+SELECT COUNT(*)
+FROM cond_country_group
+    JOIN country_group ON cond_country_group.country_group_id = country_group.id
+WHERE country_group.code = ?
+`
+
+func (q *Queries) CountConditionsUsingCountryGroup(ctx context.Context, code string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, CountConditionsUsingCountryGroup, code)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CountConditionsUsingSegment = `-- name: CountConditionsUsingSegment :one
+SELECT COUNT(*)
+FROM cond_segment
+    JOIN segment ON cond_segment.segment_id = segment.id
+WHERE segment.code = ?
+`
+
+func (q *Queries) CountConditionsUsingSegment(ctx context.Context, code string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, CountConditionsUsingSegment, code)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CreateAdvertisement = `-- name: CreateAdvertisement :execlastid
+INSERT INTO advertisement (
+        title,
+        start_at,
+        end_at,
+        status,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
 VALUES (
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
         ?,
         ?,
         ?
@@ -21,20 +207,40 @@ VALUES (
 `
 
 type CreateAdvertisementParams struct {
-	Title   string    `json:"title"`
-	StartAt time.Time `json:"start_at"`
-	EndAt   time.Time `json:"end_at"`
+	Title       string    `json:"title"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	ImageUrl    string    `json:"image_url"`
+	ImageWidth  int32     `json:"image_width"`
+	ImageHeight int32     `json:"image_height"`
+	LandingUrl  string    `json:"landing_url"`
+	CtaLabel    string    `json:"cta_label"`
+	Format      string    `json:"format"`
 }
 
 func (q *Queries) CreateAdvertisement(ctx context.Context, arg CreateAdvertisementParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, createAdvertisement, arg.Title, arg.StartAt, arg.EndAt)
+	result, err := q.db.ExecContext(ctx, CreateAdvertisement,
+		arg.Title,
+		arg.StartAt,
+		arg.EndAt,
+		arg.Status,
+		arg.Description,
+		arg.ImageUrl,
+		arg.ImageWidth,
+		arg.ImageHeight,
+		arg.LandingUrl,
+		arg.CtaLabel,
+		arg.Format,
+	)
 	if err != nil {
 		return 0, err
 	}
 	return result.LastInsertId()
 }
 
-const createAdvertisementCondition = `-- name: CreateAdvertisementCondition :exec
+const CreateAdvertisementCondition = `-- name: CreateAdvertisementCondition :exec
 INSERT INTO advertisement_cond (advertisement_id, cond_id)
 VALUES (
         ?,
@@ -48,11 +254,111 @@ type CreateAdvertisementConditionParams struct {
 }
 
 func (q *Queries) CreateAdvertisementCondition(ctx context.Context, arg CreateAdvertisementConditionParams) error {
-	_, err := q.db.ExecContext(ctx, createAdvertisementCondition, arg.AdvertisementID, arg.ConditionID)
+	_, err := q.db.ExecContext(ctx, CreateAdvertisementCondition, arg.AdvertisementID, arg.ConditionID)
+	return err
+}
+
+const CreateAdvertisementLocalization = `-- name: CreateAdvertisementLocalization :exec
+INSERT INTO advertisement_localization (
+        advertisement_id,
+        locale_id,
+        title,
+        description,
+        cta_label
+    )
+VALUES (
+        ?,
+        (
+            SELECT id
+            FROM locale
+            WHERE code = ?
+        ),
+        ?,
+        ?,
+        ?
+    )
+`
+
+type CreateAdvertisementLocalizationParams struct {
+	AdvertisementID int32  `json:"advertisement_id"`
+	Locale          string `json:"locale"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	CtaLabel        string `json:"cta_label"`
+}
+
+func (q *Queries) CreateAdvertisementLocalization(ctx context.Context, arg CreateAdvertisementLocalizationParams) error {
+	_, err := q.db.ExecContext(ctx, CreateAdvertisementLocalization,
+		arg.AdvertisementID,
+		arg.Locale,
+		arg.Title,
+		arg.Description,
+		arg.CtaLabel,
+	)
+	return err
+}
+
+const CreateAdvertisementVariant = `-- name: CreateAdvertisementVariant :exec
+INSERT INTO advertisement_variant (
+        advertisement_id,
+        name,
+        weight,
+        title,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
+VALUES (
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?,
+        ?
+    )
+`
+
+type CreateAdvertisementVariantParams struct {
+	AdvertisementID int32  `json:"advertisement_id"`
+	Name            string `json:"name"`
+	Weight          int32  `json:"weight"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	ImageUrl        string `json:"image_url"`
+	ImageWidth      int32  `json:"image_width"`
+	ImageHeight     int32  `json:"image_height"`
+	LandingUrl      string `json:"landing_url"`
+	CtaLabel        string `json:"cta_label"`
+	Format          string `json:"format"`
+}
+
+func (q *Queries) CreateAdvertisementVariant(ctx context.Context, arg CreateAdvertisementVariantParams) error {
+	_, err := q.db.ExecContext(ctx, CreateAdvertisementVariant,
+		arg.AdvertisementID,
+		arg.Name,
+		arg.Weight,
+		arg.Title,
+		arg.Description,
+		arg.ImageUrl,
+		arg.ImageWidth,
+		arg.ImageHeight,
+		arg.LandingUrl,
+		arg.CtaLabel,
+		arg.Format,
+	)
 	return err
 }
 
-const createCondition = `-- name: CreateCondition :execlastid
+const CreateCondition = `-- name: CreateCondition :execlastid
 INSERT INTO cond (age_start, age_end)
 VALUES (
         ?,
@@ -66,14 +372,32 @@ type CreateConditionParams struct {
 }
 
 func (q *Queries) CreateCondition(ctx context.Context, arg CreateConditionParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, createCondition, arg.AgeStart, arg.AgeEnd)
+	result, err := q.db.ExecContext(ctx, CreateCondition, arg.AgeStart, arg.AgeEnd)
 	if err != nil {
 		return 0, err
 	}
 	return result.LastInsertId()
 }
 
-const createConditionCountry = `-- name: CreateConditionCountry :exec
+const CreateConditionCategory = `-- name: CreateConditionCategory :exec
+INSERT INTO cond_category (cond_id, term)
+VALUES (
+        ?,
+        ?
+    )
+`
+
+type CreateConditionCategoryParams struct {
+	ConditionID int32  `json:"condition_id"`
+	Term        string `json:"term"`
+}
+
+func (q *Queries) CreateConditionCategory(ctx context.Context, arg CreateConditionCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionCategory, arg.ConditionID, arg.Term)
+	return err
+}
+
+const CreateConditionCountry = `-- name: CreateConditionCountry :exec
 INSERT INTO cond_country (cond_id, country_id)
 VALUES (
         ?,
@@ -91,11 +415,33 @@ type CreateConditionCountryParams struct {
 }
 
 func (q *Queries) CreateConditionCountry(ctx context.Context, arg CreateConditionCountryParams) error {
-	_, err := q.db.ExecContext(ctx, createConditionCountry, arg.ConditionID, arg.Country)
+	_, err := q.db.ExecContext(ctx, CreateConditionCountry, arg.ConditionID, arg.Country)
+	return err
+}
+
+const CreateConditionCountryGroup = `-- name: CreateConditionCountryGroup :exec
+INSERT INTO cond_country_group (cond_id, country_group_id)
+VALUES (
+        ?,
+        (
+            SELECT id
+            FROM country_group
+            WHERE code = ?
+        )
+    )
+`
+
+type CreateConditionCountryGroupParams struct {
+	ConditionID  int32  `json:"condition_id"`
+	CountryGroup string `json:"country_group"`
+}
+
+func (q *Queries) CreateConditionCountryGroup(ctx context.Context, arg CreateConditionCountryGroupParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionCountryGroup, arg.ConditionID, arg.CountryGroup)
 	return err
 }
 
-const createConditionGender = `-- name: CreateConditionGender :exec
+const CreateConditionGender = `-- name: CreateConditionGender :exec
 INSERT INTO cond_gender (cond_id, gender_id)
 VALUES (
         ?,
@@ -113,11 +459,77 @@ type CreateConditionGenderParams struct {
 }
 
 func (q *Queries) CreateConditionGender(ctx context.Context, arg CreateConditionGenderParams) error {
-	_, err := q.db.ExecContext(ctx, createConditionGender, arg.ConditionID, arg.Gender)
+	_, err := q.db.ExecContext(ctx, CreateConditionGender, arg.ConditionID, arg.Gender)
+	return err
+}
+
+const CreateConditionGeofence = `-- name: CreateConditionGeofence :execlastid
+INSERT INTO cond_geofence (cond_id, lat, lng, radius)
+VALUES (
+        ?,
+        ?,
+        ?,
+        ?
+    )
+`
+
+type CreateConditionGeofenceParams struct {
+	ConditionID int32   `json:"condition_id"`
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+	Radius      int32   `json:"radius"`
+}
+
+func (q *Queries) CreateConditionGeofence(ctx context.Context, arg CreateConditionGeofenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, CreateConditionGeofence,
+		arg.ConditionID,
+		arg.Lat,
+		arg.Lng,
+		arg.Radius,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const CreateConditionGeofenceCell = `-- name: CreateConditionGeofenceCell :exec
+INSERT INTO cond_geofence_cell (geofence_id, geohash)
+VALUES (
+        ?,
+        ?
+    )
+`
+
+type CreateConditionGeofenceCellParams struct {
+	GeofenceID int32  `json:"geofence_id"`
+	Geohash    string `json:"geohash"`
+}
+
+func (q *Queries) CreateConditionGeofenceCell(ctx context.Context, arg CreateConditionGeofenceCellParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionGeofenceCell, arg.GeofenceID, arg.Geohash)
+	return err
+}
+
+const CreateConditionKeyword = `-- name: CreateConditionKeyword :exec
+INSERT INTO cond_keyword (cond_id, term)
+VALUES (
+        ?,
+        ?
+    )
+`
+
+type CreateConditionKeywordParams struct {
+	ConditionID int32  `json:"condition_id"`
+	Term        string `json:"term"`
+}
+
+func (q *Queries) CreateConditionKeyword(ctx context.Context, arg CreateConditionKeywordParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionKeyword, arg.ConditionID, arg.Term)
 	return err
 }
 
-const createConditionPlatform = `-- name: CreateConditionPlatform :exec
+const CreateConditionPlatform = `-- name: CreateConditionPlatform :exec
 INSERT INTO cond_platform (cond_id, platform_id)
 VALUES (
         ?,
@@ -135,113 +547,853 @@ type CreateConditionPlatformParams struct {
 }
 
 func (q *Queries) CreateConditionPlatform(ctx context.Context, arg CreateConditionPlatformParams) error {
-	_, err := q.db.ExecContext(ctx, createConditionPlatform, arg.ConditionID, arg.Platform)
+	_, err := q.db.ExecContext(ctx, CreateConditionPlatform, arg.ConditionID, arg.Platform)
 	return err
 }
 
-const getActiveAdvertisements = `-- name: GetActiveAdvertisements :many
-SELECT DISTINCT adv.id,
-    adv.title,
-    adv.start_at,
-    adv.end_at
-FROM advertisement adv
-    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
-    LEFT JOIN cond ON adc.cond_id = cond.id
-    LEFT JOIN cond_gender ON cond.id = cond_gender.cond_id
-    LEFT JOIN gender ON cond_gender.gender_id = gender.id
-    LEFT JOIN cond_country ON cond.id = cond_country.cond_id
-    LEFT JOIN country ON cond_country.country_id = country.id
-    LEFT JOIN cond_platform ON cond.id = cond_platform.cond_id
-    LEFT JOIN platform ON cond_platform.platform_id = platform.id
-WHERE (
-        ? IS NULL
-        OR (
-            (
-                cond.age_start IS NULL
-                OR cond.age_start <= ?
-            )
-            AND (
-                cond.age_end IS NULL
-                OR cond.age_end >= ?
-            )
-        )
-    )
-    AND (
-        ? IS NULL
-        OR gender.code = ?
-        OR cond_gender.cond_id IS NULL
-    )
-    AND (
-        ? IS NULL
-        OR country.code = ?
-        OR cond_country.cond_id IS NULL
-    )
-    AND (
-        ? IS NULL
-        OR platform.name = ?
-        OR cond_platform.cond_id IS NULL
+const CreateConditionRegion = `-- name: CreateConditionRegion :exec
+INSERT INTO cond_region (cond_id, code, country)
+VALUES (
+        ?,
+        ?,
+        ?
     )
-    OR adc.id IS NULL
-ORDER BY end_at ASC
-LIMIT ?, ?
 `
 
-type GetActiveAdvertisementsParams struct {
-	Age      sql.NullInt32  `json:"age"`
-	Gender   sql.NullString `json:"gender"`
-	Country  sql.NullString `json:"country"`
-	Platform sql.NullString `json:"platform"`
-	Offset   int32          `json:"offset"`
-	Limit    int32          `json:"limit"`
+type CreateConditionRegionParams struct {
+	ConditionID int32  `json:"condition_id"`
+	Region      string `json:"region"`
+	Country     string `json:"country"`
 }
 
-func (q *Queries) GetActiveAdvertisements(ctx context.Context, arg GetActiveAdvertisementsParams) ([]Advertisement, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveAdvertisements,
-		arg.Age,
-		arg.Age,
-		arg.Age,
-		arg.Gender,
-		arg.Gender,
-		arg.Country,
-		arg.Country,
-		arg.Platform,
-		arg.Platform,
-		arg.Offset,
-		arg.Limit,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Advertisement
-	for rows.Next() {
-		var i Advertisement
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.StartAt,
-			&i.EndAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateConditionRegion(ctx context.Context, arg CreateConditionRegionParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionRegion, arg.ConditionID, arg.Region, arg.Country)
+	return err
 }
 
-const getAllCountries = `-- name: GetAllCountries :many
-SELECT code
-FROM country
+const CreateConditionSegment = `-- name: CreateConditionSegment :exec
+INSERT INTO cond_segment (cond_id, segment_id)
+VALUES (
+        ?,
+        (
+            SELECT id
+            FROM segment
+            WHERE code = ?
+        )
+    )
 `
 
-func (q *Queries) GetAllCountries(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getAllCountries)
+type CreateConditionSegmentParams struct {
+	ConditionID int32  `json:"condition_id"`
+	Segment     string `json:"segment"`
+}
+
+func (q *Queries) CreateConditionSegment(ctx context.Context, arg CreateConditionSegmentParams) error {
+	_, err := q.db.ExecContext(ctx, CreateConditionSegment, arg.ConditionID, arg.Segment)
+	return err
+}
+
+const CreateCountryGroupMember = `-- name: CreateCountryGroupMember :exec
+INSERT INTO country_group_member (country_group_id, country_id)
+VALUES (
+        (
+            SELECT id
+            FROM country_group
+            WHERE country_group.code = ?
+        ),
+        (
+            SELECT id
+            FROM country
+            WHERE country.code = ?
+        )
+    )
+`
+
+type CreateCountryGroupMemberParams struct {
+	CountryGroup string `json:"country_group"`
+	Country      string `json:"country"`
+}
+
+func (q *Queries) CreateCountryGroupMember(ctx context.Context, arg CreateCountryGroupMemberParams) error {
+	_, err := q.db.ExecContext(ctx, CreateCountryGroupMember, arg.CountryGroup, arg.Country)
+	return err
+}
+
+const DeleteCountryGroup = `-- name: DeleteCountryGroup :execrows
+DELETE FROM country_group
+WHERE code = ?
+    AND builtin = false
+`
+
+func (q *Queries) DeleteCountryGroup(ctx context.Context, code string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, DeleteCountryGroup, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteCountryGroupMembers = `-- name: DeleteCountryGroupMembers :exec
+DELETE FROM country_group_member
+WHERE country_group_id = (
+        SELECT id
+        FROM country_group
+        WHERE code = ?
+    )
+`
+
+func (q *Queries) DeleteCountryGroupMembers(ctx context.Context, code string) error {
+	_, err := q.db.ExecContext(ctx, DeleteCountryGroupMembers, code)
+	return err
+}
+
+const DeleteSegment = `-- name: DeleteSegment :execrows
+DELETE FROM segment
+WHERE code = ?
+`
+
+func (q *Queries) DeleteSegment(ctx context.Context, code string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, DeleteSegment, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ExportAdvertisements = `-- name: ExportAdvertisements :many
+SELECT adv.id,
+    adv.title,
+    adv.start_at,
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(
+                        JSON_OBJECT(
+                            'name',
+                            v.name,
+                            'weight',
+                            v.weight,
+                            'title',
+                            v.title,
+                            'creative',
+                            JSON_OBJECT(
+                                'description',
+                                v.description,
+                                'imageUrl',
+                                v.image_url,
+                                'imageWidth',
+                                v.image_width,
+                                'imageHeight',
+                                v.image_height,
+                                'landingUrl',
+                                v.landing_url,
+                                'ctaLabel',
+                                v.cta_label,
+                                'format',
+                                v.format
+                            )
+                        )
+                    )
+                FROM advertisement_variant v
+                WHERE v.advertisement_id = adv.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS variants,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_OBJECTAGG(
+                        locale.code,
+                        JSON_OBJECT(
+                            'title',
+                            l.title,
+                            'description',
+                            l.description,
+                            'ctaLabel',
+                            l.cta_label
+                        )
+                    )
+                FROM advertisement_localization l
+                    JOIN locale ON l.locale_id = locale.id
+                WHERE l.advertisement_id = adv.id
+            ),
+            JSON_OBJECT()
+        ) AS JSON
+    ) AS localizations,
+    cond.id AS cond_id,
+    cond.age_start,
+    cond.age_end,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(gender.code)
+                FROM cond_gender
+                    JOIN gender ON cond_gender.gender_id = gender.id
+                WHERE cond_gender.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS genders,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(country.code)
+                FROM cond_country
+                    JOIN country ON cond_country.country_id = country.id
+                WHERE cond_country.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS countries,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(country_group.code)
+                FROM cond_country_group
+                    JOIN country_group ON cond_country_group.country_group_id = country_group.id
+                WHERE cond_country_group.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS country_groups,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_region.code)
+                FROM cond_region
+                WHERE cond_region.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS regions,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(
+                        JSON_OBJECT('lat', g.lat, 'lng', g.lng, 'radius', g.radius)
+                    )
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS geofences,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(platform.name)
+                FROM cond_platform
+                    JOIN platform ON cond_platform.platform_id = platform.id
+                WHERE cond_platform.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS platforms,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(segment.code)
+                FROM cond_segment
+                    JOIN segment ON cond_segment.segment_id = segment.id
+                WHERE cond_segment.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS segments,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_keyword.term)
+                FROM cond_keyword
+                WHERE cond_keyword.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS keywords,
+    CAST(
+        COALESCE(
+            (
+                SELECT JSON_ARRAYAGG(cond_category.term)
+                FROM cond_category
+                WHERE cond_category.cond_id = cond.id
+            ),
+            JSON_ARRAY()
+        ) AS JSON
+    ) AS categories
+FROM advertisement adv
+    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    LEFT JOIN cond ON adc.cond_id = cond.id
+WHERE (
+        ? IS NULL
+        OR adv.status = ?
+    )
+    AND (
+        ? IS NULL
+        OR adv.end_at >= ?
+    )
+    AND (
+        ? IS NULL
+        OR adv.start_at <= ?
+    )
+ORDER BY adv.id ASC,
+    cond.id ASC
+`
+
+type ExportAdvertisementsParams struct {
+	Status      sql.NullString `json:"status"`
+	WindowStart sql.NullTime   `json:"window_start"`
+	WindowEnd   sql.NullTime   `json:"window_end"`
+}
+
+type ExportAdvertisementsRow struct {
+	ID            int32           `json:"id"`
+	Title         string          `json:"title"`
+	StartAt       time.Time       `json:"start_at"`
+	EndAt         time.Time       `json:"end_at"`
+	Status        string          `json:"status"`
+	Description   string          `json:"description"`
+	ImageUrl      string          `json:"image_url"`
+	ImageWidth    int32           `json:"image_width"`
+	ImageHeight   int32           `json:"image_height"`
+	LandingUrl    string          `json:"landing_url"`
+	CtaLabel      string          `json:"cta_label"`
+	Format        string          `json:"format"`
+	Variants      json.RawMessage `json:"variants"`
+	Localizations json.RawMessage `json:"localizations"`
+	CondID        sql.NullInt32   `json:"cond_id"`
+	AgeStart      sql.NullInt32   `json:"age_start"`
+	AgeEnd        sql.NullInt32   `json:"age_end"`
+	Genders       json.RawMessage `json:"genders"`
+	Countries     json.RawMessage `json:"countries"`
+	CountryGroups json.RawMessage `json:"country_groups"`
+	Regions       json.RawMessage `json:"regions"`
+	Geofences     json.RawMessage `json:"geofences"`
+	Platforms     json.RawMessage `json:"platforms"`
+	Segments      json.RawMessage `json:"segments"`
+	Keywords      json.RawMessage `json:"keywords"`
+	Categories    json.RawMessage `json:"categories"`
+}
+
+func (q *Queries) ExportAdvertisements(ctx context.Context, arg ExportAdvertisementsParams) ([]ExportAdvertisementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, ExportAdvertisements,
+		arg.Status,
+		arg.Status,
+		arg.WindowStart,
+		arg.WindowStart,
+		arg.WindowEnd,
+		arg.WindowEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportAdvertisementsRow
+	for rows.Next() {
+		var i ExportAdvertisementsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Status,
+			&i.Description,
+			&i.ImageUrl,
+			&i.ImageWidth,
+			&i.ImageHeight,
+			&i.LandingUrl,
+			&i.CtaLabel,
+			&i.Format,
+			&i.Variants,
+			&i.Localizations,
+			&i.CondID,
+			&i.AgeStart,
+			&i.AgeEnd,
+			&i.Genders,
+			&i.Countries,
+			&i.CountryGroups,
+			&i.Regions,
+			&i.Geofences,
+			&i.Platforms,
+			&i.Segments,
+			&i.Keywords,
+			&i.Categories,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetActiveAdvertisements = `-- name: GetActiveAdvertisements :many
+SELECT DISTINCT adv.id,
+    adv.title,
+    adv.start_at,
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format
+FROM advertisement adv
+    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    LEFT JOIN cond ON adc.cond_id = cond.id
+    LEFT JOIN cond_gender ON cond.id = cond_gender.cond_id
+    LEFT JOIN gender ON cond_gender.gender_id = gender.id
+    LEFT JOIN cond_country ON cond.id = cond_country.cond_id
+    LEFT JOIN country ON cond_country.country_id = country.id
+    LEFT JOIN cond_platform ON cond.id = cond_platform.cond_id
+    LEFT JOIN platform ON cond_platform.platform_id = platform.id
+WHERE adv.status = 'active'
+    AND adv.start_at <= UTC_TIMESTAMP()
+    AND adv.end_at >= UTC_TIMESTAMP()
+    AND (
+        (
+            ? IS NULL
+            OR (
+                (
+                    cond.age_start IS NULL
+                    OR cond.age_start <= ?
+                )
+                AND (
+                    cond.age_end IS NULL
+                    OR cond.age_end >= ?
+                )
+            )
+        )
+        AND (
+            ? IS NULL
+            OR gender.code = ?
+            OR cond_gender.cond_id IS NULL
+        )
+        AND (
+            ? IS NULL
+            OR country.code = ?
+            OR EXISTS (
+                SELECT 1
+                FROM cond_country_group ccg
+                    JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+                    JOIN country group_country ON cgm.country_id = group_country.id
+                WHERE ccg.cond_id = cond.id
+                    AND group_country.code = ?
+            )
+            OR (
+                cond_country.cond_id IS NULL
+                AND NOT EXISTS (
+                    SELECT 1
+                    FROM cond_country_group ccg
+                    WHERE ccg.cond_id = cond.id
+                )
+            )
+        )
+        AND (
+            ? IS NULL
+            OR platform.name = ?
+            OR cond_platform.cond_id IS NULL
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+            )
+            OR ? IS NULL
+            OR EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+                    AND (
+                        r.code = ?
+                        OR (
+                            ? IS NULL
+                            AND r.country = ?
+                        )
+                    )
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+                    AND g.id IN (/*SLICE:geofence_ids*/?)
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                WHERE cs.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                    JOIN segment s ON cs.segment_id = s.id
+                WHERE cs.cond_id = cond.id
+                    AND s.code IN (/*SLICE:segments*/?)
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+                    AND ck.term IN (/*SLICE:keywords*/?)
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+                    AND cc.term = ?
+            )
+        )
+        OR adc.id IS NULL
+    )
+ORDER BY end_at ASC
+LIMIT ?, ?
+`
+
+type GetActiveAdvertisementsParams struct {
+	Age         sql.NullInt32  `json:"age"`
+	Gender      sql.NullString `json:"gender"`
+	Country     sql.NullString `json:"country"`
+	Platform    sql.NullString `json:"platform"`
+	Region      sql.NullString `json:"region"`
+	GeofenceIds []int32        `json:"geofence_ids"`
+	Segments    []string       `json:"segments"`
+	Keywords    []string       `json:"keywords"`
+	Category    sql.NullString `json:"category"`
+	Offset      int32          `json:"offset"`
+	Limit       int32          `json:"limit"`
+}
+
+func (q *Queries) GetActiveAdvertisements(ctx context.Context, arg GetActiveAdvertisementsParams) ([]Advertisement, error) {
+	query := GetActiveAdvertisements
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.Age)
+	queryParams = append(queryParams, arg.Age)
+	queryParams = append(queryParams, arg.Age)
+	queryParams = append(queryParams, arg.Gender)
+	queryParams = append(queryParams, arg.Gender)
+	queryParams = append(queryParams, arg.Country)
+	queryParams = append(queryParams, arg.Country)
+	queryParams = append(queryParams, arg.Country)
+	queryParams = append(queryParams, arg.Platform)
+	queryParams = append(queryParams, arg.Platform)
+	queryParams = append(queryParams, arg.Country)
+	queryParams = append(queryParams, arg.Region)
+	queryParams = append(queryParams, arg.Region)
+	queryParams = append(queryParams, arg.Country)
+	if len(arg.GeofenceIds) > 0 {
+		for _, v := range arg.GeofenceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:geofence_ids*/?", strings.Repeat(",?", len(arg.GeofenceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:geofence_ids*/?", "NULL", 1)
+	}
+	if len(arg.Segments) > 0 {
+		for _, v := range arg.Segments {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:segments*/?", strings.Repeat(",?", len(arg.Segments))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:segments*/?", "NULL", 1)
+	}
+	if len(arg.Keywords) > 0 {
+		for _, v := range arg.Keywords {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:keywords*/?", strings.Repeat(",?", len(arg.Keywords))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:keywords*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Offset)
+	queryParams = append(queryParams, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Advertisement
+	for rows.Next() {
+		var i Advertisement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Status,
+			&i.Description,
+			&i.ImageUrl,
+			&i.ImageWidth,
+			&i.ImageHeight,
+			&i.LandingUrl,
+			&i.CtaLabel,
+			&i.Format,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAdvertisementLocalizations = `-- name: GetAdvertisementLocalizations :many
+SELECT l.advertisement_id,
+    locale.code AS locale,
+    l.title,
+    l.description,
+    l.cta_label
+FROM advertisement_localization l
+    JOIN locale ON l.locale_id = locale.id
+WHERE l.advertisement_id IN (/*SLICE:advertisement_ids*/?)
+    AND locale.code IN (/*SLICE:locales*/?)
+`
+
+type GetAdvertisementLocalizationsParams struct {
+	AdvertisementIds []int32  `json:"advertisement_ids"`
+	Locales          []string `json:"locales"`
+}
+
+type GetAdvertisementLocalizationsRow struct {
+	AdvertisementID int32  `json:"advertisement_id"`
+	Locale          string `json:"locale"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	CtaLabel        string `json:"cta_label"`
+}
+
+func (q *Queries) GetAdvertisementLocalizations(ctx context.Context, arg GetAdvertisementLocalizationsParams) ([]GetAdvertisementLocalizationsRow, error) {
+	query := GetAdvertisementLocalizations
+	var queryParams []interface{}
+	if len(arg.AdvertisementIds) > 0 {
+		for _, v := range arg.AdvertisementIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:advertisement_ids*/?", strings.Repeat(",?", len(arg.AdvertisementIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:advertisement_ids*/?", "NULL", 1)
+	}
+	if len(arg.Locales) > 0 {
+		for _, v := range arg.Locales {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:locales*/?", strings.Repeat(",?", len(arg.Locales))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:locales*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAdvertisementLocalizationsRow
+	for rows.Next() {
+		var i GetAdvertisementLocalizationsRow
+		if err := rows.Scan(
+			&i.AdvertisementID,
+			&i.Locale,
+			&i.Title,
+			&i.Description,
+			&i.CtaLabel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAdvertisementStatus = `-- name: GetAdvertisementStatus :one
+SELECT status,
+    start_at
+FROM advertisement
+WHERE id = ?
+`
+
+type GetAdvertisementStatusRow struct {
+	Status  string    `json:"status"`
+	StartAt time.Time `json:"start_at"`
+}
+
+func (q *Queries) GetAdvertisementStatus(ctx context.Context, id int32) (GetAdvertisementStatusRow, error) {
+	row := q.db.QueryRowContext(ctx, GetAdvertisementStatus, id)
+	var i GetAdvertisementStatusRow
+	err := row.Scan(&i.Status, &i.StartAt)
+	return i, err
+}
+
+const GetAdvertisementVariants = `-- name: GetAdvertisementVariants :many
+SELECT id, advertisement_id, name, weight, title, description, image_url, image_width, image_height, landing_url, cta_label, format
+FROM advertisement_variant
+WHERE advertisement_id IN (/*SLICE:advertisement_ids*/?)
+ORDER BY advertisement_id ASC,
+    id ASC
+`
+
+func (q *Queries) GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]AdvertisementVariant, error) {
+	query := GetAdvertisementVariants
+	var queryParams []interface{}
+	if len(advertisementIds) > 0 {
+		for _, v := range advertisementIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:advertisement_ids*/?", strings.Repeat(",?", len(advertisementIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:advertisement_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdvertisementVariant
+	for rows.Next() {
+		var i AdvertisementVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdvertisementID,
+			&i.Name,
+			&i.Weight,
+			&i.Title,
+			&i.Description,
+			&i.ImageUrl,
+			&i.ImageWidth,
+			&i.ImageHeight,
+			&i.LandingUrl,
+			&i.CtaLabel,
+			&i.Format,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAllCategories = `-- name: GetAllCategories :many
+SELECT DISTINCT cond_category.term
+FROM cond_category
+    JOIN advertisement_cond adc ON cond_category.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived'
+`
+
+func (q *Queries) GetAllCategories(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		items = append(items, term)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAllCountries = `-- name: GetAllCountries :many
+SELECT code
+FROM country
+WHERE retired = false
+`
+
+func (q *Queries) GetAllCountries(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllCountries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		items = append(items, code)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAllCountryGroups = `-- name: GetAllCountryGroups :many
+SELECT code
+FROM country_group
+`
+
+func (q *Queries) GetAllCountryGroups(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllCountryGroups)
 	if err != nil {
 		return nil, err
 	}
@@ -263,13 +1415,73 @@ func (q *Queries) GetAllCountries(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
-const getAllGenders = `-- name: GetAllGenders :many
+const GetAllGenders = `-- name: GetAllGenders :many
 SELECT code
 FROM gender
+WHERE retired = false
 `
 
 func (q *Queries) GetAllGenders(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getAllGenders)
+	rows, err := q.db.QueryContext(ctx, GetAllGenders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		items = append(items, code)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAllKeywords = `-- name: GetAllKeywords :many
+SELECT DISTINCT cond_keyword.term
+FROM cond_keyword
+    JOIN advertisement_cond adc ON cond_keyword.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived'
+`
+
+func (q *Queries) GetAllKeywords(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllKeywords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		items = append(items, term)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetAllLocales = `-- name: GetAllLocales :many
+SELECT code
+FROM locale
+`
+
+func (q *Queries) GetAllLocales(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllLocales)
 	if err != nil {
 		return nil, err
 	}
@@ -291,13 +1503,14 @@ func (q *Queries) GetAllGenders(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
-const getAllPlatforms = `-- name: GetAllPlatforms :many
+const GetAllPlatforms = `-- name: GetAllPlatforms :many
 SELECT name
 FROM platform
+WHERE retired = false
 `
 
 func (q *Queries) GetAllPlatforms(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPlatforms)
+	rows, err := q.db.QueryContext(ctx, GetAllPlatforms)
 	if err != nil {
 		return nil, err
 	}
@@ -318,3 +1531,401 @@ func (q *Queries) GetAllPlatforms(ctx context.Context) ([]string, error) {
 	}
 	return items, nil
 }
+
+const GetAllSegments = `-- name: GetAllSegments :many
+SELECT code
+FROM segment
+`
+
+func (q *Queries) GetAllSegments(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, GetAllSegments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		items = append(items, code)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetCountryGroupBuiltin = `-- name: GetCountryGroupBuiltin :one
+SELECT builtin
+FROM country_group
+WHERE code = ?
+`
+
+func (q *Queries) GetCountryGroupBuiltin(ctx context.Context, code string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, GetCountryGroupBuiltin, code)
+	var builtin bool
+	err := row.Scan(&builtin)
+	return builtin, err
+}
+
+const ListAdvertisements = `-- name: ListAdvertisements :many
+SELECT id, title, start_at, end_at, status, description, image_url, image_width, image_height, landing_url, cta_label, format
+FROM advertisement
+WHERE (
+        ? IS NULL
+        OR status = ?
+    )
+ORDER BY id ASC
+LIMIT ?, ?
+`
+
+type ListAdvertisementsParams struct {
+	Status sql.NullString `json:"status"`
+	Offset int32          `json:"offset"`
+	Limit  int32          `json:"limit"`
+}
+
+func (q *Queries) ListAdvertisements(ctx context.Context, arg ListAdvertisementsParams) ([]Advertisement, error) {
+	rows, err := q.db.QueryContext(ctx, ListAdvertisements,
+		arg.Status,
+		arg.Status,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Advertisement
+	for rows.Next() {
+		var i Advertisement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Status,
+			&i.Description,
+			&i.ImageUrl,
+			&i.ImageWidth,
+			&i.ImageHeight,
+			&i.LandingUrl,
+			&i.CtaLabel,
+			&i.Format,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCountryGroupMembers = `-- name: ListCountryGroupMembers :many
+SELECT country_group.code,
+    country_group.name,
+    country_group.builtin,
+    country.code AS country
+FROM country_group
+    LEFT JOIN country_group_member cgm ON country_group.id = cgm.country_group_id
+    LEFT JOIN country ON cgm.country_id = country.id
+ORDER BY country_group.code,
+    country.code
+`
+
+type ListCountryGroupMembersRow struct {
+	Code    string         `json:"code"`
+	Name    string         `json:"name"`
+	Builtin bool           `json:"builtin"`
+	Country sql.NullString `json:"country"`
+}
+
+func (q *Queries) ListCountryGroupMembers(ctx context.Context) ([]ListCountryGroupMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, ListCountryGroupMembers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCountryGroupMembersRow
+	for rows.Next() {
+		var i ListCountryGroupMembersRow
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.Builtin,
+			&i.Country,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSegments = `-- name: ListSegments :many
+SELECT segment.code,
+    segment.name,
+    COUNT(cond_segment.id) AS conditions
+FROM segment
+    LEFT JOIN cond_segment ON segment.id = cond_segment.segment_id
+GROUP BY segment.id,
+    segment.code,
+    segment.name
+ORDER BY segment.code
+`
+
+type ListSegmentsRow struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Conditions int64  `json:"conditions"`
+}
+
+func (q *Queries) ListSegments(ctx context.Context) ([]ListSegmentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, ListSegments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSegmentsRow
+	for rows.Next() {
+		var i ListSegmentsRow
+		if err := rows.Scan(&i.Code, &i.Name, &i.Conditions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ResolveGeofences = `-- name: ResolveGeofences :many
+SELECT DISTINCT g.id
+FROM cond_geofence_cell cell
+    JOIN cond_geofence g ON cell.geofence_id = g.id
+    JOIN advertisement_cond adc ON g.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE cell.geohash IN (
+        SUBSTRING(?, 1, 2),
+        SUBSTRING(?, 1, 3),
+        SUBSTRING(?, 1, 4),
+        SUBSTRING(?, 1, 5),
+        SUBSTRING(?, 1, 6)
+    )
+    AND ((? - g.lat) * (? - g.lat) + (? - g.lng) * (? - g.lng) * ?) * 111195 * 111195 <= 1.0 * g.radius * g.radius
+    AND adv.status = 'active'
+ORDER BY g.id
+`
+
+type ResolveGeofencesParams struct {
+	Geohash  string  `json:"geohash"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	LngScale float64 `json:"lng_scale"`
+}
+
+func (q *Queries) ResolveGeofences(ctx context.Context, arg ResolveGeofencesParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, ResolveGeofences,
+		arg.Geohash,
+		arg.Geohash,
+		arg.Geohash,
+		arg.Geohash,
+		arg.Geohash,
+		arg.Lat,
+		arg.Lat,
+		arg.Lng,
+		arg.Lng,
+		arg.LngScale,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const RetireCountry = `-- name: RetireCountry :execrows
+UPDATE country
+SET retired = true
+WHERE code = ?
+    AND retired = false
+`
+
+func (q *Queries) RetireCountry(ctx context.Context, code string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, RetireCountry, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RetireGender = `-- name: RetireGender :execrows
+UPDATE gender
+SET retired = true
+WHERE code = ?
+    AND retired = false
+`
+
+func (q *Queries) RetireGender(ctx context.Context, code string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, RetireGender, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RetirePlatform = `-- name: RetirePlatform :execrows
+UPDATE platform
+SET retired = true
+WHERE name = ?
+    AND retired = false
+`
+
+func (q *Queries) RetirePlatform(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, RetirePlatform, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const UpdateAdvertisementStatus = `-- name: UpdateAdvertisementStatus :execrows
+UPDATE advertisement
+SET status = ?
+WHERE id = ?
+    AND status = ?
+`
+
+type UpdateAdvertisementStatusParams struct {
+	Status        string `json:"status"`
+	ID            int32  `json:"id"`
+	CurrentStatus string `json:"current_status"`
+}
+
+func (q *Queries) UpdateAdvertisementStatus(ctx context.Context, arg UpdateAdvertisementStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, UpdateAdvertisementStatus, arg.Status, arg.ID, arg.CurrentStatus)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const UpsertCountry = `-- name: UpsertCountry :exec
+INSERT INTO country (code, name)
+VALUES (
+        ?,
+        ?
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name),
+    retired = false
+`
+
+type UpsertCountryParams struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) UpsertCountry(ctx context.Context, arg UpsertCountryParams) error {
+	_, err := q.db.ExecContext(ctx, UpsertCountry, arg.Code, arg.Name)
+	return err
+}
+
+const UpsertCountryGroup = `-- name: UpsertCountryGroup :exec
+INSERT INTO country_group (code, name)
+VALUES (
+        ?,
+        ?
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name)
+`
+
+type UpsertCountryGroupParams struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) UpsertCountryGroup(ctx context.Context, arg UpsertCountryGroupParams) error {
+	_, err := q.db.ExecContext(ctx, UpsertCountryGroup, arg.Code, arg.Name)
+	return err
+}
+
+const UpsertGender = `-- name: UpsertGender :exec
+INSERT INTO gender (code, name)
+VALUES (
+        ?,
+        ?
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name),
+    retired = false
+`
+
+type UpsertGenderParams struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) UpsertGender(ctx context.Context, arg UpsertGenderParams) error {
+	_, err := q.db.ExecContext(ctx, UpsertGender, arg.Code, arg.Name)
+	return err
+}
+
+const UpsertPlatform = `-- name: UpsertPlatform :exec
+INSERT INTO platform (name)
+VALUES (
+        ?
+    ) ON DUPLICATE KEY UPDATE retired = false
+`
+
+func (q *Queries) UpsertPlatform(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, UpsertPlatform, name)
+	return err
+}
+
+const UpsertSegment = `-- name: UpsertSegment :exec
+INSERT INTO segment (code, name)
+VALUES (
+        ?,
+        ?
+    ) ON DUPLICATE KEY UPDATE name = VALUES(name)
+`
+
+type UpsertSegmentParams struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) UpsertSegment(ctx context.Context, arg UpsertSegmentParams) error {
+	_, err := q.db.ExecContext(ctx, UpsertSegment, arg.Code, arg.Name)
+	return err
+}
@@ -0,0 +1,834 @@
+--
+-- name: GetActiveAdvertisements :many
+SELECT DISTINCT adv.id,
+    adv.title,
+    adv.start_at,
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format
+FROM advertisement adv
+    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    LEFT JOIN cond ON adc.cond_id = cond.id
+    LEFT JOIN cond_gender ON cond.id = cond_gender.cond_id
+    LEFT JOIN gender ON cond_gender.gender_id = gender.id
+    LEFT JOIN cond_country ON cond.id = cond_country.cond_id
+    LEFT JOIN country ON cond_country.country_id = country.id
+    LEFT JOIN cond_platform ON cond.id = cond_platform.cond_id
+    LEFT JOIN platform ON cond_platform.platform_id = platform.id
+WHERE adv.status = 'active'
+    AND adv.start_at <= strftime('%Y-%m-%d %H:%M:%f', 'now')
+    AND adv.end_at >= strftime('%Y-%m-%d %H:%M:%f', 'now')
+    AND (
+        (
+            CAST(sqlc.narg(age) AS INTEGER) IS NULL
+            OR (
+                (
+                    cond.age_start IS NULL
+                    OR cond.age_start <= CAST(sqlc.narg(age) AS INTEGER)
+                )
+                AND (
+                    cond.age_end IS NULL
+                    OR cond.age_end >= CAST(sqlc.narg(age) AS INTEGER)
+                )
+            )
+        )
+        AND (
+            CAST(sqlc.narg(gender) AS TEXT) IS NULL
+            OR gender.code = CAST(sqlc.narg(gender) AS TEXT)
+            OR cond_gender.cond_id IS NULL
+        )
+        AND (
+            CAST(sqlc.narg(country) AS TEXT) IS NULL
+            OR country.code = CAST(sqlc.narg(country) AS TEXT)
+            OR EXISTS (
+                SELECT 1
+                FROM cond_country_group ccg
+                    JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+                    JOIN country group_country ON cgm.country_id = group_country.id
+                WHERE ccg.cond_id = cond.id
+                    AND group_country.code = CAST(sqlc.narg(country) AS TEXT)
+            )
+            OR (
+                cond_country.cond_id IS NULL
+                AND NOT EXISTS (
+                    SELECT 1
+                    FROM cond_country_group ccg
+                    WHERE ccg.cond_id = cond.id
+                )
+            )
+        )
+        AND (
+            CAST(sqlc.narg(platform) AS TEXT) IS NULL
+            OR platform.name = CAST(sqlc.narg(platform) AS TEXT)
+            OR cond_platform.cond_id IS NULL
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+            )
+            OR CAST(sqlc.narg(country) AS TEXT) IS NULL
+            OR EXISTS (
+                SELECT 1
+                FROM cond_region r
+                WHERE r.cond_id = cond.id
+                    AND (
+                        r.code = CAST(sqlc.narg(region) AS TEXT)
+                        OR (
+                            CAST(sqlc.narg(region) AS TEXT) IS NULL
+                            AND r.country = CAST(sqlc.narg(country) AS TEXT)
+                        )
+                    )
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_geofence g
+                WHERE g.cond_id = cond.id
+                    AND instr(',' || CAST(sqlc.arg(geofence_ids) AS TEXT) || ',', ',' || g.id || ',') > 0
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                WHERE cs.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_segment cs
+                    JOIN segment s ON cs.segment_id = s.id
+                WHERE cs.cond_id = cond.id
+                    AND instr(',' || CAST(sqlc.arg(segments) AS TEXT) || ',', ',' || s.code || ',') > 0
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_keyword ck
+                WHERE ck.cond_id = cond.id
+                    AND instr(',' || CAST(sqlc.arg(keywords) AS TEXT) || ',', ',' || ck.term || ',') > 0
+            )
+        )
+        AND (
+            NOT EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+            )
+            OR EXISTS (
+                SELECT 1
+                FROM cond_category cc
+                WHERE cc.cond_id = cond.id
+                    AND cc.term = CAST(sqlc.narg(category) AS TEXT)
+            )
+        )
+        OR adc.id IS NULL
+    )
+ORDER BY end_at ASC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+--
+-- name: CreateAdvertisement :execlastid
+INSERT INTO advertisement (
+        title,
+        start_at,
+        end_at,
+        status,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
+VALUES (
+        sqlc.arg(title),
+        sqlc.arg(start_at),
+        sqlc.arg(end_at),
+        sqlc.arg(status),
+        sqlc.arg(description),
+        sqlc.arg(image_url),
+        sqlc.arg(image_width),
+        sqlc.arg(image_height),
+        sqlc.arg(landing_url),
+        sqlc.arg(cta_label),
+        sqlc.arg(format)
+    );
+--
+-- name: CreateCondition :execlastid
+INSERT INTO cond (age_start, age_end)
+VALUES (
+        sqlc.arg(age_start),
+        sqlc.arg(age_end)
+    );
+-- 
+-- name: CreateAdvertisementCondition :exec
+INSERT INTO advertisement_cond (advertisement_id, cond_id)
+VALUES (
+        sqlc.arg(advertisement_id),
+        sqlc.arg(condition_id)
+    );
+--
+-- name: CreateConditionGender :exec
+INSERT INTO cond_gender (cond_id, gender_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM gender
+            WHERE code = sqlc.arg(gender)
+        )
+    );
+--
+-- name: CreateConditionCountry :exec
+INSERT INTO cond_country (cond_id, country_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM country
+            WHERE code = sqlc.arg(country)
+        )
+    );
+--
+-- name: CreateConditionCountryGroup :exec
+INSERT INTO cond_country_group (cond_id, country_group_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM country_group
+            WHERE code = sqlc.arg(country_group)
+        )
+    );
+--
+-- name: CreateConditionRegion :exec
+INSERT INTO cond_region (cond_id, code, country)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(region),
+        sqlc.arg(country)
+    );
+--
+-- name: CreateConditionGeofence :execlastid
+INSERT INTO cond_geofence (cond_id, lat, lng, radius)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(lat),
+        sqlc.arg(lng),
+        sqlc.arg(radius)
+    );
+--
+-- name: CreateConditionGeofenceCell :exec
+INSERT INTO cond_geofence_cell (geofence_id, geohash)
+VALUES (
+        sqlc.arg(geofence_id),
+        sqlc.arg(geohash)
+    );
+--
+-- name: CreateConditionPlatform :exec
+INSERT INTO cond_platform (cond_id, platform_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM platform
+            WHERE name = sqlc.arg(platform)
+        )
+    );
+--
+-- name: CreateConditionSegment :exec
+INSERT INTO cond_segment (cond_id, segment_id)
+VALUES (
+        sqlc.arg(condition_id),
+        (
+            SELECT id
+            FROM segment
+            WHERE code = sqlc.arg(segment)
+        )
+    );
+--
+-- name: CreateConditionKeyword :exec
+INSERT INTO cond_keyword (cond_id, term)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(term)
+    );
+--
+-- name: CreateConditionCategory :exec
+INSERT INTO cond_category (cond_id, term)
+VALUES (
+        sqlc.arg(condition_id),
+        sqlc.arg(term)
+    );
+--
+-- name: GetAllGenders :many
+SELECT code
+FROM gender
+WHERE retired = false;
+--
+-- name: GetAllCountries :many
+SELECT code
+FROM country
+WHERE retired = false;
+--
+-- name: GetAllCountryGroups :many
+SELECT code
+FROM country_group;
+--
+-- name: GetAllSegments :many
+SELECT code
+FROM segment;
+--
+-- name: GetAllKeywords :many
+SELECT DISTINCT cond_keyword.term
+FROM cond_keyword
+    JOIN advertisement_cond adc ON cond_keyword.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived';
+--
+-- name: GetAllCategories :many
+SELECT DISTINCT cond_category.term
+FROM cond_category
+    JOIN advertisement_cond adc ON cond_category.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE adv.status <> 'archived';
+--
+-- name: GetAllPlatforms :many
+SELECT name
+FROM platform
+WHERE retired = false;
+--
+-- name: GetAllLocales :many
+SELECT code
+FROM locale;
+--
+-- name: ExportAdvertisements :many
+SELECT adv.id,
+    adv.title,
+    adv.start_at,
+    adv.end_at,
+    adv.status,
+    adv.description,
+    adv.image_url,
+    adv.image_width,
+    adv.image_height,
+    adv.landing_url,
+    adv.cta_label,
+    adv.format,
+    COALESCE(
+        (
+            SELECT json_group_array(
+                    json_object(
+                        'name',
+                        v.name,
+                        'weight',
+                        v.weight,
+                        'title',
+                        v.title,
+                        'creative',
+                        json_object(
+                            'description',
+                            v.description,
+                            'imageUrl',
+                            v.image_url,
+                            'imageWidth',
+                            v.image_width,
+                            'imageHeight',
+                            v.image_height,
+                            'landingUrl',
+                            v.landing_url,
+                            'ctaLabel',
+                            v.cta_label,
+                            'format',
+                            v.format
+                        )
+                    )
+                )
+            FROM (
+                    SELECT *
+                    FROM advertisement_variant
+                    WHERE advertisement_id = adv.id
+                    ORDER BY id
+                ) v
+        ),
+        '[]'
+    ) AS variants,
+    COALESCE(
+        (
+            SELECT json_group_object(
+                    locale.code,
+                    json_object(
+                        'title',
+                        l.title,
+                        'description',
+                        l.description,
+                        'ctaLabel',
+                        l.cta_label
+                    )
+                )
+            FROM advertisement_localization l
+                JOIN locale ON l.locale_id = locale.id
+            WHERE l.advertisement_id = adv.id
+        ),
+        '{}'
+    ) AS localizations,
+    cond.id AS cond_id,
+    cond.age_start,
+    cond.age_end,
+    COALESCE(
+        (
+            SELECT json_group_array(gender.code)
+            FROM (
+                    SELECT gender.code
+                    FROM cond_gender
+                        JOIN gender ON cond_gender.gender_id = gender.id
+                    WHERE cond_gender.cond_id = cond.id
+                    ORDER BY cond_gender.id
+                ) gender
+        ),
+        '[]'
+    ) AS genders,
+    COALESCE(
+        (
+            SELECT json_group_array(country.code)
+            FROM (
+                    SELECT country.code
+                    FROM cond_country
+                        JOIN country ON cond_country.country_id = country.id
+                    WHERE cond_country.cond_id = cond.id
+                    ORDER BY cond_country.id
+                ) country
+        ),
+        '[]'
+    ) AS countries,
+    COALESCE(
+        (
+            SELECT json_group_array(country_group.code)
+            FROM (
+                    SELECT country_group.code
+                    FROM cond_country_group
+                        JOIN country_group ON cond_country_group.country_group_id = country_group.id
+                    WHERE cond_country_group.cond_id = cond.id
+                    ORDER BY cond_country_group.id
+                ) country_group
+        ),
+        '[]'
+    ) AS country_groups,
+    COALESCE(
+        (
+            SELECT json_group_array(cond_region.code)
+            FROM (
+                    SELECT cond_region.code
+                    FROM cond_region
+                    WHERE cond_region.cond_id = cond.id
+                    ORDER BY cond_region.id
+                ) cond_region
+        ),
+        '[]'
+    ) AS regions,
+    COALESCE(
+        (
+            SELECT json_group_array(
+                    json_object('lat', g.lat, 'lng', g.lng, 'radius', g.radius)
+                )
+            FROM (
+                    SELECT *
+                    FROM cond_geofence
+                    WHERE cond_id = cond.id
+                    ORDER BY id
+                ) g
+        ),
+        '[]'
+    ) AS geofences,
+    COALESCE(
+        (
+            SELECT json_group_array(platform.name)
+            FROM (
+                    SELECT platform.name
+                    FROM cond_platform
+                        JOIN platform ON cond_platform.platform_id = platform.id
+                    WHERE cond_platform.cond_id = cond.id
+                    ORDER BY cond_platform.id
+                ) platform
+        ),
+        '[]'
+    ) AS platforms,
+    COALESCE(
+        (
+            SELECT json_group_array(segment.code)
+            FROM (
+                    SELECT segment.code
+                    FROM cond_segment
+                        JOIN segment ON cond_segment.segment_id = segment.id
+                    WHERE cond_segment.cond_id = cond.id
+                    ORDER BY cond_segment.id
+                ) segment
+        ),
+        '[]'
+    ) AS segments,
+    COALESCE(
+        (
+            SELECT json_group_array(cond_keyword.term)
+            FROM (
+                    SELECT cond_keyword.term
+                    FROM cond_keyword
+                    WHERE cond_keyword.cond_id = cond.id
+                    ORDER BY cond_keyword.id
+                ) cond_keyword
+        ),
+        '[]'
+    ) AS keywords,
+    COALESCE(
+        (
+            SELECT json_group_array(cond_category.term)
+            FROM (
+                    SELECT cond_category.term
+                    FROM cond_category
+                    WHERE cond_category.cond_id = cond.id
+                    ORDER BY cond_category.id
+                ) cond_category
+        ),
+        '[]'
+    ) AS categories
+FROM advertisement adv
+    LEFT JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    LEFT JOIN cond ON adc.cond_id = cond.id
+WHERE (
+        CAST(sqlc.narg(status) AS TEXT) IS NULL
+        OR adv.status = CAST(sqlc.narg(status) AS TEXT)
+    )
+    AND (
+        sqlc.narg(window_start) IS NULL
+        OR adv.end_at >= sqlc.narg(window_start)
+    )
+    AND (
+        sqlc.narg(window_end) IS NULL
+        OR adv.start_at <= sqlc.narg(window_end)
+    )
+ORDER BY adv.id ASC,
+    cond.id ASC;
+--
+-- name: ListAdvertisements :many
+SELECT *
+FROM advertisement
+WHERE (
+        CAST(sqlc.narg(status) AS TEXT) IS NULL
+        OR status = CAST(sqlc.narg(status) AS TEXT)
+    )
+ORDER BY id ASC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+--
+-- name: GetAdvertisementStatus :one
+SELECT status,
+    start_at
+FROM advertisement
+WHERE id = sqlc.arg(id);
+--
+-- name: UpdateAdvertisementStatus :execrows
+UPDATE advertisement
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+    AND status = sqlc.arg(current_status);
+--
+-- name: ActivateScheduledAdvertisements :execrows
+UPDATE advertisement
+SET status = 'active'
+WHERE status = 'scheduled'
+    AND start_at <= sqlc.arg(now);
+--
+-- name: ArchiveExpiredAdvertisements :execrows
+UPDATE advertisement
+SET status = 'archived'
+WHERE status IN ('active', 'paused')
+    AND end_at < sqlc.arg(now);
+--
+-- name: CreateAdvertisementVariant :exec
+INSERT INTO advertisement_variant (
+        advertisement_id,
+        name,
+        weight,
+        title,
+        description,
+        image_url,
+        image_width,
+        image_height,
+        landing_url,
+        cta_label,
+        format
+    )
+VALUES (
+        sqlc.arg(advertisement_id),
+        sqlc.arg(name),
+        sqlc.arg(weight),
+        sqlc.arg(title),
+        sqlc.arg(description),
+        sqlc.arg(image_url),
+        sqlc.arg(image_width),
+        sqlc.arg(image_height),
+        sqlc.arg(landing_url),
+        sqlc.arg(cta_label),
+        sqlc.arg(format)
+    );
+--
+-- name: GetAdvertisementVariants :many
+SELECT *
+FROM advertisement_variant
+WHERE advertisement_id IN (sqlc.slice(advertisement_ids))
+ORDER BY advertisement_id ASC,
+    id ASC;
+--
+-- name: CreateAdvertisementLocalization :exec
+INSERT INTO advertisement_localization (
+        advertisement_id,
+        locale_id,
+        title,
+        description,
+        cta_label
+    )
+VALUES (
+        sqlc.arg(advertisement_id),
+        (
+            SELECT id
+            FROM locale
+            WHERE code = sqlc.arg(locale)
+        ),
+        sqlc.arg(title),
+        sqlc.arg(description),
+        sqlc.arg(cta_label)
+    );
+--
+-- name: GetAdvertisementLocalizations :many
+SELECT l.advertisement_id,
+    locale.code AS locale,
+    l.title,
+    l.description,
+    l.cta_label
+FROM advertisement_localization l
+    JOIN locale ON l.locale_id = locale.id
+WHERE l.advertisement_id IN (sqlc.slice(advertisement_ids))
+    AND locale.code IN (sqlc.slice(locales));
+--
+-- name: UpsertGender :exec
+INSERT INTO gender (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON CONFLICT (code) DO
+UPDATE
+SET name = excluded.name,
+    retired = false;
+--
+-- name: RetireGender :execrows
+UPDATE gender
+SET retired = true
+WHERE code = sqlc.arg(code)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingGender :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_gender ON adc.cond_id = cond_gender.cond_id
+    JOIN gender ON cond_gender.gender_id = gender.id
+WHERE gender.code = sqlc.arg(code)
+    AND adv.status <> 'archived';
+--
+-- name: UpsertCountry :exec
+INSERT INTO country (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON CONFLICT (code) DO
+UPDATE
+SET name = excluded.name,
+    retired = false;
+--
+-- name: RetireCountry :execrows
+UPDATE country
+SET retired = true
+WHERE code = sqlc.arg(code)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingCountry :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN country ON country.code = sqlc.arg(code)
+WHERE adv.status <> 'archived'
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM cond_country
+            WHERE cond_country.cond_id = adc.cond_id
+                AND cond_country.country_id = country.id
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_region
+            WHERE cond_region.cond_id = adc.cond_id
+                AND cond_region.country = country.code
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM cond_country_group ccg
+                JOIN country_group_member cgm ON ccg.country_group_id = cgm.country_group_id
+            WHERE ccg.cond_id = adc.cond_id
+                AND cgm.country_id = country.id
+        )
+    );
+--
+-- name: UpsertPlatform :exec
+INSERT INTO platform (name)
+VALUES (
+        sqlc.arg(name)
+    ) ON CONFLICT (name) DO
+UPDATE
+SET retired = false;
+--
+-- name: RetirePlatform :execrows
+UPDATE platform
+SET retired = true
+WHERE name = sqlc.arg(name)
+    AND retired = false;
+--
+-- name: CountAdvertisementsUsingPlatform :one
+SELECT COUNT(DISTINCT adv.id)
+FROM advertisement adv
+    JOIN advertisement_cond adc ON adv.id = adc.advertisement_id
+    JOIN cond_platform ON adc.cond_id = cond_platform.cond_id
+    JOIN platform ON cond_platform.platform_id = platform.id
+WHERE platform.name = sqlc.arg(name)
+    AND adv.status <> 'archived';
+--
+-- name: ListCountryGroupMembers :many
+SELECT country_group.code,
+    country_group.name,
+    country_group.builtin,
+    country.code AS country
+FROM country_group
+    LEFT JOIN country_group_member cgm ON country_group.id = cgm.country_group_id
+    LEFT JOIN country ON cgm.country_id = country.id
+ORDER BY country_group.code,
+    country.code;
+--
+-- name: GetCountryGroupBuiltin :one
+SELECT builtin
+FROM country_group
+WHERE code = sqlc.arg(code);
+--
+-- name: UpsertCountryGroup :exec
+INSERT INTO country_group (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON CONFLICT (code) DO
+UPDATE
+SET name = excluded.name;
+--
+-- name: DeleteCountryGroupMembers :exec
+DELETE FROM country_group_member
+WHERE country_group_id = (
+        SELECT id
+        FROM country_group
+        WHERE code = sqlc.arg(code)
+    );
+--
+-- name: CreateCountryGroupMember :exec
+INSERT INTO country_group_member (country_group_id, country_id)
+VALUES (
+        (
+            SELECT id
+            FROM country_group
+            WHERE country_group.code = sqlc.arg(country_group)
+        ),
+        (
+            SELECT id
+            FROM country
+            WHERE country.code = sqlc.arg(country)
+        )
+    );
+--
+-- name: DeleteCountryGroup :execrows
+DELETE FROM country_group
+WHERE code = sqlc.arg(code)
+    AND builtin = false;
+--
+-- name: CountConditionsUsingCountryGroup :one
+SELECT COUNT(*)
+FROM cond_country_group
+    JOIN country_group ON cond_country_group.country_group_id = country_group.id
+WHERE country_group.code = sqlc.arg(code);
+--
+-- name: ResolveGeofences :many
+SELECT DISTINCT g.id
+FROM cond_geofence_cell cell
+    JOIN cond_geofence g ON cell.geofence_id = g.id
+    JOIN advertisement_cond adc ON g.cond_id = adc.cond_id
+    JOIN advertisement adv ON adc.advertisement_id = adv.id
+WHERE cell.geohash IN (
+        substr(CAST(sqlc.arg(geohash) AS TEXT), 1, 2),
+        substr(CAST(sqlc.arg(geohash) AS TEXT), 1, 3),
+        substr(CAST(sqlc.arg(geohash) AS TEXT), 1, 4),
+        substr(CAST(sqlc.arg(geohash) AS TEXT), 1, 5),
+        substr(CAST(sqlc.arg(geohash) AS TEXT), 1, 6)
+    )
+    AND ((CAST(sqlc.arg(lat) AS REAL) - g.lat) * (CAST(sqlc.arg(lat) AS REAL) - g.lat) + (CAST(sqlc.arg(lng) AS REAL) - g.lng) * (CAST(sqlc.arg(lng) AS REAL) - g.lng) * CAST(sqlc.arg(lng_scale) AS REAL)) * 111195 * 111195 <= 1.0 * g.radius * g.radius
+    AND adv.status = 'active'
+ORDER BY g.id;
+--
+-- name: ListSegments :many
+SELECT segment.code,
+    segment.name,
+    COUNT(cond_segment.id) AS conditions
+FROM segment
+    LEFT JOIN cond_segment ON segment.id = cond_segment.segment_id
+GROUP BY segment.id,
+    segment.code,
+    segment.name
+ORDER BY segment.code;
+--
+-- name: UpsertSegment :exec
+INSERT INTO segment (code, name)
+VALUES (
+        sqlc.arg(code),
+        sqlc.arg(name)
+    ) ON CONFLICT (code) DO
+UPDATE
+SET name = excluded.name;
+--
+-- name: DeleteSegment :execrows
+DELETE FROM segment
+WHERE code = sqlc.arg(code);
+--
+-- name: CountConditionsUsingSegment :one
+SELECT COUNT(*)
+FROM cond_segment
+    JOIN segment ON cond_segment.segment_id = segment.id
+WHERE segment.code = sqlc.arg(code);
+--
+-- name: CountAdvertisementsByStatus :many
+SELECT status,
+    COUNT(*) AS count
+FROM advertisement
+GROUP BY status
+ORDER BY status;
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lnfu/dcard-intern/app/metrics"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlc 的 DBTX (*sql.DB 或 *sql.Tx) 加上每個 query 的執行時間 (db_query_duration_seconds) 與 span,
+// 三種 dialect 產生的 DBTX 方法相同, 所以可以共用
+type instrumentedDB struct {
+	db     sqlc.DBTX
+	system attribute.KeyValue // db.system (semconv.DBSystemMySQL 等)
+}
+
+func instrument(db sqlc.DBTX, system attribute.KeyValue) instrumentedDB {
+	return instrumentedDB{db, system}
+}
+
+// sqlc 產生的 query 以 "-- name: GetActiveAdvertisements :many" 開頭
+func queryName(query string) string {
+	name, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	if i := strings.IndexByte(name, ' '); i >= 0 {
+		return name[:i]
+	}
+	return "unknown"
+}
+
+// span 的名稱是 query 的名稱 (statement 可能包含展開的 sqlc.slice, 不放進 attributes)
+func (db instrumentedDB) start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(db.system, semconv.DBOperation(name)),
+	)
+}
+
+func (db instrumentedDB) observe(span trace.Span, name string, start time.Time, err error) {
+	metrics.ObserveQuery(name, time.Since(start), err)
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+func (db instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	name := queryName(query)
+	ctx, span := db.start(ctx, name)
+	start := time.Now()
+	result, err := db.db.ExecContext(ctx, query, args...)
+	db.observe(span, name, start, err)
+	return result, err
+}
+
+func (db instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return db.db.PrepareContext(ctx, query)
+}
+
+// 只計算到拿到第一批結果為止 (不包含呼叫端讀取 rows 的時間)
+func (db instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	name := queryName(query)
+	ctx, span := db.start(ctx, name)
+	start := time.Now()
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	db.observe(span, name, start, err)
+	return rows, err
+}
+
+func (db instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	name := queryName(query)
+	ctx, span := db.start(ctx, name)
+	start := time.Now()
+	row := db.db.QueryRowContext(ctx, query, args...)
+	db.observe(span, name, start, row.Err())
+	return row
+}
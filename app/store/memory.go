@@ -0,0 +1,1175 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lnfu/dcard-intern/app/geo"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// 不需要 MySQL 的 advertisement 資料 (-mode test 與測試使用), 行為與 query.sql 中的 queries 相同
+type Memory struct {
+	mu     *sync.Mutex // transaction 中為 nil (外層已經拿到 lock)
+	tables *memoryTables
+}
+
+type memoryTables struct {
+	advertisements          []sqlc.Advertisement
+	variants                []sqlc.AdvertisementVariant
+	localizations           []sqlc.GetAdvertisementLocalizationsRow
+	conditions              []memoryCondition
+	advertisementConditions []sqlc.AdvertisementCond
+	genders                 []memoryReference
+	countries               []memoryReference
+	platforms               []memoryReference
+	countryGroups           []memoryCountryGroup
+	geofences               []memoryGeofence
+	segments                []memorySegment
+}
+
+// gender/country/platform 的一列 (platform 的 code 就是 name)
+type memoryReference struct {
+	code    string
+	name    string
+	retired bool
+}
+
+// country_group 與 country_group_member
+type memoryCountryGroup struct {
+	code    string
+	name    string
+	builtin bool
+	members []string
+}
+
+// segment 的一列
+type memorySegment struct {
+	code string
+	name string
+}
+
+// cond_region 的一列
+type memoryRegion struct {
+	code    string
+	country string
+}
+
+// cond_geofence 與 cond_geofence_cell
+type memoryGeofence struct {
+	id     int32
+	condID int32
+	lat    float64
+	lng    float64
+	radius int32
+	cells  []string
+}
+
+type memoryCondition struct {
+	id            int32
+	ageStart      sql.NullInt32
+	ageEnd        sql.NullInt32
+	genders       []string
+	countries     []string
+	countryGroups []string
+	regions       []memoryRegion
+	geofences     []int32
+	platforms     []string
+	segments      []string
+	keywords      []string
+	categories    []string
+}
+
+var _ sqlc.Querier = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{mu: &sync.Mutex{}, tables: &memoryTables{
+		genders:   newMemoryReferences(seedGenders),
+		countries: newMemoryReferences(seedCountries),
+		platforms: newMemoryReferences(seedPlatforms),
+
+		countryGroups: slices.Clone(seedCountryGroups),
+	}}
+}
+
+func newMemoryReferences(codes []string) []memoryReference {
+	references := make([]memoryReference, len(codes))
+	for i, code := range codes {
+		references[i] = memoryReference{code: code}
+	}
+	return references
+}
+
+func (store *Memory) lock() func() {
+	if store.mu == nil {
+		return func() {}
+	}
+	store.mu.Lock()
+	return store.mu.Unlock
+}
+
+// 在同一個 transaction 中執行 fn, fn 回傳 error 時捨棄所有變更
+func (store *Memory) InTx(ctx context.Context, fn func(sqlc.Querier) error) error {
+	defer store.lock()()
+
+	tx := &Memory{tables: store.tables.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	store.tables = tx.tables
+	return nil
+}
+
+func (tables *memoryTables) clone() *memoryTables {
+	conditions := make([]memoryCondition, len(tables.conditions))
+	for i, condition := range tables.conditions {
+		condition.genders = slices.Clone(condition.genders)
+		condition.countries = slices.Clone(condition.countries)
+		condition.countryGroups = slices.Clone(condition.countryGroups)
+		condition.regions = slices.Clone(condition.regions)
+		condition.geofences = slices.Clone(condition.geofences)
+		condition.platforms = slices.Clone(condition.platforms)
+		condition.segments = slices.Clone(condition.segments)
+		condition.keywords = slices.Clone(condition.keywords)
+		condition.categories = slices.Clone(condition.categories)
+		conditions[i] = condition
+	}
+	return &memoryTables{
+		advertisements:          slices.Clone(tables.advertisements),
+		variants:                slices.Clone(tables.variants),
+		localizations:           slices.Clone(tables.localizations),
+		conditions:              conditions,
+		advertisementConditions: slices.Clone(tables.advertisementConditions),
+		genders:                 slices.Clone(tables.genders),
+		countries:               slices.Clone(tables.countries),
+		platforms:               slices.Clone(tables.platforms),
+		countryGroups:           slices.Clone(tables.countryGroups),
+		geofences:               slices.Clone(tables.geofences),
+		segments:                slices.Clone(tables.segments),
+	}
+}
+
+// 包含已經停用的 (同 CreateCondition* 的 subquery)
+func findReference(references []memoryReference, code string) *memoryReference {
+	i := slices.IndexFunc(references, func(reference memoryReference) bool { return reference.code == code })
+	if i < 0 {
+		return nil
+	}
+	return &references[i]
+}
+
+// 同 GetAll*: 沒有停用的 codes
+func activeReferences(references []memoryReference) []string {
+	codes := make([]string, 0, len(references))
+	for _, reference := range references {
+		if !reference.retired {
+			codes = append(codes, reference.code)
+		}
+	}
+	return codes
+}
+
+// 同 Upsert*: 已經存在時更新 name 並重新啟用
+func upsertReference(references []memoryReference, code string, name string) []memoryReference {
+	if reference := findReference(references, code); reference != nil {
+		reference.name = name
+		reference.retired = false
+		return references
+	}
+	return append(references, memoryReference{code: code, name: name})
+}
+
+// 同 Retire*: 回傳停用的數量
+func retireReference(references []memoryReference, code string) int64 {
+	reference := findReference(references, code)
+	if reference == nil || reference.retired {
+		return 0
+	}
+	reference.retired = true
+	return 1
+}
+
+// 同 CountAdvertisementsUsing*: 使用 value 的廣告數量 (archived 除外)
+func (tables *memoryTables) countAdvertisementsUsing(values func(condition *memoryCondition) []string, value string) int64 {
+	var count int64
+	for _, ad := range tables.advertisements {
+		if ad.Status == "archived" {
+			continue
+		}
+		if slices.ContainsFunc(tables.conditionsOf(ad.ID), func(condition *memoryCondition) bool {
+			return slices.Contains(values(condition), value)
+		}) {
+			count++
+		}
+	}
+	return count
+}
+
+func (tables *memoryTables) countryGroup(code string) *memoryCountryGroup {
+	i := slices.IndexFunc(tables.countryGroups, func(group memoryCountryGroup) bool { return group.code == code })
+	if i < 0 {
+		return nil
+	}
+	return &tables.countryGroups[i]
+}
+
+func (tables *memoryTables) segment(code string) *memorySegment {
+	i := slices.IndexFunc(tables.segments, func(segment memorySegment) bool { return segment.code == code })
+	if i < 0 {
+		return nil
+	}
+	return &tables.segments[i]
+}
+
+func (tables *memoryTables) advertisement(id int32) *sqlc.Advertisement {
+	i, ok := slices.BinarySearchFunc(tables.advertisements, id, func(ad sqlc.Advertisement, id int32) int {
+		return cmp.Compare(ad.ID, id)
+	})
+	if !ok {
+		return nil
+	}
+	return &tables.advertisements[i]
+}
+
+func (tables *memoryTables) condition(id int32) *memoryCondition {
+	i, ok := slices.BinarySearchFunc(tables.conditions, id, func(condition memoryCondition, id int32) int {
+		return cmp.Compare(condition.id, id)
+	})
+	if !ok {
+		return nil
+	}
+	return &tables.conditions[i]
+}
+
+// advertisement 的所有 conditions (依 id 排序)
+func (tables *memoryTables) conditionsOf(advertisementID int32) []*memoryCondition {
+	var conditions []*memoryCondition
+	for _, relation := range tables.advertisementConditions {
+		if relation.AdvertisementID == advertisementID {
+			conditions = append(conditions, tables.condition(relation.CondID))
+		}
+	}
+	slices.SortFunc(conditions, func(a, b *memoryCondition) int { return cmp.Compare(a.id, b.id) })
+	return conditions
+}
+
+// 與 GetActiveAdvertisements 的 WHERE 相同: 沒有設定的條件不限制, country groups 在比對時展開
+func (tables *memoryTables) matches(condition *memoryCondition, arg sqlc.GetActiveAdvertisementsParams) bool {
+	if arg.Age.Valid {
+		if condition.ageStart.Valid && condition.ageStart.Int32 > arg.Age.Int32 {
+			return false
+		}
+		if condition.ageEnd.Valid && condition.ageEnd.Int32 < arg.Age.Int32 {
+			return false
+		}
+	}
+	if arg.Gender.Valid && len(condition.genders) > 0 && !slices.Contains(condition.genders, arg.Gender.String) {
+		return false
+	}
+	if arg.Country.Valid && (len(condition.countries) > 0 || len(condition.countryGroups) > 0) &&
+		!slices.Contains(condition.countries, arg.Country.String) &&
+		!slices.ContainsFunc(condition.countryGroups, func(code string) bool {
+			return slices.Contains(tables.countryGroup(code).members, arg.Country.String)
+		}) {
+		return false
+	}
+	// region 沒有指定時比對 region 所屬的 country
+	if arg.Country.Valid && len(condition.regions) > 0 && !slices.ContainsFunc(condition.regions, func(region memoryRegion) bool {
+		if arg.Region.Valid {
+			return region.code == arg.Region.String
+		}
+		return region.country == arg.Country.String
+	}) {
+		return false
+	}
+	// 有 geofence 的條件只符合 ResolveGeofences 找到的 geofences
+	if len(condition.geofences) > 0 && !slices.ContainsFunc(condition.geofences, func(id int32) bool {
+		return slices.Contains(arg.GeofenceIds, id)
+	}) {
+		return false
+	}
+	if arg.Platform.Valid && len(condition.platforms) > 0 && !slices.Contains(condition.platforms, arg.Platform.String) {
+		return false
+	}
+	// 有 segment 的條件只符合 segments 有交集的請求
+	if len(condition.segments) > 0 && !slices.ContainsFunc(condition.segments, func(code string) bool {
+		return slices.Contains(arg.Segments, code)
+	}) {
+		return false
+	}
+	// keyword/category 同 segment (已經是正規化後的 terms)
+	if len(condition.keywords) > 0 && !slices.ContainsFunc(condition.keywords, func(term string) bool {
+		return slices.Contains(arg.Keywords, term)
+	}) {
+		return false
+	}
+	if len(condition.categories) > 0 && (!arg.Category.Valid || !slices.Contains(condition.categories, arg.Category.String)) {
+		return false
+	}
+	return true
+}
+
+func paginate[T any](items []T, offset int32, limit int32) []T {
+	if int(offset) >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func (store *Memory) ActivateScheduledAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	defer store.lock()()
+
+	var affected int64
+	for i := range store.tables.advertisements {
+		ad := &store.tables.advertisements[i]
+		if ad.Status == "scheduled" && !ad.StartAt.After(now) {
+			ad.Status = "active"
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+func (store *Memory) ArchiveExpiredAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	defer store.lock()()
+
+	var affected int64
+	for i := range store.tables.advertisements {
+		ad := &store.tables.advertisements[i]
+		if (ad.Status == "active" || ad.Status == "paused") && ad.EndAt.Before(now) {
+			ad.Status = "archived"
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// 同 CountAdvertisementsByStatus: 依 status 排序
+func (store *Memory) CountAdvertisementsByStatus(ctx context.Context) ([]sqlc.CountAdvertisementsByStatusRow, error) {
+	defer store.lock()()
+
+	rows := make([]sqlc.CountAdvertisementsByStatusRow, 0)
+	for _, ad := range store.tables.advertisements {
+		i := slices.IndexFunc(rows, func(row sqlc.CountAdvertisementsByStatusRow) bool { return row.Status == ad.Status })
+		if i < 0 {
+			rows = append(rows, sqlc.CountAdvertisementsByStatusRow{Status: ad.Status})
+			i = len(rows) - 1
+		}
+		rows[i].Count++
+	}
+	slices.SortFunc(rows, func(a, b sqlc.CountAdvertisementsByStatusRow) int { return cmp.Compare(a.Status, b.Status) })
+	return rows, nil
+}
+
+func (store *Memory) CountAdvertisementsUsingCountry(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	// region 與 country group 的成員也算使用中
+	return store.tables.countAdvertisementsUsing(func(condition *memoryCondition) []string {
+		countries := slices.Clone(condition.countries)
+		for _, region := range condition.regions {
+			countries = append(countries, region.country)
+		}
+		for _, code := range condition.countryGroups {
+			if group := store.tables.countryGroup(code); group != nil {
+				countries = append(countries, group.members...)
+			}
+		}
+		return countries
+	}, code), nil
+}
+
+func (store *Memory) CountAdvertisementsUsingGender(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	return store.tables.countAdvertisementsUsing(func(condition *memoryCondition) []string { return condition.genders }, code), nil
+}
+
+func (store *Memory) CountAdvertisementsUsingPlatform(ctx context.Context, name string) (int64, error) {
+	defer store.lock()()
+
+	return store.tables.countAdvertisementsUsing(func(condition *memoryCondition) []string { return condition.platforms }, name), nil
+}
+
+func (store *Memory) CountConditionsUsingCountryGroup(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	var count int64
+	for _, condition := range store.tables.conditions {
+		for _, group := range condition.countryGroups {
+			if group == code {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (store *Memory) CountConditionsUsingSegment(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	var count int64
+	for _, condition := range store.tables.conditions {
+		if slices.Contains(condition.segments, code) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *Memory) CreateAdvertisement(ctx context.Context, arg sqlc.CreateAdvertisementParams) (int64, error) {
+	defer store.lock()()
+
+	id := int32(len(store.tables.advertisements) + 1)
+	store.tables.advertisements = append(store.tables.advertisements, sqlc.Advertisement{
+		ID:          id,
+		Title:       arg.Title,
+		StartAt:     arg.StartAt,
+		EndAt:       arg.EndAt,
+		Status:      arg.Status,
+		Description: arg.Description,
+		ImageUrl:    arg.ImageUrl,
+		ImageWidth:  arg.ImageWidth,
+		ImageHeight: arg.ImageHeight,
+		LandingUrl:  arg.LandingUrl,
+		CtaLabel:    arg.CtaLabel,
+		Format:      arg.Format,
+	})
+	return int64(id), nil
+}
+
+func (store *Memory) CreateAdvertisementCondition(ctx context.Context, arg sqlc.CreateAdvertisementConditionParams) error {
+	defer store.lock()()
+
+	if store.tables.advertisement(arg.AdvertisementID) == nil || store.tables.condition(arg.ConditionID) == nil {
+		return errors.New("foreign key constraint fails (advertisement_cond)")
+	}
+	store.tables.advertisementConditions = append(store.tables.advertisementConditions, sqlc.AdvertisementCond{
+		ID:              int32(len(store.tables.advertisementConditions) + 1),
+		AdvertisementID: arg.AdvertisementID,
+		CondID:          arg.ConditionID,
+	})
+	return nil
+}
+
+func (store *Memory) CreateAdvertisementLocalization(ctx context.Context, arg sqlc.CreateAdvertisementLocalizationParams) error {
+	defer store.lock()()
+
+	if store.tables.advertisement(arg.AdvertisementID) == nil {
+		return errors.New("foreign key constraint fails (advertisement_localization.advertisement_id)")
+	}
+	if !slices.Contains(seedLocales, arg.Locale) {
+		return errors.New("column 'locale_id' cannot be null")
+	}
+	for _, localization := range store.tables.localizations {
+		if localization.AdvertisementID == arg.AdvertisementID && localization.Locale == arg.Locale {
+			return errors.New("duplicate entry for key 'idx_advertisement_localization_advertisement_id_locale_id'")
+		}
+	}
+	store.tables.localizations = append(store.tables.localizations, sqlc.GetAdvertisementLocalizationsRow{
+		AdvertisementID: arg.AdvertisementID,
+		Locale:          arg.Locale,
+		Title:           arg.Title,
+		Description:     arg.Description,
+		CtaLabel:        arg.CtaLabel,
+	})
+	return nil
+}
+
+func (store *Memory) CreateAdvertisementVariant(ctx context.Context, arg sqlc.CreateAdvertisementVariantParams) error {
+	defer store.lock()()
+
+	if store.tables.advertisement(arg.AdvertisementID) == nil {
+		return errors.New("foreign key constraint fails (advertisement_variant)")
+	}
+	store.tables.variants = append(store.tables.variants, sqlc.AdvertisementVariant{
+		ID:              int32(len(store.tables.variants) + 1),
+		AdvertisementID: arg.AdvertisementID,
+		Name:            arg.Name,
+		Weight:          arg.Weight,
+		Title:           arg.Title,
+		Description:     arg.Description,
+		ImageUrl:        arg.ImageUrl,
+		ImageWidth:      arg.ImageWidth,
+		ImageHeight:     arg.ImageHeight,
+		LandingUrl:      arg.LandingUrl,
+		CtaLabel:        arg.CtaLabel,
+		Format:          arg.Format,
+	})
+	return nil
+}
+
+func (store *Memory) CreateCondition(ctx context.Context, arg sqlc.CreateConditionParams) (int64, error) {
+	defer store.lock()()
+
+	id := int32(len(store.tables.conditions) + 1)
+	store.tables.conditions = append(store.tables.conditions, memoryCondition{
+		id:       id,
+		ageStart: arg.AgeStart,
+		ageEnd:   arg.AgeEnd,
+	})
+	return int64(id), nil
+}
+
+func (store *Memory) CreateConditionCategory(ctx context.Context, arg sqlc.CreateConditionCategoryParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_category.cond_id)")
+	}
+	condition.categories = append(condition.categories, arg.Term)
+	return nil
+}
+
+func (store *Memory) CreateConditionCountry(ctx context.Context, arg sqlc.CreateConditionCountryParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_country.cond_id)")
+	}
+	if findReference(store.tables.countries, arg.Country) == nil {
+		return errors.New("column 'country_id' cannot be null")
+	}
+	condition.countries = append(condition.countries, arg.Country)
+	return nil
+}
+
+func (store *Memory) CreateConditionCountryGroup(ctx context.Context, arg sqlc.CreateConditionCountryGroupParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_country_group.cond_id)")
+	}
+	if store.tables.countryGroup(arg.CountryGroup) == nil {
+		return errors.New("column 'country_group_id' cannot be null")
+	}
+	condition.countryGroups = append(condition.countryGroups, arg.CountryGroup)
+	return nil
+}
+
+func (store *Memory) CreateConditionGender(ctx context.Context, arg sqlc.CreateConditionGenderParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_gender.cond_id)")
+	}
+	if findReference(store.tables.genders, arg.Gender) == nil {
+		return errors.New("column 'gender_id' cannot be null")
+	}
+	condition.genders = append(condition.genders, arg.Gender)
+	return nil
+}
+
+func (store *Memory) CreateConditionGeofence(ctx context.Context, arg sqlc.CreateConditionGeofenceParams) (int64, error) {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return 0, errors.New("foreign key constraint fails (cond_geofence.cond_id)")
+	}
+	id := int32(len(store.tables.geofences) + 1)
+	store.tables.geofences = append(store.tables.geofences, memoryGeofence{
+		id:     id,
+		condID: arg.ConditionID,
+		lat:    arg.Lat,
+		lng:    arg.Lng,
+		radius: arg.Radius,
+	})
+	condition.geofences = append(condition.geofences, id)
+	return int64(id), nil
+}
+
+func (store *Memory) CreateConditionGeofenceCell(ctx context.Context, arg sqlc.CreateConditionGeofenceCellParams) error {
+	defer store.lock()()
+
+	if arg.GeofenceID < 1 || int(arg.GeofenceID) > len(store.tables.geofences) {
+		return errors.New("foreign key constraint fails (cond_geofence_cell.geofence_id)")
+	}
+	geofence := &store.tables.geofences[arg.GeofenceID-1]
+	geofence.cells = append(slices.Clone(geofence.cells), arg.Geohash)
+	return nil
+}
+
+func (store *Memory) CreateConditionKeyword(ctx context.Context, arg sqlc.CreateConditionKeywordParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_keyword.cond_id)")
+	}
+	condition.keywords = append(condition.keywords, arg.Term)
+	return nil
+}
+
+func (store *Memory) CreateConditionPlatform(ctx context.Context, arg sqlc.CreateConditionPlatformParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_platform.cond_id)")
+	}
+	if findReference(store.tables.platforms, arg.Platform) == nil {
+		return errors.New("column 'platform_id' cannot be null")
+	}
+	condition.platforms = append(condition.platforms, arg.Platform)
+	return nil
+}
+
+func (store *Memory) CreateConditionRegion(ctx context.Context, arg sqlc.CreateConditionRegionParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_region.cond_id)")
+	}
+	condition.regions = append(condition.regions, memoryRegion{code: arg.Region, country: arg.Country})
+	return nil
+}
+
+func (store *Memory) CreateConditionSegment(ctx context.Context, arg sqlc.CreateConditionSegmentParams) error {
+	defer store.lock()()
+
+	condition := store.tables.condition(arg.ConditionID)
+	if condition == nil {
+		return errors.New("foreign key constraint fails (cond_segment.cond_id)")
+	}
+	if store.tables.segment(arg.Segment) == nil {
+		return errors.New("column 'segment_id' cannot be null")
+	}
+	condition.segments = append(condition.segments, arg.Segment)
+	return nil
+}
+
+func (store *Memory) CreateCountryGroupMember(ctx context.Context, arg sqlc.CreateCountryGroupMemberParams) error {
+	defer store.lock()()
+
+	group := store.tables.countryGroup(arg.CountryGroup)
+	if group == nil {
+		return errors.New("column 'country_group_id' cannot be null")
+	}
+	if findReference(store.tables.countries, arg.Country) == nil {
+		return errors.New("column 'country_id' cannot be null")
+	}
+	if slices.Contains(group.members, arg.Country) {
+		return errors.New("duplicate entry for key 'idx_country_group_member_country_group_id_country_id'")
+	}
+	group.members = append(slices.Clone(group.members), arg.Country)
+	return nil
+}
+
+func (store *Memory) DeleteCountryGroup(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	group := store.tables.countryGroup(code)
+	if group == nil || group.builtin {
+		return 0, nil
+	}
+	if len(group.members) > 0 || slices.ContainsFunc(store.tables.conditions, func(condition memoryCondition) bool {
+		return slices.Contains(condition.countryGroups, code)
+	}) {
+		return 0, errors.New("foreign key constraint fails (country_group)")
+	}
+	store.tables.countryGroups = slices.DeleteFunc(slices.Clone(store.tables.countryGroups), func(group memoryCountryGroup) bool {
+		return group.code == code
+	})
+	return 1, nil
+}
+
+func (store *Memory) DeleteCountryGroupMembers(ctx context.Context, code string) error {
+	defer store.lock()()
+
+	if group := store.tables.countryGroup(code); group != nil {
+		group.members = nil
+	}
+	return nil
+}
+
+// 與 ExportAdvertisements 的 JSON_OBJECT 相同的 key
+type memoryExportVariant struct {
+	Name     string `json:"name"`
+	Weight   int32  `json:"weight"`
+	Title    string `json:"title"`
+	Creative struct {
+		Description string `json:"description"`
+		ImageURL    string `json:"imageUrl"`
+		ImageWidth  int32  `json:"imageWidth"`
+		ImageHeight int32  `json:"imageHeight"`
+		LandingURL  string `json:"landingUrl"`
+		CTALabel    string `json:"ctaLabel"`
+		Format      string `json:"format"`
+	} `json:"creative"`
+}
+
+type memoryExportLocalization struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CTALabel    string `json:"ctaLabel"`
+}
+
+// 與 ExportAdvertisements 的 geofences 相同的 key
+type memoryExportGeofence struct {
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Radius int32   `json:"radius"`
+}
+
+func (tables *memoryTables) geofencesJSON(ids []int32) json.RawMessage {
+	geofences := make([]memoryExportGeofence, len(ids))
+	for i, id := range ids {
+		geofence := tables.geofences[id-1]
+		geofences[i] = memoryExportGeofence{Lat: geofence.lat, Lng: geofence.lng, Radius: geofence.radius}
+	}
+	data, _ := json.Marshal(geofences)
+	return data
+}
+
+func regionCodes(regions []memoryRegion) []string {
+	codes := make([]string, len(regions))
+	for i, region := range regions {
+		codes[i] = region.code
+	}
+	return codes
+}
+
+// 同 JSON_ARRAYAGG (沒有資料時為 [])
+func jsonArray(values []string) json.RawMessage {
+	if len(values) == 0 {
+		return json.RawMessage("[]")
+	}
+	data, _ := json.Marshal(values)
+	return data
+}
+
+func (store *Memory) DeleteSegment(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	if store.tables.segment(code) == nil {
+		return 0, nil
+	}
+	if slices.ContainsFunc(store.tables.conditions, func(condition memoryCondition) bool {
+		return slices.Contains(condition.segments, code)
+	}) {
+		return 0, errors.New("foreign key constraint fails (cond_segment.segment_id)")
+	}
+	store.tables.segments = slices.DeleteFunc(slices.Clone(store.tables.segments), func(segment memorySegment) bool {
+		return segment.code == code
+	})
+	return 1, nil
+}
+
+func (store *Memory) ExportAdvertisements(ctx context.Context, arg sqlc.ExportAdvertisementsParams) ([]sqlc.ExportAdvertisementsRow, error) {
+	defer store.lock()()
+
+	rows := make([]sqlc.ExportAdvertisementsRow, 0)
+	for _, ad := range store.tables.advertisements {
+		if arg.Status.Valid && ad.Status != arg.Status.String {
+			continue
+		}
+		if arg.WindowStart.Valid && ad.EndAt.Before(arg.WindowStart.Time) {
+			continue
+		}
+		if arg.WindowEnd.Valid && ad.StartAt.After(arg.WindowEnd.Time) {
+			continue
+		}
+
+		// 沒有資料時為空的 JSON array/object (同 query 的 COALESCE)
+		variantsJSON, localizationsJSON := json.RawMessage("[]"), json.RawMessage("{}")
+		var variants []memoryExportVariant
+		for _, v := range store.tables.variants {
+			if v.AdvertisementID != ad.ID {
+				continue
+			}
+			variant := memoryExportVariant{Name: v.Name, Weight: v.Weight, Title: v.Title}
+			variant.Creative.Description = v.Description
+			variant.Creative.ImageURL = v.ImageUrl
+			variant.Creative.ImageWidth = v.ImageWidth
+			variant.Creative.ImageHeight = v.ImageHeight
+			variant.Creative.LandingURL = v.LandingUrl
+			variant.Creative.CTALabel = v.CtaLabel
+			variant.Creative.Format = v.Format
+			variants = append(variants, variant)
+		}
+		if len(variants) > 0 {
+			variantsJSON, _ = json.Marshal(variants)
+		}
+		localizations := make(map[string]memoryExportLocalization)
+		for _, l := range store.tables.localizations {
+			if l.AdvertisementID == ad.ID {
+				localizations[l.Locale] = memoryExportLocalization{l.Title, l.Description, l.CtaLabel}
+			}
+		}
+		if len(localizations) > 0 {
+			localizationsJSON, _ = json.Marshal(localizations)
+		}
+
+		row := sqlc.ExportAdvertisementsRow{
+			ID:            ad.ID,
+			Title:         ad.Title,
+			StartAt:       ad.StartAt,
+			EndAt:         ad.EndAt,
+			Status:        ad.Status,
+			Description:   ad.Description,
+			ImageUrl:      ad.ImageUrl,
+			ImageWidth:    ad.ImageWidth,
+			ImageHeight:   ad.ImageHeight,
+			LandingUrl:    ad.LandingUrl,
+			CtaLabel:      ad.CtaLabel,
+			Format:        ad.Format,
+			Variants:      variantsJSON,
+			Localizations: localizationsJSON,
+			Genders:       jsonArray(nil),
+			Countries:     jsonArray(nil),
+			CountryGroups: jsonArray(nil),
+			Regions:       jsonArray(nil),
+			Geofences:     json.RawMessage("[]"),
+			Platforms:     jsonArray(nil),
+			Segments:      jsonArray(nil),
+			Keywords:      jsonArray(nil),
+			Categories:    jsonArray(nil),
+		}
+
+		// LEFT JOIN: 沒有 condition 的 advertisement 也有一列
+		conditions := store.tables.conditionsOf(ad.ID)
+		if len(conditions) == 0 {
+			rows = append(rows, row)
+			continue
+		}
+		for _, condition := range conditions {
+			row.CondID = sql.NullInt32{Int32: condition.id, Valid: true}
+			row.AgeStart = condition.ageStart
+			row.AgeEnd = condition.ageEnd
+			row.Genders = jsonArray(condition.genders)
+			row.Countries = jsonArray(condition.countries)
+			row.CountryGroups = jsonArray(condition.countryGroups)
+			row.Regions = jsonArray(regionCodes(condition.regions))
+			row.Geofences = store.tables.geofencesJSON(condition.geofences)
+			row.Platforms = jsonArray(condition.platforms)
+			row.Segments = jsonArray(condition.segments)
+			row.Keywords = jsonArray(condition.keywords)
+			row.Categories = jsonArray(condition.categories)
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// 與 MySQL.ExportAdvertisementsEach 相同 (先複製一份結果, fn 執行期間不持有 lock)
+func (store *Memory) ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error {
+	rows, err := store.ExportAdvertisements(ctx, arg)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *Memory) GetActiveAdvertisements(ctx context.Context, arg sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	defer store.lock()()
+
+	now := time.Now()
+	ads := make([]sqlc.Advertisement, 0)
+	for _, ad := range store.tables.advertisements {
+		if ad.Status != "active" || ad.StartAt.After(now) || ad.EndAt.Before(now) {
+			continue
+		}
+		conditions := store.tables.conditionsOf(ad.ID)
+		matched := len(conditions) == 0
+		for _, condition := range conditions {
+			if store.tables.matches(condition, arg) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			ads = append(ads, ad)
+		}
+	}
+	slices.SortStableFunc(ads, func(a, b sqlc.Advertisement) int { return a.EndAt.Compare(b.EndAt) })
+	return paginate(ads, arg.Offset, arg.Limit), nil
+}
+
+func (store *Memory) GetAdvertisementLocalizations(ctx context.Context, arg sqlc.GetAdvertisementLocalizationsParams) ([]sqlc.GetAdvertisementLocalizationsRow, error) {
+	defer store.lock()()
+
+	localizations := make([]sqlc.GetAdvertisementLocalizationsRow, 0)
+	for _, localization := range store.tables.localizations {
+		if slices.Contains(arg.AdvertisementIds, localization.AdvertisementID) && slices.Contains(arg.Locales, localization.Locale) {
+			localizations = append(localizations, localization)
+		}
+	}
+	return localizations, nil
+}
+
+func (store *Memory) GetAdvertisementStatus(ctx context.Context, id int32) (sqlc.GetAdvertisementStatusRow, error) {
+	defer store.lock()()
+
+	ad := store.tables.advertisement(id)
+	if ad == nil {
+		return sqlc.GetAdvertisementStatusRow{}, sql.ErrNoRows
+	}
+	return sqlc.GetAdvertisementStatusRow{Status: ad.Status, StartAt: ad.StartAt}, nil
+}
+
+func (store *Memory) GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]sqlc.AdvertisementVariant, error) {
+	defer store.lock()()
+
+	variants := make([]sqlc.AdvertisementVariant, 0)
+	for _, variant := range store.tables.variants {
+		if slices.Contains(advertisementIds, variant.AdvertisementID) {
+			variants = append(variants, variant)
+		}
+	}
+	slices.SortStableFunc(variants, func(a, b sqlc.AdvertisementVariant) int {
+		return cmp.Compare(a.AdvertisementID, b.AdvertisementID)
+	})
+	return variants, nil
+}
+
+// 同 GetAllCategories: archived 以外的廣告條件中的 categories (不重複)
+func (store *Memory) GetAllCategories(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	return store.tables.terms(func(condition *memoryCondition) []string { return condition.categories }), nil
+}
+
+func (store *Memory) GetAllCountries(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	return activeReferences(store.tables.countries), nil
+}
+
+func (store *Memory) GetAllCountryGroups(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	codes := make([]string, len(store.tables.countryGroups))
+	for i, group := range store.tables.countryGroups {
+		codes[i] = group.code
+	}
+	return codes, nil
+}
+
+func (store *Memory) GetAllGenders(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	return activeReferences(store.tables.genders), nil
+}
+
+// 同 GetAllKeywords: archived 以外的廣告條件中的 keywords (不重複)
+func (store *Memory) GetAllKeywords(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	return store.tables.terms(func(condition *memoryCondition) []string { return condition.keywords }), nil
+}
+
+// archived 以外的廣告條件中的 terms (不重複)
+func (tables *memoryTables) terms(values func(condition *memoryCondition) []string) []string {
+	terms := make([]string, 0)
+	for _, ad := range tables.advertisements {
+		if ad.Status == "archived" {
+			continue
+		}
+		for _, condition := range tables.conditionsOf(ad.ID) {
+			for _, t := range values(condition) {
+				if !slices.Contains(terms, t) {
+					terms = append(terms, t)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+func (store *Memory) GetAllLocales(ctx context.Context) ([]string, error) {
+	return slices.Clone(seedLocales), nil
+}
+
+func (store *Memory) GetAllPlatforms(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	return activeReferences(store.tables.platforms), nil
+}
+
+func (store *Memory) GetAllSegments(ctx context.Context) ([]string, error) {
+	defer store.lock()()
+
+	codes := make([]string, len(store.tables.segments))
+	for i, segment := range store.tables.segments {
+		codes[i] = segment.code
+	}
+	return codes, nil
+}
+
+func (store *Memory) GetCountryGroupBuiltin(ctx context.Context, code string) (bool, error) {
+	defer store.lock()()
+
+	group := store.tables.countryGroup(code)
+	if group == nil {
+		return false, sql.ErrNoRows
+	}
+	return group.builtin, nil
+}
+
+func (store *Memory) ListAdvertisements(ctx context.Context, arg sqlc.ListAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	defer store.lock()()
+
+	ads := make([]sqlc.Advertisement, 0)
+	for _, ad := range store.tables.advertisements {
+		if !arg.Status.Valid || ad.Status == arg.Status.String {
+			ads = append(ads, ad)
+		}
+	}
+	return slices.Clone(paginate(ads, arg.Offset, arg.Limit)), nil
+}
+
+// 同 ListCountryGroupMembers 的 LEFT JOIN: 沒有 member 的 group 也有一列, 依 group code 與 country code 排序
+func (store *Memory) ListCountryGroupMembers(ctx context.Context) ([]sqlc.ListCountryGroupMembersRow, error) {
+	defer store.lock()()
+
+	rows := make([]sqlc.ListCountryGroupMembersRow, 0)
+	for _, group := range store.tables.countryGroups {
+		row := sqlc.ListCountryGroupMembersRow{Code: group.code, Name: group.name, Builtin: group.builtin}
+		if len(group.members) == 0 {
+			rows = append(rows, row)
+			continue
+		}
+		for _, member := range group.members {
+			row.Country = sql.NullString{String: member, Valid: true}
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b sqlc.ListCountryGroupMembersRow) int {
+		return cmp.Or(cmp.Compare(a.Code, b.Code), cmp.Compare(a.Country.String, b.Country.String))
+	})
+	return rows, nil
+}
+
+// 同 ListSegments: 依 code 排序, conditions 是使用這個 segment 的條件數
+func (store *Memory) ListSegments(ctx context.Context) ([]sqlc.ListSegmentsRow, error) {
+	defer store.lock()()
+
+	rows := make([]sqlc.ListSegmentsRow, len(store.tables.segments))
+	for i, segment := range store.tables.segments {
+		rows[i] = sqlc.ListSegmentsRow{Code: segment.code, Name: segment.name}
+		for _, condition := range store.tables.conditions {
+			if slices.Contains(condition.segments, segment.code) {
+				rows[i].Conditions++
+			}
+		}
+	}
+	slices.SortFunc(rows, func(a, b sqlc.ListSegmentsRow) int { return cmp.Compare(a.Code, b.Code) })
+	return rows, nil
+}
+
+// 同 ResolveGeofences: active 廣告中 cells 符合 geohash 的 prefixes 且距離在 radius 內的 geofences
+func (store *Memory) ResolveGeofences(ctx context.Context, arg sqlc.ResolveGeofencesParams) ([]int32, error) {
+	defer store.lock()()
+
+	prefixes := geo.Prefixes(arg.Geohash)
+	ids := make([]int32, 0)
+	for _, geofence := range store.tables.geofences {
+		if !slices.ContainsFunc(geofence.cells, func(cell string) bool { return slices.Contains(prefixes, cell) }) {
+			continue
+		}
+		if !geo.Within(arg.Lat, arg.Lng, geofence.lat, geofence.lng, geofence.radius) {
+			continue
+		}
+		if !slices.ContainsFunc(store.tables.advertisementConditions, func(relation sqlc.AdvertisementCond) bool {
+			ad := store.tables.advertisement(relation.AdvertisementID)
+			return relation.CondID == geofence.condID && ad != nil && ad.Status == "active"
+		}) {
+			continue
+		}
+		ids = append(ids, geofence.id)
+	}
+	return ids, nil
+}
+
+func (store *Memory) RetireCountry(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	return retireReference(store.tables.countries, code), nil
+}
+
+func (store *Memory) RetireGender(ctx context.Context, code string) (int64, error) {
+	defer store.lock()()
+
+	return retireReference(store.tables.genders, code), nil
+}
+
+func (store *Memory) RetirePlatform(ctx context.Context, name string) (int64, error) {
+	defer store.lock()()
+
+	return retireReference(store.tables.platforms, name), nil
+}
+
+func (store *Memory) UpdateAdvertisementStatus(ctx context.Context, arg sqlc.UpdateAdvertisementStatusParams) (int64, error) {
+	defer store.lock()()
+
+	ad := store.tables.advertisement(arg.ID)
+	if ad == nil || ad.Status != arg.CurrentStatus {
+		return 0, nil
+	}
+	ad.Status = arg.Status
+	return 1, nil
+}
+
+func (store *Memory) UpsertCountry(ctx context.Context, arg sqlc.UpsertCountryParams) error {
+	defer store.lock()()
+
+	store.tables.countries = upsertReference(store.tables.countries, arg.Code, arg.Name)
+	return nil
+}
+
+func (store *Memory) UpsertCountryGroup(ctx context.Context, arg sqlc.UpsertCountryGroupParams) error {
+	defer store.lock()()
+
+	if group := store.tables.countryGroup(arg.Code); group != nil {
+		group.name = arg.Name
+		return nil
+	}
+	store.tables.countryGroups = append(store.tables.countryGroups, memoryCountryGroup{code: arg.Code, name: arg.Name})
+	return nil
+}
+
+func (store *Memory) UpsertGender(ctx context.Context, arg sqlc.UpsertGenderParams) error {
+	defer store.lock()()
+
+	store.tables.genders = upsertReference(store.tables.genders, arg.Code, arg.Name)
+	return nil
+}
+
+func (store *Memory) UpsertPlatform(ctx context.Context, name string) error {
+	defer store.lock()()
+
+	store.tables.platforms = upsertReference(store.tables.platforms, name, name)
+	return nil
+}
+
+func (store *Memory) UpsertSegment(ctx context.Context, arg sqlc.UpsertSegmentParams) error {
+	defer store.lock()()
+
+	if segment := store.tables.segment(arg.Code); segment != nil {
+		segment.name = arg.Name
+		return nil
+	}
+	store.tables.segments = append(store.tables.segments, memorySegment{code: arg.Code, name: arg.Name})
+	return nil
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// MySQL 上的 advertisement 資料 (sqlc 產生的 queries 加上 transaction)
+type MySQL struct {
+	*sqlc.Queries
+	db *sql.DB
+}
+
+func NewMySQL(db *sql.DB) *MySQL {
+	return &MySQL{sqlc.New(instrument(db, semconv.DBSystemMySQL)), db}
+}
+
+// 在同一個 transaction 中執行 fn, fn 回傳 error 時 rollback
+func (store *MySQL) InTx(ctx context.Context, fn func(sqlc.Querier) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqlc.New(instrument(tx, semconv.DBSystemMySQL))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 與 ExportAdvertisements 相同, 但逐列呼叫 fn (fn 回傳 error 時停止)
+func (store *MySQL) ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error {
+	args := []any{arg.Status, arg.Status, arg.WindowStart, arg.WindowStart, arg.WindowEnd, arg.WindowEnd}
+	return queryEach(ctx, instrument(store.db, semconv.DBSystemMySQL), sqlc.ExportAdvertisements, args, fn)
+}
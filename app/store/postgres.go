@@ -0,0 +1,337 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lnfu/dcard-intern/app/models/postgres"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// PostgreSQL 上的 advertisement 資料
+//
+// sqlc 對 postgres 產生的是另一個 package (models/postgres), 這裡把參數與結果
+// 轉換成 MySQL 版本 (models/sqlc) 的型別, 讓 handlers 只需要認得一種 Querier
+type Postgres struct {
+	postgresQueries
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{postgresQueries{postgres.New(instrument(db, semconv.DBSystemPostgreSQL))}, db}
+}
+
+// 在同一個 transaction 中執行 fn, fn 回傳 error 時 rollback
+func (store *Postgres) InTx(ctx context.Context, fn func(sqlc.Querier) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(postgresQueries{postgres.New(instrument(tx, semconv.DBSystemPostgreSQL))}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// postgres.Queries -> sqlc.Querier
+type postgresQueries struct {
+	queries *postgres.Queries
+}
+
+var _ sqlc.Querier = postgresQueries{}
+
+func (q postgresQueries) ActivateScheduledAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	return q.queries.ActivateScheduledAdvertisements(ctx, now)
+}
+
+func (q postgresQueries) ArchiveExpiredAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	return q.queries.ArchiveExpiredAdvertisements(ctx, now)
+}
+
+func (q postgresQueries) CountAdvertisementsByStatus(ctx context.Context) ([]sqlc.CountAdvertisementsByStatusRow, error) {
+	rows, err := q.queries.CountAdvertisementsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.CountAdvertisementsByStatusRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.CountAdvertisementsByStatusRow(row)
+	}
+	return items, nil
+}
+
+func (q postgresQueries) CountAdvertisementsUsingCountry(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingCountry(ctx, code)
+}
+
+func (q postgresQueries) CountAdvertisementsUsingGender(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingGender(ctx, code)
+}
+
+func (q postgresQueries) CountAdvertisementsUsingPlatform(ctx context.Context, name string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingPlatform(ctx, name)
+}
+
+func (q postgresQueries) CountConditionsUsingCountryGroup(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountConditionsUsingCountryGroup(ctx, code)
+}
+
+func (q postgresQueries) CountConditionsUsingSegment(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountConditionsUsingSegment(ctx, code)
+}
+
+func (q postgresQueries) CreateAdvertisement(ctx context.Context, arg sqlc.CreateAdvertisementParams) (int64, error) {
+	id, err := q.queries.CreateAdvertisement(ctx, postgres.CreateAdvertisementParams(arg))
+	return int64(id), err
+}
+
+func (q postgresQueries) CreateAdvertisementCondition(ctx context.Context, arg sqlc.CreateAdvertisementConditionParams) error {
+	return q.queries.CreateAdvertisementCondition(ctx, postgres.CreateAdvertisementConditionParams(arg))
+}
+
+func (q postgresQueries) CreateAdvertisementLocalization(ctx context.Context, arg sqlc.CreateAdvertisementLocalizationParams) error {
+	return q.queries.CreateAdvertisementLocalization(ctx, postgres.CreateAdvertisementLocalizationParams(arg))
+}
+
+func (q postgresQueries) CreateAdvertisementVariant(ctx context.Context, arg sqlc.CreateAdvertisementVariantParams) error {
+	return q.queries.CreateAdvertisementVariant(ctx, postgres.CreateAdvertisementVariantParams(arg))
+}
+
+func (q postgresQueries) CreateCondition(ctx context.Context, arg sqlc.CreateConditionParams) (int64, error) {
+	id, err := q.queries.CreateCondition(ctx, postgres.CreateConditionParams(arg))
+	return int64(id), err
+}
+
+func (q postgresQueries) CreateConditionCategory(ctx context.Context, arg sqlc.CreateConditionCategoryParams) error {
+	return q.queries.CreateConditionCategory(ctx, postgres.CreateConditionCategoryParams(arg))
+}
+
+func (q postgresQueries) CreateConditionCountry(ctx context.Context, arg sqlc.CreateConditionCountryParams) error {
+	return q.queries.CreateConditionCountry(ctx, postgres.CreateConditionCountryParams(arg))
+}
+
+func (q postgresQueries) CreateConditionCountryGroup(ctx context.Context, arg sqlc.CreateConditionCountryGroupParams) error {
+	return q.queries.CreateConditionCountryGroup(ctx, postgres.CreateConditionCountryGroupParams(arg))
+}
+
+func (q postgresQueries) CreateConditionGender(ctx context.Context, arg sqlc.CreateConditionGenderParams) error {
+	return q.queries.CreateConditionGender(ctx, postgres.CreateConditionGenderParams(arg))
+}
+
+func (q postgresQueries) CreateConditionGeofence(ctx context.Context, arg sqlc.CreateConditionGeofenceParams) (int64, error) {
+	return q.queries.CreateConditionGeofence(ctx, postgres.CreateConditionGeofenceParams(arg))
+}
+
+func (q postgresQueries) CreateConditionGeofenceCell(ctx context.Context, arg sqlc.CreateConditionGeofenceCellParams) error {
+	return q.queries.CreateConditionGeofenceCell(ctx, postgres.CreateConditionGeofenceCellParams(arg))
+}
+
+func (q postgresQueries) CreateConditionKeyword(ctx context.Context, arg sqlc.CreateConditionKeywordParams) error {
+	return q.queries.CreateConditionKeyword(ctx, postgres.CreateConditionKeywordParams(arg))
+}
+
+func (q postgresQueries) CreateConditionPlatform(ctx context.Context, arg sqlc.CreateConditionPlatformParams) error {
+	return q.queries.CreateConditionPlatform(ctx, postgres.CreateConditionPlatformParams(arg))
+}
+
+func (q postgresQueries) CreateConditionRegion(ctx context.Context, arg sqlc.CreateConditionRegionParams) error {
+	return q.queries.CreateConditionRegion(ctx, postgres.CreateConditionRegionParams(arg))
+}
+
+func (q postgresQueries) CreateConditionSegment(ctx context.Context, arg sqlc.CreateConditionSegmentParams) error {
+	return q.queries.CreateConditionSegment(ctx, postgres.CreateConditionSegmentParams(arg))
+}
+
+func (q postgresQueries) CreateCountryGroupMember(ctx context.Context, arg sqlc.CreateCountryGroupMemberParams) error {
+	return q.queries.CreateCountryGroupMember(ctx, postgres.CreateCountryGroupMemberParams(arg))
+}
+
+func (q postgresQueries) DeleteCountryGroup(ctx context.Context, code string) (int64, error) {
+	return q.queries.DeleteCountryGroup(ctx, code)
+}
+
+func (q postgresQueries) DeleteCountryGroupMembers(ctx context.Context, code string) error {
+	return q.queries.DeleteCountryGroupMembers(ctx, code)
+}
+
+func (q postgresQueries) DeleteSegment(ctx context.Context, code string) (int64, error) {
+	return q.queries.DeleteSegment(ctx, code)
+}
+
+func (q postgresQueries) ExportAdvertisements(ctx context.Context, arg sqlc.ExportAdvertisementsParams) ([]sqlc.ExportAdvertisementsRow, error) {
+	rows, err := q.queries.ExportAdvertisements(ctx, postgres.ExportAdvertisementsParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ExportAdvertisementsRow, len(rows))
+	for i, row := range rows {
+		items[i] = exportAdvertisementsRowFromPostgres(row)
+	}
+	return items, nil
+}
+
+// 與 ExportAdvertisements 相同, 但逐列呼叫 fn (fn 回傳 error 時停止)
+func (store *Postgres) ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error {
+	args := []any{arg.Status, arg.WindowStart, arg.WindowEnd}
+	return queryEach(ctx, instrument(store.db, semconv.DBSystemPostgreSQL), postgres.ExportAdvertisements, args, func(row postgres.ExportAdvertisementsRow) error {
+		return fn(exportAdvertisementsRowFromPostgres(row))
+	})
+}
+
+func (q postgresQueries) GetActiveAdvertisements(ctx context.Context, arg sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	rows, err := q.queries.GetActiveAdvertisements(ctx, postgres.GetActiveAdvertisementsParams(arg))
+	return advertisementsFromPostgres(rows), err
+}
+
+func (q postgresQueries) GetAdvertisementLocalizations(ctx context.Context, arg sqlc.GetAdvertisementLocalizationsParams) ([]sqlc.GetAdvertisementLocalizationsRow, error) {
+	rows, err := q.queries.GetAdvertisementLocalizations(ctx, postgres.GetAdvertisementLocalizationsParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.GetAdvertisementLocalizationsRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.GetAdvertisementLocalizationsRow(row)
+	}
+	return items, nil
+}
+
+func (q postgresQueries) GetAdvertisementStatus(ctx context.Context, id int32) (sqlc.GetAdvertisementStatusRow, error) {
+	row, err := q.queries.GetAdvertisementStatus(ctx, id)
+	return sqlc.GetAdvertisementStatusRow(row), err
+}
+
+func (q postgresQueries) GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]sqlc.AdvertisementVariant, error) {
+	rows, err := q.queries.GetAdvertisementVariants(ctx, advertisementIds)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.AdvertisementVariant, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.AdvertisementVariant(row)
+	}
+	return items, nil
+}
+
+func (q postgresQueries) GetAllCategories(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCategories(ctx)
+}
+
+func (q postgresQueries) GetAllCountries(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCountries(ctx)
+}
+
+func (q postgresQueries) GetAllCountryGroups(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCountryGroups(ctx)
+}
+
+func (q postgresQueries) GetAllGenders(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllGenders(ctx)
+}
+
+func (q postgresQueries) GetAllKeywords(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllKeywords(ctx)
+}
+
+func (q postgresQueries) GetAllLocales(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllLocales(ctx)
+}
+
+func (q postgresQueries) GetAllPlatforms(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllPlatforms(ctx)
+}
+
+func (q postgresQueries) GetAllSegments(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllSegments(ctx)
+}
+
+func (q postgresQueries) GetCountryGroupBuiltin(ctx context.Context, code string) (bool, error) {
+	return q.queries.GetCountryGroupBuiltin(ctx, code)
+}
+
+func (q postgresQueries) ListAdvertisements(ctx context.Context, arg sqlc.ListAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	rows, err := q.queries.ListAdvertisements(ctx, postgres.ListAdvertisementsParams(arg))
+	return advertisementsFromPostgres(rows), err
+}
+
+func (q postgresQueries) ListCountryGroupMembers(ctx context.Context) ([]sqlc.ListCountryGroupMembersRow, error) {
+	rows, err := q.queries.ListCountryGroupMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListCountryGroupMembersRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.ListCountryGroupMembersRow(row)
+	}
+	return items, nil
+}
+
+func (q postgresQueries) ListSegments(ctx context.Context) ([]sqlc.ListSegmentsRow, error) {
+	rows, err := q.queries.ListSegments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListSegmentsRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.ListSegmentsRow(row)
+	}
+	return items, nil
+}
+
+func (q postgresQueries) ResolveGeofences(ctx context.Context, arg sqlc.ResolveGeofencesParams) ([]int32, error) {
+	return q.queries.ResolveGeofences(ctx, postgres.ResolveGeofencesParams(arg))
+}
+
+func (q postgresQueries) RetireCountry(ctx context.Context, code string) (int64, error) {
+	return q.queries.RetireCountry(ctx, code)
+}
+
+func (q postgresQueries) RetireGender(ctx context.Context, code string) (int64, error) {
+	return q.queries.RetireGender(ctx, code)
+}
+
+func (q postgresQueries) RetirePlatform(ctx context.Context, name string) (int64, error) {
+	return q.queries.RetirePlatform(ctx, name)
+}
+
+func (q postgresQueries) UpdateAdvertisementStatus(ctx context.Context, arg sqlc.UpdateAdvertisementStatusParams) (int64, error) {
+	return q.queries.UpdateAdvertisementStatus(ctx, postgres.UpdateAdvertisementStatusParams(arg))
+}
+
+func (q postgresQueries) UpsertCountry(ctx context.Context, arg sqlc.UpsertCountryParams) error {
+	return q.queries.UpsertCountry(ctx, postgres.UpsertCountryParams(arg))
+}
+
+func (q postgresQueries) UpsertCountryGroup(ctx context.Context, arg sqlc.UpsertCountryGroupParams) error {
+	return q.queries.UpsertCountryGroup(ctx, postgres.UpsertCountryGroupParams(arg))
+}
+
+func (q postgresQueries) UpsertGender(ctx context.Context, arg sqlc.UpsertGenderParams) error {
+	return q.queries.UpsertGender(ctx, postgres.UpsertGenderParams(arg))
+}
+
+func (q postgresQueries) UpsertPlatform(ctx context.Context, name string) error {
+	return q.queries.UpsertPlatform(ctx, name)
+}
+
+func (q postgresQueries) UpsertSegment(ctx context.Context, arg sqlc.UpsertSegmentParams) error {
+	return q.queries.UpsertSegment(ctx, postgres.UpsertSegmentParams(arg))
+}
+
+func advertisementsFromPostgres(rows []postgres.Advertisement) []sqlc.Advertisement {
+	if rows == nil {
+		return nil
+	}
+	items := make([]sqlc.Advertisement, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.Advertisement(row)
+	}
+	return items
+}
+
+func exportAdvertisementsRowFromPostgres(row postgres.ExportAdvertisementsRow) sqlc.ExportAdvertisementsRow {
+	return sqlc.ExportAdvertisementsRow(row)
+}
@@ -0,0 +1,39 @@
+package store
+
+// 與 migrations/mysql 中的 seed 相同的 reference data (memory store 使用)
+var (
+	seedGenders   = []string{"M", "F"}
+	seedPlatforms = []string{"android", "ios", "web"}
+	seedLocales   = []string{"en", "en-US", "en-GB", "zh", "zh-TW", "zh-HK", "zh-CN", "ja", "ko", "th", "vi", "id", "ms", "fr", "de", "es"}
+	seedCountries = []string{
+		"AF", "AX", "AL", "DZ", "AS", "AD", "AO", "AI", "AQ", "AG", "AR", "AM", "AW", "AU", "AT",
+		"AZ", "BS", "BH", "BD", "BB", "BY", "BE", "BZ", "BJ", "BM", "BT", "BO", "BQ", "BA", "BW",
+		"BV", "BR", "IO", "BN", "BG", "BF", "BI", "CV", "KH", "CM", "CA", "KY", "CF", "TD", "CL",
+		"CN", "CX", "CC", "CO", "KM", "CG", "CD", "CK", "CR", "CI", "HR", "CU", "CW", "CY", "CZ",
+		"DK", "DJ", "DM", "DO", "EC", "EG", "SV", "GQ", "ER", "EE", "SZ", "ET", "FK", "FO", "FJ",
+		"FI", "FR", "GF", "PF", "TF", "GA", "GM", "GE", "DE", "GH", "GI", "GR", "GL", "GD", "GP",
+		"GU", "GT", "GG", "GN", "GW", "GY", "HT", "HM", "VA", "HN", "HK", "HU", "IS", "IN", "ID",
+		"IR", "IQ", "IE", "IM", "IL", "IT", "JM", "JP", "JE", "JO", "KZ", "KE", "KI", "KP", "KR",
+		"KW", "KG", "LA", "LV", "LB", "LS", "LR", "LY", "LI", "LT", "LU", "MO", "MG", "MW", "MY",
+		"MV", "ML", "MT", "MH", "MQ", "MR", "MU", "YT", "MX", "FM", "MD", "MC", "MN", "ME", "MS",
+		"MA", "MZ", "MM", "NA", "NR", "NP", "NL", "NC", "NZ", "NI", "NE", "NG", "NU", "NF", "MK",
+		"MP", "NO", "OM", "PK", "PW", "PS", "PA", "PG", "PY", "PE", "PH", "PN", "PL", "PT", "PR",
+		"QA", "RE", "RO", "RU", "RW", "BL", "KN", "LC", "MF", "PM", "VC", "WS", "SM", "ST", "SA",
+		"SN", "RS", "SC", "SL", "SG", "SX", "SK", "SI", "SB", "SO", "ZA", "SS", "ES", "LK", "SD",
+		"SR", "SJ", "SE", "CH", "SY", "TW", "TJ", "TZ", "TH", "TL", "TG", "TK", "TO", "TT", "TN",
+		"TR", "TM", "TC", "TV", "UG", "UA", "AE", "US", "UM", "UY", "UZ", "VU", "VE", "VN", "VG",
+		"VI", "WF", "EH", "YE", "ZM", "ZW",
+	}
+
+	// 與 migrations/mysql 中的 seed_country_group 相同 (builtin)
+	seedCountryGroups = []memoryCountryGroup{
+		{code: "EU", name: "European Union", builtin: true, members: []string{"AT", "BE", "BG", "HR", "CY", "CZ", "DK", "EE", "FI", "FR", "DE", "GR", "HU", "IE", "IT", "LV", "LT", "LU", "MT", "NL", "PL", "PT", "RO", "SK", "SI", "ES", "SE"}},
+		{code: "EEA", name: "European Economic Area", builtin: true, members: []string{"AT", "BE", "BG", "HR", "CY", "CZ", "DK", "EE", "FI", "FR", "DE", "GR", "HU", "IE", "IT", "LV", "LT", "LU", "MT", "NL", "PL", "PT", "RO", "SK", "SI", "ES", "SE", "IS", "LI", "NO"}},
+		{code: "DACH", name: "Germany, Austria and Switzerland", builtin: true, members: []string{"DE", "AT", "CH"}},
+		{code: "NORDICS", name: "Nordic countries", builtin: true, members: []string{"DK", "FI", "IS", "NO", "SE"}},
+		{code: "ASEAN", name: "Association of Southeast Asian Nations", builtin: true, members: []string{"BN", "KH", "ID", "LA", "MY", "MM", "PH", "SG", "TH", "VN"}},
+		{code: "APAC", name: "Asia-Pacific", builtin: true, members: []string{"AU", "BD", "BN", "KH", "CN", "FJ", "HK", "IN", "ID", "JP", "KR", "LA", "MO", "MY", "MV", "MN", "MM", "NP", "NZ", "PK", "PG", "PH", "SG", "LK", "TW", "TH", "TL", "VN"}},
+		{code: "GCC", name: "Gulf Cooperation Council", builtin: true, members: []string{"AE", "BH", "KW", "OM", "QA", "SA"}},
+		{code: "LATAM", name: "Latin America", builtin: true, members: []string{"AR", "BO", "BR", "CL", "CO", "CR", "CU", "DO", "EC", "SV", "GT", "HN", "MX", "NI", "PA", "PY", "PE", "PR", "UY", "VE"}},
+	}
+)
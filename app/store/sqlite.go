@@ -0,0 +1,518 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	"github.com/lnfu/dcard-intern/app/models/sqlite"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// SQLite 上的 advertisement 資料 (modernc.org/sqlite, 不需要 CGO)
+//
+// 與 Postgres 相同, 把 models/sqlite 的型別轉換成 models/sqlc 的型別.
+// SQLite 的 INTEGER 是 int64, 時間以文字 (UTC) 儲存, 所以比較前一律轉成 UTC
+type SQLite struct {
+	sqliteQueries
+	db *sql.DB
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{sqliteQueries{sqlite.New(instrument(db, semconv.DBSystemSqlite))}, db}
+}
+
+// 在同一個 transaction 中執行 fn, fn 回傳 error 時 rollback
+func (store *SQLite) InTx(ctx context.Context, fn func(sqlc.Querier) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqliteQueries{sqlite.New(instrument(tx, semconv.DBSystemSqlite))}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqlite.Queries -> sqlc.Querier
+type sqliteQueries struct {
+	queries *sqlite.Queries
+}
+
+var _ sqlc.Querier = sqliteQueries{}
+
+func (q sqliteQueries) ActivateScheduledAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	return q.queries.ActivateScheduledAdvertisements(ctx, now.UTC())
+}
+
+func (q sqliteQueries) ArchiveExpiredAdvertisements(ctx context.Context, now time.Time) (int64, error) {
+	return q.queries.ArchiveExpiredAdvertisements(ctx, now.UTC())
+}
+
+func (q sqliteQueries) CountAdvertisementsByStatus(ctx context.Context) ([]sqlc.CountAdvertisementsByStatusRow, error) {
+	rows, err := q.queries.CountAdvertisementsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.CountAdvertisementsByStatusRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.CountAdvertisementsByStatusRow(row)
+	}
+	return items, nil
+}
+
+func (q sqliteQueries) CountAdvertisementsUsingCountry(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingCountry(ctx, code)
+}
+
+func (q sqliteQueries) CountAdvertisementsUsingGender(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingGender(ctx, code)
+}
+
+func (q sqliteQueries) CountAdvertisementsUsingPlatform(ctx context.Context, name string) (int64, error) {
+	return q.queries.CountAdvertisementsUsingPlatform(ctx, name)
+}
+
+func (q sqliteQueries) CountConditionsUsingCountryGroup(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountConditionsUsingCountryGroup(ctx, code)
+}
+
+func (q sqliteQueries) CountConditionsUsingSegment(ctx context.Context, code string) (int64, error) {
+	return q.queries.CountConditionsUsingSegment(ctx, code)
+}
+
+func (q sqliteQueries) CreateAdvertisement(ctx context.Context, arg sqlc.CreateAdvertisementParams) (int64, error) {
+	return q.queries.CreateAdvertisement(ctx, sqlite.CreateAdvertisementParams{
+		Title:       arg.Title,
+		StartAt:     arg.StartAt.UTC(),
+		EndAt:       arg.EndAt.UTC(),
+		Status:      arg.Status,
+		Description: arg.Description,
+		ImageUrl:    arg.ImageUrl,
+		ImageWidth:  int64(arg.ImageWidth),
+		ImageHeight: int64(arg.ImageHeight),
+		LandingUrl:  arg.LandingUrl,
+		CtaLabel:    arg.CtaLabel,
+		Format:      arg.Format,
+	})
+}
+
+func (q sqliteQueries) CreateAdvertisementCondition(ctx context.Context, arg sqlc.CreateAdvertisementConditionParams) error {
+	return q.queries.CreateAdvertisementCondition(ctx, sqlite.CreateAdvertisementConditionParams{
+		AdvertisementID: int64(arg.AdvertisementID),
+		ConditionID:     int64(arg.ConditionID),
+	})
+}
+
+func (q sqliteQueries) CreateAdvertisementLocalization(ctx context.Context, arg sqlc.CreateAdvertisementLocalizationParams) error {
+	return q.queries.CreateAdvertisementLocalization(ctx, sqlite.CreateAdvertisementLocalizationParams{
+		AdvertisementID: int64(arg.AdvertisementID),
+		Locale:          arg.Locale,
+		Title:           arg.Title,
+		Description:     arg.Description,
+		CtaLabel:        arg.CtaLabel,
+	})
+}
+
+func (q sqliteQueries) CreateAdvertisementVariant(ctx context.Context, arg sqlc.CreateAdvertisementVariantParams) error {
+	return q.queries.CreateAdvertisementVariant(ctx, sqlite.CreateAdvertisementVariantParams{
+		AdvertisementID: int64(arg.AdvertisementID),
+		Name:            arg.Name,
+		Weight:          int64(arg.Weight),
+		Title:           arg.Title,
+		Description:     arg.Description,
+		ImageUrl:        arg.ImageUrl,
+		ImageWidth:      int64(arg.ImageWidth),
+		ImageHeight:     int64(arg.ImageHeight),
+		LandingUrl:      arg.LandingUrl,
+		CtaLabel:        arg.CtaLabel,
+		Format:          arg.Format,
+	})
+}
+
+func (q sqliteQueries) CreateCondition(ctx context.Context, arg sqlc.CreateConditionParams) (int64, error) {
+	return q.queries.CreateCondition(ctx, sqlite.CreateConditionParams{
+		AgeStart: nullInt64(arg.AgeStart),
+		AgeEnd:   nullInt64(arg.AgeEnd),
+	})
+}
+
+func (q sqliteQueries) CreateConditionCategory(ctx context.Context, arg sqlc.CreateConditionCategoryParams) error {
+	return q.queries.CreateConditionCategory(ctx, sqlite.CreateConditionCategoryParams{ConditionID: int64(arg.ConditionID), Term: arg.Term})
+}
+
+func (q sqliteQueries) CreateConditionCountry(ctx context.Context, arg sqlc.CreateConditionCountryParams) error {
+	return q.queries.CreateConditionCountry(ctx, sqlite.CreateConditionCountryParams{ConditionID: int64(arg.ConditionID), Country: arg.Country})
+}
+
+func (q sqliteQueries) CreateConditionCountryGroup(ctx context.Context, arg sqlc.CreateConditionCountryGroupParams) error {
+	return q.queries.CreateConditionCountryGroup(ctx, sqlite.CreateConditionCountryGroupParams{ConditionID: int64(arg.ConditionID), CountryGroup: arg.CountryGroup})
+}
+
+func (q sqliteQueries) CreateConditionGender(ctx context.Context, arg sqlc.CreateConditionGenderParams) error {
+	return q.queries.CreateConditionGender(ctx, sqlite.CreateConditionGenderParams{ConditionID: int64(arg.ConditionID), Gender: arg.Gender})
+}
+
+func (q sqliteQueries) CreateConditionGeofence(ctx context.Context, arg sqlc.CreateConditionGeofenceParams) (int64, error) {
+	return q.queries.CreateConditionGeofence(ctx, sqlite.CreateConditionGeofenceParams{
+		ConditionID: int64(arg.ConditionID),
+		Lat:         arg.Lat,
+		Lng:         arg.Lng,
+		Radius:      int64(arg.Radius),
+	})
+}
+
+func (q sqliteQueries) CreateConditionGeofenceCell(ctx context.Context, arg sqlc.CreateConditionGeofenceCellParams) error {
+	return q.queries.CreateConditionGeofenceCell(ctx, sqlite.CreateConditionGeofenceCellParams{GeofenceID: int64(arg.GeofenceID), Geohash: arg.Geohash})
+}
+
+func (q sqliteQueries) CreateConditionKeyword(ctx context.Context, arg sqlc.CreateConditionKeywordParams) error {
+	return q.queries.CreateConditionKeyword(ctx, sqlite.CreateConditionKeywordParams{ConditionID: int64(arg.ConditionID), Term: arg.Term})
+}
+
+func (q sqliteQueries) CreateConditionPlatform(ctx context.Context, arg sqlc.CreateConditionPlatformParams) error {
+	return q.queries.CreateConditionPlatform(ctx, sqlite.CreateConditionPlatformParams{ConditionID: int64(arg.ConditionID), Platform: arg.Platform})
+}
+
+func (q sqliteQueries) CreateConditionRegion(ctx context.Context, arg sqlc.CreateConditionRegionParams) error {
+	return q.queries.CreateConditionRegion(ctx, sqlite.CreateConditionRegionParams{ConditionID: int64(arg.ConditionID), Region: arg.Region, Country: arg.Country})
+}
+
+func (q sqliteQueries) CreateConditionSegment(ctx context.Context, arg sqlc.CreateConditionSegmentParams) error {
+	return q.queries.CreateConditionSegment(ctx, sqlite.CreateConditionSegmentParams{ConditionID: int64(arg.ConditionID), Segment: arg.Segment})
+}
+
+func (q sqliteQueries) CreateCountryGroupMember(ctx context.Context, arg sqlc.CreateCountryGroupMemberParams) error {
+	return q.queries.CreateCountryGroupMember(ctx, sqlite.CreateCountryGroupMemberParams(arg))
+}
+
+func (q sqliteQueries) DeleteCountryGroup(ctx context.Context, code string) (int64, error) {
+	return q.queries.DeleteCountryGroup(ctx, code)
+}
+
+func (q sqliteQueries) DeleteCountryGroupMembers(ctx context.Context, code string) error {
+	return q.queries.DeleteCountryGroupMembers(ctx, code)
+}
+
+func (q sqliteQueries) DeleteSegment(ctx context.Context, code string) (int64, error) {
+	return q.queries.DeleteSegment(ctx, code)
+}
+
+func (q sqliteQueries) ExportAdvertisements(ctx context.Context, arg sqlc.ExportAdvertisementsParams) ([]sqlc.ExportAdvertisementsRow, error) {
+	rows, err := q.queries.ExportAdvertisements(ctx, exportAdvertisementsParamsToSQLite(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ExportAdvertisementsRow, len(rows))
+	for i, row := range rows {
+		items[i] = exportAdvertisementsRowFromSQLite(row)
+	}
+	return items, nil
+}
+
+// 與 ExportAdvertisements 相同, 但逐列呼叫 fn (fn 回傳 error 時停止)
+func (store *SQLite) ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error {
+	params := exportAdvertisementsParamsToSQLite(arg)
+	args := []any{params.Status, params.WindowStart, params.WindowEnd}
+	return queryEach(ctx, instrument(store.db, semconv.DBSystemSqlite), sqlite.ExportAdvertisements, args, func(row sqlite.ExportAdvertisementsRow) error {
+		return fn(exportAdvertisementsRowFromSQLite(row))
+	})
+}
+
+func (q sqliteQueries) GetActiveAdvertisements(ctx context.Context, arg sqlc.GetActiveAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	rows, err := q.queries.GetActiveAdvertisements(ctx, sqlite.GetActiveAdvertisementsParams{
+		Age:         nullInt64(arg.Age),
+		Gender:      arg.Gender,
+		Country:     arg.Country,
+		Platform:    arg.Platform,
+		Region:      arg.Region,
+		GeofenceIds: joinIDs(arg.GeofenceIds),
+		Segments:    strings.Join(arg.Segments, ","),
+		Keywords:    strings.Join(arg.Keywords, ","),
+		Category:    arg.Category,
+		Offset:      int64(arg.Offset),
+		Limit:       int64(arg.Limit),
+	})
+	return advertisementsFromSQLite(rows), err
+}
+
+func (q sqliteQueries) GetAdvertisementLocalizations(ctx context.Context, arg sqlc.GetAdvertisementLocalizationsParams) ([]sqlc.GetAdvertisementLocalizationsRow, error) {
+	rows, err := q.queries.GetAdvertisementLocalizations(ctx, sqlite.GetAdvertisementLocalizationsParams{
+		AdvertisementIds: int64s(arg.AdvertisementIds),
+		Locales:          arg.Locales,
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.GetAdvertisementLocalizationsRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.GetAdvertisementLocalizationsRow{
+			AdvertisementID: int32(row.AdvertisementID),
+			Locale:          row.Locale,
+			Title:           row.Title,
+			Description:     row.Description,
+			CtaLabel:        row.CtaLabel,
+		}
+	}
+	return items, nil
+}
+
+func (q sqliteQueries) GetAdvertisementStatus(ctx context.Context, id int32) (sqlc.GetAdvertisementStatusRow, error) {
+	row, err := q.queries.GetAdvertisementStatus(ctx, int64(id))
+	return sqlc.GetAdvertisementStatusRow(row), err
+}
+
+func (q sqliteQueries) GetAdvertisementVariants(ctx context.Context, advertisementIds []int32) ([]sqlc.AdvertisementVariant, error) {
+	rows, err := q.queries.GetAdvertisementVariants(ctx, int64s(advertisementIds))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.AdvertisementVariant, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.AdvertisementVariant{
+			ID:              int32(row.ID),
+			AdvertisementID: int32(row.AdvertisementID),
+			Name:            row.Name,
+			Weight:          int32(row.Weight),
+			Title:           row.Title,
+			Description:     row.Description,
+			ImageUrl:        row.ImageUrl,
+			ImageWidth:      int32(row.ImageWidth),
+			ImageHeight:     int32(row.ImageHeight),
+			LandingUrl:      row.LandingUrl,
+			CtaLabel:        row.CtaLabel,
+			Format:          row.Format,
+		}
+	}
+	return items, nil
+}
+
+func (q sqliteQueries) GetAllCategories(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCategories(ctx)
+}
+
+func (q sqliteQueries) GetAllCountries(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCountries(ctx)
+}
+
+func (q sqliteQueries) GetAllCountryGroups(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllCountryGroups(ctx)
+}
+
+func (q sqliteQueries) GetAllGenders(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllGenders(ctx)
+}
+
+func (q sqliteQueries) GetAllKeywords(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllKeywords(ctx)
+}
+
+func (q sqliteQueries) GetAllLocales(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllLocales(ctx)
+}
+
+func (q sqliteQueries) GetAllPlatforms(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllPlatforms(ctx)
+}
+
+func (q sqliteQueries) GetAllSegments(ctx context.Context) ([]string, error) {
+	return q.queries.GetAllSegments(ctx)
+}
+
+func (q sqliteQueries) GetCountryGroupBuiltin(ctx context.Context, code string) (bool, error) {
+	return q.queries.GetCountryGroupBuiltin(ctx, code)
+}
+
+func (q sqliteQueries) ListAdvertisements(ctx context.Context, arg sqlc.ListAdvertisementsParams) ([]sqlc.Advertisement, error) {
+	rows, err := q.queries.ListAdvertisements(ctx, sqlite.ListAdvertisementsParams{
+		Status: arg.Status,
+		Offset: int64(arg.Offset),
+		Limit:  int64(arg.Limit),
+	})
+	return advertisementsFromSQLite(rows), err
+}
+
+func (q sqliteQueries) ListCountryGroupMembers(ctx context.Context) ([]sqlc.ListCountryGroupMembersRow, error) {
+	rows, err := q.queries.ListCountryGroupMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListCountryGroupMembersRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.ListCountryGroupMembersRow(row)
+	}
+	return items, nil
+}
+
+func (q sqliteQueries) ListSegments(ctx context.Context) ([]sqlc.ListSegmentsRow, error) {
+	rows, err := q.queries.ListSegments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListSegmentsRow, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.ListSegmentsRow(row)
+	}
+	return items, nil
+}
+
+func (q sqliteQueries) ResolveGeofences(ctx context.Context, arg sqlc.ResolveGeofencesParams) ([]int32, error) {
+	ids, err := q.queries.ResolveGeofences(ctx, sqlite.ResolveGeofencesParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int32, len(ids))
+	for i, id := range ids {
+		result[i] = int32(id)
+	}
+	return result, nil
+}
+
+func (q sqliteQueries) RetireCountry(ctx context.Context, code string) (int64, error) {
+	return q.queries.RetireCountry(ctx, code)
+}
+
+func (q sqliteQueries) RetireGender(ctx context.Context, code string) (int64, error) {
+	return q.queries.RetireGender(ctx, code)
+}
+
+func (q sqliteQueries) RetirePlatform(ctx context.Context, name string) (int64, error) {
+	return q.queries.RetirePlatform(ctx, name)
+}
+
+func (q sqliteQueries) UpdateAdvertisementStatus(ctx context.Context, arg sqlc.UpdateAdvertisementStatusParams) (int64, error) {
+	return q.queries.UpdateAdvertisementStatus(ctx, sqlite.UpdateAdvertisementStatusParams{
+		Status:        arg.Status,
+		ID:            int64(arg.ID),
+		CurrentStatus: arg.CurrentStatus,
+	})
+}
+
+func (q sqliteQueries) UpsertCountry(ctx context.Context, arg sqlc.UpsertCountryParams) error {
+	return q.queries.UpsertCountry(ctx, sqlite.UpsertCountryParams(arg))
+}
+
+func (q sqliteQueries) UpsertCountryGroup(ctx context.Context, arg sqlc.UpsertCountryGroupParams) error {
+	return q.queries.UpsertCountryGroup(ctx, sqlite.UpsertCountryGroupParams(arg))
+}
+
+func (q sqliteQueries) UpsertGender(ctx context.Context, arg sqlc.UpsertGenderParams) error {
+	return q.queries.UpsertGender(ctx, sqlite.UpsertGenderParams(arg))
+}
+
+func (q sqliteQueries) UpsertPlatform(ctx context.Context, name string) error {
+	return q.queries.UpsertPlatform(ctx, name)
+}
+
+func (q sqliteQueries) UpsertSegment(ctx context.Context, arg sqlc.UpsertSegmentParams) error {
+	return q.queries.UpsertSegment(ctx, sqlite.UpsertSegmentParams(arg))
+}
+
+func advertisementsFromSQLite(rows []sqlite.Advertisement) []sqlc.Advertisement {
+	if rows == nil {
+		return nil
+	}
+	items := make([]sqlc.Advertisement, len(rows))
+	for i, row := range rows {
+		items[i] = sqlc.Advertisement{
+			ID:          int32(row.ID),
+			Title:       row.Title,
+			StartAt:     row.StartAt,
+			EndAt:       row.EndAt,
+			Status:      row.Status,
+			Description: row.Description,
+			ImageUrl:    row.ImageUrl,
+			ImageWidth:  int32(row.ImageWidth),
+			ImageHeight: int32(row.ImageHeight),
+			LandingUrl:  row.LandingUrl,
+			CtaLabel:    row.CtaLabel,
+			Format:      row.Format,
+		}
+	}
+	return items
+}
+
+// window 的型別 sqlc 推斷不出來 (interface{}), NULL 時傳 nil
+func exportAdvertisementsParamsToSQLite(arg sqlc.ExportAdvertisementsParams) sqlite.ExportAdvertisementsParams {
+	params := sqlite.ExportAdvertisementsParams{Status: arg.Status}
+	if arg.WindowStart.Valid {
+		params.WindowStart = arg.WindowStart.Time.UTC()
+	}
+	if arg.WindowEnd.Valid {
+		params.WindowEnd = arg.WindowEnd.Time.UTC()
+	}
+	return params
+}
+
+// JSON 的欄位 sqlc 產生 interface{} (NULL 時為 nil, 否則為 string)
+func exportAdvertisementsRowFromSQLite(row sqlite.ExportAdvertisementsRow) sqlc.ExportAdvertisementsRow {
+	text := func(value interface{}) sql.NullString {
+		switch value := value.(type) {
+		case string:
+			return sql.NullString{String: value, Valid: true}
+		case []byte:
+			return sql.NullString{String: string(value), Valid: true}
+		default:
+			return sql.NullString{}
+		}
+	}
+	return sqlc.ExportAdvertisementsRow{
+		ID:            int32(row.ID),
+		Title:         row.Title,
+		StartAt:       row.StartAt,
+		EndAt:         row.EndAt,
+		Status:        row.Status,
+		Description:   row.Description,
+		ImageUrl:      row.ImageUrl,
+		ImageWidth:    int32(row.ImageWidth),
+		ImageHeight:   int32(row.ImageHeight),
+		LandingUrl:    row.LandingUrl,
+		CtaLabel:      row.CtaLabel,
+		Format:        row.Format,
+		Variants:      json.RawMessage(text(row.Variants).String),
+		Localizations: json.RawMessage(text(row.Localizations).String),
+		CondID:        nullInt32(row.CondID),
+		AgeStart:      nullInt32(row.AgeStart),
+		AgeEnd:        nullInt32(row.AgeEnd),
+		Genders:       json.RawMessage(text(row.Genders).String),
+		Countries:     json.RawMessage(text(row.Countries).String),
+		CountryGroups: json.RawMessage(text(row.CountryGroups).String),
+		Regions:       json.RawMessage(text(row.Regions).String),
+		Geofences:     json.RawMessage(text(row.Geofences).String),
+		Platforms:     json.RawMessage(text(row.Platforms).String),
+		Segments:      json.RawMessage(text(row.Segments).String),
+		Keywords:      json.RawMessage(text(row.Keywords).String),
+		Categories:    json.RawMessage(text(row.Categories).String),
+	}
+}
+
+func nullInt64(value sql.NullInt32) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value.Int32), Valid: value.Valid}
+}
+
+func nullInt32(value sql.NullInt64) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(value.Int64), Valid: value.Valid}
+}
+
+// sqlc 的 SQLite slice 不能與 ?N 參數混用, 改用逗號分隔的字串 (query 中以 instr 比對)
+func joinIDs(values []int32) string {
+	ids := make([]string, len(values))
+	for i, value := range values {
+		ids[i] = strconv.Itoa(int(value))
+	}
+	return strings.Join(ids, ",")
+}
+
+func int64s(values []int32) []int64 {
+	result := make([]int64, len(values))
+	for i, value := range values {
+		result[i] = int64(value)
+	}
+	return result
+}
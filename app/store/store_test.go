@@ -0,0 +1,1036 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/lnfu/dcard-intern/app/geo"
+	"github.com/lnfu/dcard-intern/app/migrations"
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+	_ "modernc.org/sqlite"
+)
+
+var ctx = context.Background()
+
+// 所有 store 共用的介面 (同 handlers.AdStore)
+type testStore interface {
+	sqlc.Querier
+	ExportAdvertisementsEach(ctx context.Context, arg sqlc.ExportAdvertisementsParams, fn func(sqlc.ExportAdvertisementsRow) error) error
+	InTx(ctx context.Context, fn func(sqlc.Querier) error) error
+}
+
+// 測試會清空資料的 table (reference data 保留)
+var testTables = []string{
+	"advertisement_localization",
+	"advertisement_variant",
+	"cond_platform",
+	"cond_country",
+	"cond_country_group",
+	"cond_region",
+	"cond_geofence_cell",
+	"cond_geofence",
+	"cond_segment",
+	"cond_keyword",
+	"cond_category",
+	"cond_gender",
+	"advertisement_cond",
+	"cond",
+	"advertisement",
+	"segment",
+}
+
+// 對每一種 store 執行同一組測試, memory 與 sqlite (暫存檔) 一定會跑,
+// MySQL/PostgreSQL 需要設定 TEST_MYSQL_DSN/TEST_POSTGRES_DSN (測試用資料庫, 會先 migrate 再清空資料,
+// docker compose --profile test 的 mysql-test/postgres-test)
+func forEachStore(t *testing.T, test func(t *testing.T, store testStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemory())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/test.db?_pragma=foreign_keys(1)&_time_format=sqlite&_txlock=immediate")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if _, err := migrations.Up(ctx, db, "sqlite"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		test(t, NewSQLite(db))
+	})
+	t.Run("mysql", func(t *testing.T) {
+		db := openTestDatabase(t, "mysql", "TEST_MYSQL_DSN")
+		for _, table := range testTables {
+			execTestStatements(t, db, "DELETE FROM "+table, "ALTER TABLE "+table+" AUTO_INCREMENT = 1")
+		}
+		test(t, NewMySQL(db))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := openTestDatabase(t, "postgres", "TEST_POSTGRES_DSN")
+		for _, table := range testTables {
+			execTestStatements(t, db, "TRUNCATE "+table+" RESTART IDENTITY CASCADE")
+		}
+		test(t, NewPostgres(db))
+	})
+}
+
+func openTestDatabase(t *testing.T, driver string, env string) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("%s not set", env)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// migration 檔案有多個 statements, MySQL 另外開一個 multiStatements 的連線
+	migrateDB := db
+	if driver == "mysql" {
+		config, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		config.MultiStatements = true
+		migrateDB, err = sql.Open(driver, config.FormatDSN())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer migrateDB.Close()
+	}
+	if _, err := migrations.Up(ctx, migrateDB, driver); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return db
+}
+
+func execTestStatements(t *testing.T, db *sql.DB, statements ...string) {
+	t.Helper()
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+}
+
+// 比較 JSON 的內容 (各資料庫輸出的 key 順序不同)
+func equalJSON(t *testing.T, expected string, got json.RawMessage) bool {
+	t.Helper()
+	var expectedValue, gotValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(expectedValue, gotValue)
+}
+
+// JSON array -> 以 "," 連接的字串 (方便比較)
+func joinJSON(t *testing.T, value json.RawMessage) string {
+	t.Helper()
+	var values []string
+	if err := json.Unmarshal(value, &values); err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, value)
+	}
+	return strings.Join(values, ",")
+}
+
+type testCondition struct {
+	ageStart, ageEnd              sql.NullInt32
+	genders, countries, platforms []string
+	countryGroups                 []string
+	regions                       []string
+	geofences                     []testGeofence
+	segments                      []string
+	keywords, categories          []string
+}
+
+type testGeofence struct {
+	lat, lng float64
+	radius   int32
+}
+
+// 新增一個 advertisement 與它的 conditions, 回傳 id
+func createTestAdvertisement(t *testing.T, store sqlc.Querier, title string, status string, endAt time.Time, conditions ...testCondition) int32 {
+	t.Helper()
+	id, err := store.CreateAdvertisement(ctx, sqlc.CreateAdvertisementParams{
+		Title:   title,
+		StartAt: endAt.Add(-30 * 24 * time.Hour),
+		EndAt:   endAt,
+		Status:  status,
+		Format:  "text",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, condition := range conditions {
+		conditionID, err := store.CreateCondition(ctx, sqlc.CreateConditionParams{AgeStart: condition.ageStart, AgeEnd: condition.ageEnd})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, gender := range condition.genders {
+			if err := store.CreateConditionGender(ctx, sqlc.CreateConditionGenderParams{ConditionID: int32(conditionID), Gender: gender}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, country := range condition.countries {
+			if err := store.CreateConditionCountry(ctx, sqlc.CreateConditionCountryParams{ConditionID: int32(conditionID), Country: country}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, group := range condition.countryGroups {
+			if err := store.CreateConditionCountryGroup(ctx, sqlc.CreateConditionCountryGroupParams{ConditionID: int32(conditionID), CountryGroup: group}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, region := range condition.regions {
+			if err := store.CreateConditionRegion(ctx, sqlc.CreateConditionRegionParams{ConditionID: int32(conditionID), Region: region, Country: region[:2]}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, geofence := range condition.geofences {
+			geofenceID, err := store.CreateConditionGeofence(ctx, sqlc.CreateConditionGeofenceParams{ConditionID: int32(conditionID), Lat: geofence.lat, Lng: geofence.lng, Radius: geofence.radius})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, cell := range geo.Cover(geofence.lat, geofence.lng, geofence.radius) {
+				if err := store.CreateConditionGeofenceCell(ctx, sqlc.CreateConditionGeofenceCellParams{GeofenceID: int32(geofenceID), Geohash: cell}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+		}
+		for _, platform := range condition.platforms {
+			if err := store.CreateConditionPlatform(ctx, sqlc.CreateConditionPlatformParams{ConditionID: int32(conditionID), Platform: platform}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, segment := range condition.segments {
+			if err := store.CreateConditionSegment(ctx, sqlc.CreateConditionSegmentParams{ConditionID: int32(conditionID), Segment: segment}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, keyword := range condition.keywords {
+			if err := store.CreateConditionKeyword(ctx, sqlc.CreateConditionKeywordParams{ConditionID: int32(conditionID), Term: keyword}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		for _, category := range condition.categories {
+			if err := store.CreateConditionCategory(ctx, sqlc.CreateConditionCategoryParams{ConditionID: int32(conditionID), Term: category}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := store.CreateAdvertisementCondition(ctx, sqlc.CreateAdvertisementConditionParams{AdvertisementID: int32(id), ConditionID: int32(conditionID)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return int32(id)
+}
+
+func TestStore_GetActiveAdvertisements(t *testing.T) {
+	forEachStore(t, testGetActiveAdvertisements)
+}
+
+func testGetActiveAdvertisements(t *testing.T, store testStore) {
+	day := time.Now().UTC().Truncate(time.Second)
+
+	// 1: 沒有 condition (所有人)
+	createTestAdvertisement(t, store, "AD 1", "active", day.AddDate(0, 0, 5))
+	// 2: 20 ~ 30 歲的 TW/JP 使用者, 或任何 ios 使用者
+	createTestAdvertisement(t, store, "AD 2", "active", day.AddDate(0, 0, 1),
+		testCondition{ageStart: sql.NullInt32{Int32: 20, Valid: true}, ageEnd: sql.NullInt32{Int32: 30, Valid: true}, countries: []string{"TW", "JP"}},
+		testCondition{platforms: []string{"ios"}},
+	)
+	// 3: 女性
+	createTestAdvertisement(t, store, "AD 3", "active", day.AddDate(0, 0, 3),
+		testCondition{genders: []string{"F"}},
+	)
+	// 4: 暫停中
+	createTestAdvertisement(t, store, "AD 4", "paused", day)
+	// 5, 6: 已經結束/還沒開始 (startAt = endAt - 30 天)
+	createTestAdvertisement(t, store, "AD 5", "active", day.AddDate(0, 0, -1))
+	createTestAdvertisement(t, store, "AD 6", "active", day.AddDate(0, 0, 40))
+
+	testCases := []struct {
+		name     string
+		params   sqlc.GetActiveAdvertisementsParams
+		expected []int32
+	}{
+		{
+			name:     "no filter (ordered by endAt)",
+			params:   sqlc.GetActiveAdvertisementsParams{Limit: 10},
+			expected: []int32{2, 3, 1},
+		},
+		{
+			name: "age & country",
+			params: sqlc.GetActiveAdvertisementsParams{
+				Age:     sql.NullInt32{Int32: 25, Valid: true},
+				Country: sql.NullString{String: "TW", Valid: true},
+				Limit:   10,
+			},
+			expected: []int32{2, 3, 1},
+		},
+		{
+			name: "age out of range",
+			params: sqlc.GetActiveAdvertisementsParams{
+				Age:      sql.NullInt32{Int32: 40, Valid: true},
+				Gender:   sql.NullString{String: "M", Valid: true},
+				Platform: sql.NullString{String: "android", Valid: true},
+				Limit:    10,
+			},
+			expected: []int32{1},
+		},
+		{
+			name: "second condition",
+			params: sqlc.GetActiveAdvertisementsParams{
+				Age:      sql.NullInt32{Int32: 40, Valid: true},
+				Platform: sql.NullString{String: "ios", Valid: true},
+				Limit:    10,
+			},
+			expected: []int32{2, 3, 1},
+		},
+		{
+			name:     "offset & limit",
+			params:   sqlc.GetActiveAdvertisementsParams{Offset: 1, Limit: 1},
+			expected: []int32{3},
+		},
+		{
+			name:     "offset out of range",
+			params:   sqlc.GetActiveAdvertisementsParams{Offset: 10, Limit: 1},
+			expected: []int32{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ads, err := store.GetActiveAdvertisements(ctx, tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]int32, len(ads))
+			for i, ad := range ads {
+				ids[i] = ad.ID
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, ids)
+			}
+		})
+	}
+}
+
+func TestStore_InTx(t *testing.T) {
+	forEachStore(t, testInTx)
+}
+
+func testInTx(t *testing.T, store testStore) {
+	day := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	// fn 回傳 error 時不保留任何變更
+	var id int32
+	err := store.InTx(ctx, func(queries sqlc.Querier) error {
+		id = createTestAdvertisement(t, queries, "AD 1", "active", day)
+		return queries.CreateConditionGender(ctx, sqlc.CreateConditionGenderParams{ConditionID: 1, Gender: "X"})
+	})
+	if err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+	if _, err := store.GetAdvertisementStatus(ctx, id); err != sql.ErrNoRows {
+		t.Errorf("expected error: %v, got: %v", sql.ErrNoRows, err)
+	}
+
+	// rollback 不一定會退回 auto increment 的 id
+	err = store.InTx(ctx, func(queries sqlc.Querier) error {
+		id = createTestAdvertisement(t, queries, "AD 1", "active", day, testCondition{genders: []string{"M"}})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ad, err := store.GetAdvertisementStatus(ctx, id); err != nil || ad.Status != "active" {
+		t.Errorf("expected status: active, got: %q (%v)", ad.Status, err)
+	}
+}
+
+func TestStore_UpdateAdvertisementStatus(t *testing.T) {
+	forEachStore(t, testUpdateAdvertisementStatus)
+}
+
+func testUpdateAdvertisementStatus(t *testing.T, store testStore) {
+	now := time.Now()
+	createTestAdvertisement(t, store, "AD 1", "scheduled", now.Add(48*time.Hour))
+
+	affected, err := store.UpdateAdvertisementStatus(ctx, sqlc.UpdateAdvertisementStatusParams{ID: 1, Status: "paused", CurrentStatus: "active"})
+	if err != nil || affected != 0 {
+		t.Errorf("expected 0 affected rows, got: %d (%v)", affected, err)
+	}
+
+	// startAt 已經過了 (endAt - 30 天)
+	activated, err := store.ActivateScheduledAdvertisements(ctx, now.Add(25*time.Hour))
+	if err != nil || activated != 1 {
+		t.Errorf("expected 1 activated advertisement, got: %d (%v)", activated, err)
+	}
+
+	affected, err = store.UpdateAdvertisementStatus(ctx, sqlc.UpdateAdvertisementStatusParams{ID: 1, Status: "paused", CurrentStatus: "active"})
+	if err != nil || affected != 1 {
+		t.Errorf("expected 1 affected row, got: %d (%v)", affected, err)
+	}
+
+	// endAt 還沒過
+	archived, err := store.ArchiveExpiredAdvertisements(ctx, now.Add(47*time.Hour))
+	if err != nil || archived != 0 {
+		t.Errorf("expected 0 archived advertisements, got: %d (%v)", archived, err)
+	}
+	archived, err = store.ArchiveExpiredAdvertisements(ctx, now.Add(49*time.Hour))
+	if err != nil || archived != 1 {
+		t.Errorf("expected 1 archived advertisement, got: %d (%v)", archived, err)
+	}
+	ad, err := store.GetAdvertisementStatus(ctx, 1)
+	if err != nil || ad.Status != "archived" {
+		t.Errorf("expected archived, got: %s (%v)", ad.Status, err)
+	}
+	// startAt = endAt - 30 天 (createTestAdvertisement)
+	if got := now.Sub(ad.StartAt); got < 27*24*time.Hour || got > 29*24*time.Hour {
+		t.Errorf("unexpected startAt: %v", ad.StartAt)
+	}
+}
+
+func TestStore_ReferenceData(t *testing.T) {
+	forEachStore(t, testReferenceData)
+}
+
+func testReferenceData(t *testing.T, store testStore) {
+	// 重複執行也會得到相同的結果 (upsert 會重新啟用)
+	if err := store.UpsertPlatform(ctx, "tv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.UpsertPlatform(ctx, "tv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.UpsertGender(ctx, sqlc.UpsertGenderParams{Code: "X", Name: "Non-binary"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	platforms, err := store.GetAllPlatforms(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(platforms, "tv") {
+		t.Errorf("expected tv in %v", platforms)
+	}
+
+	// archived 的廣告不計算
+	now := time.Now()
+	createTestAdvertisement(t, store, "AD 1", "active", now.Add(24*time.Hour), testCondition{platforms: []string{"tv"}})
+	createTestAdvertisement(t, store, "AD 2", "scheduled", now.Add(48*time.Hour), testCondition{platforms: []string{"ios"}}, testCondition{platforms: []string{"tv"}, genders: []string{"X"}})
+	createTestAdvertisement(t, store, "AD 3", "paused", now.Add(24*time.Hour), testCondition{platforms: []string{"tv"}})
+	createTestAdvertisement(t, store, "AD 4", "archived", now.Add(24*time.Hour), testCondition{platforms: []string{"tv"}})
+	count, err := store.CountAdvertisementsUsingPlatform(ctx, "tv")
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 advertisements, got: %d (%v)", count, err)
+	}
+	count, err = store.CountAdvertisementsUsingGender(ctx, "X")
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 advertisement, got: %d (%v)", count, err)
+	}
+	count, err = store.CountAdvertisementsUsingCountry(ctx, "TW")
+	if err != nil || count != 0 {
+		t.Errorf("expected 0 advertisements, got: %d (%v)", count, err)
+	}
+
+	retired, err := store.RetirePlatform(ctx, "tv")
+	if err != nil || retired != 1 {
+		t.Errorf("expected 1 retired row, got: %d (%v)", retired, err)
+	}
+	retired, err = store.RetirePlatform(ctx, "tv")
+	if err != nil || retired != 0 {
+		t.Errorf("expected 0 retired rows, got: %d (%v)", retired, err)
+	}
+	retired, err = store.RetireGender(ctx, "X")
+	if err != nil || retired != 1 {
+		t.Errorf("expected 1 retired row, got: %d (%v)", retired, err)
+	}
+	platforms, err = store.GetAllPlatforms(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Contains(platforms, "tv") {
+		t.Errorf("unexpected tv in %v", platforms)
+	}
+	genders, err := store.GetAllGenders(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(genders, []string{"M", "F"}) {
+		t.Errorf("expected: [M F], got: %v", genders)
+	}
+
+	// 停用後已經存在的條件不受影響
+	ads, err := store.GetActiveAdvertisements(ctx, sqlc.GetActiveAdvertisementsParams{Platform: sql.NullString{String: "tv", Valid: true}, Limit: 10})
+	if err != nil || len(ads) != 1 {
+		t.Errorf("expected 1 advertisement, got: %d (%v)", len(ads), err)
+	}
+}
+
+func TestStore_CountAdvertisementsUsingCountry(t *testing.T) {
+	forEachStore(t, testCountAdvertisementsUsingCountry)
+}
+
+// region 與 country group 的成員也算使用中 (archived 的廣告不計算)
+func testCountAdvertisementsUsingCountry(t *testing.T, store testStore) {
+	now := time.Now()
+	createTestAdvertisement(t, store, "AD 1", "active", now.Add(24*time.Hour), testCondition{regions: []string{"JP-13"}})
+	createTestAdvertisement(t, store, "AD 2", "scheduled", now.Add(24*time.Hour), testCondition{countries: []string{"JP"}}, testCondition{countryGroups: []string{"DACH"}})
+	createTestAdvertisement(t, store, "AD 3", "archived", now.Add(24*time.Hour), testCondition{regions: []string{"DE-BE"}})
+	for _, tc := range []struct {
+		code     string
+		expected int64
+	}{
+		{code: "JP", expected: 2},
+		{code: "DE", expected: 1},
+		{code: "FR", expected: 0},
+	} {
+		count, err := store.CountAdvertisementsUsingCountry(ctx, tc.code)
+		if err != nil || count != tc.expected {
+			t.Errorf("%s: expected %d advertisements, got: %d (%v)", tc.code, tc.expected, count, err)
+		}
+	}
+}
+
+func TestStore_CountryGroups(t *testing.T) {
+	forEachStore(t, testCountryGroups)
+}
+
+func testCountryGroups(t *testing.T, store testStore) {
+	// 前一次執行留下的 group (MySQL/PostgreSQL)
+	if err := store.DeleteCountryGroupMembers(ctx, "ISLANDS"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.DeleteCountryGroup(ctx, "ISLANDS"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	groups, err := store.GetAllCountryGroups(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(groups, "EU") || !slices.Contains(groups, "APAC") {
+		t.Errorf("expected built-in groups, got: %v", groups)
+	}
+	builtin, err := store.GetCountryGroupBuiltin(ctx, "EU")
+	if err != nil || !builtin {
+		t.Errorf("expected EU to be built-in, got: %v (%v)", builtin, err)
+	}
+	if _, err := store.GetCountryGroupBuiltin(ctx, "MARS"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got: %v", err)
+	}
+
+	if err := store.UpsertCountryGroup(ctx, sqlc.UpsertCountryGroupParams{Code: "ISLANDS", Name: "Islands"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, country := range []string{"TW", "JP"} {
+		if err := store.CreateCountryGroupMember(ctx, sqlc.CreateCountryGroupMemberParams{CountryGroup: "ISLANDS", Country: country}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	day := time.Now().UTC().Truncate(time.Second)
+	createTestAdvertisement(t, store, "AD 1", "active", day.AddDate(0, 0, 1), testCondition{countryGroups: []string{"DACH"}})
+	createTestAdvertisement(t, store, "AD 2", "active", day.AddDate(0, 0, 2), testCondition{countries: []string{"US"}, countryGroups: []string{"ISLANDS"}})
+	createTestAdvertisement(t, store, "AD 3", "active", day.AddDate(0, 0, 3))
+
+	titles := func(country string) []string {
+		t.Helper()
+		ads, err := store.GetActiveAdvertisements(ctx, sqlc.GetActiveAdvertisementsParams{
+			Country: sql.NullString{String: country, Valid: true},
+			Limit:   10,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		titles := make([]string, len(ads))
+		for i, ad := range ads {
+			titles[i] = ad.Title
+		}
+		return titles
+	}
+	testCases := []struct {
+		country  string
+		expected []string
+	}{
+		{"AT", []string{"AD 1", "AD 3"}},
+		{"US", []string{"AD 2", "AD 3"}},
+		{"JP", []string{"AD 2", "AD 3"}},
+		{"FR", []string{"AD 3"}},
+	}
+	for _, tc := range testCases {
+		if got := titles(tc.country); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("country %s: expected: %v, got: %v", tc.country, tc.expected, got)
+		}
+	}
+
+	// 比對時才展開, 已經存在的廣告馬上套用新的 members
+	if err := store.InTx(ctx, func(q sqlc.Querier) error {
+		if err := q.DeleteCountryGroupMembers(ctx, "ISLANDS"); err != nil {
+			return err
+		}
+		return q.CreateCountryGroupMember(ctx, sqlc.CreateCountryGroupMemberParams{CountryGroup: "ISLANDS", Country: "KR"})
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := titles("JP"); !reflect.DeepEqual(got, []string{"AD 3"}) {
+		t.Errorf("expected: [AD 3], got: %v", got)
+	}
+	if got := titles("KR"); !reflect.DeepEqual(got, []string{"AD 2", "AD 3"}) {
+		t.Errorf("expected: [AD 2 AD 3], got: %v", got)
+	}
+
+	rows, err := store.ListCountryGroupMembers(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var members []string
+	for _, row := range rows {
+		if row.Code == "ISLANDS" {
+			members = append(members, row.Country.String)
+		}
+	}
+	if !reflect.DeepEqual(members, []string{"KR"}) {
+		t.Errorf("expected: [KR], got: %v", members)
+	}
+
+	count, err := store.CountConditionsUsingCountryGroup(ctx, "ISLANDS")
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 condition, got: %d (%v)", count, err)
+	}
+	deleted, err := store.DeleteCountryGroup(ctx, "EU")
+	if err != nil || deleted != 0 {
+		t.Errorf("expected built-in group to be kept, got: %d (%v)", deleted, err)
+	}
+
+	var exported []string
+	if err := store.ExportAdvertisementsEach(ctx, sqlc.ExportAdvertisementsParams{}, func(row sqlc.ExportAdvertisementsRow) error {
+		exported = append(exported, joinJSON(t, row.CountryGroups))
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(exported, []string{"DACH", "ISLANDS", ""}) {
+		t.Errorf("expected: [DACH ISLANDS ], got: %v", exported)
+	}
+}
+
+func TestStore_GeoConditions(t *testing.T) {
+	forEachStore(t, testGeoConditions)
+}
+
+func testGeoConditions(t *testing.T, store testStore) {
+	day := time.Now().UTC().Truncate(time.Second)
+	taipei101 := testGeofence{lat: 25.0340, lng: 121.5645, radius: 2000}
+
+	createTestAdvertisement(t, store, "AD 1", "active", day.AddDate(0, 0, 1), testCondition{regions: []string{"TW-TPE", "TW-NWT"}})
+	createTestAdvertisement(t, store, "AD 2", "active", day.AddDate(0, 0, 2), testCondition{geofences: []testGeofence{taipei101}})
+	createTestAdvertisement(t, store, "AD 3", "paused", day.AddDate(0, 0, 3), testCondition{geofences: []testGeofence{taipei101}})
+	createTestAdvertisement(t, store, "AD 4", "active", day.AddDate(0, 0, 4))
+
+	// 包含位置的 geofences (只有 active 廣告的)
+	resolve := func(lat, lng float64) []int32 {
+		t.Helper()
+		ids, err := store.ResolveGeofences(ctx, sqlc.ResolveGeofencesParams{
+			Geohash:  geo.Encode(lat, lng, geo.MaxPrecision),
+			Lat:      lat,
+			Lng:      lng,
+			LngScale: geo.LngScale(lat),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ids
+	}
+	if ids := resolve(25.0330, 121.5654); !reflect.DeepEqual(ids, []int32{1}) {
+		t.Errorf("expected: [1], got: %v", ids)
+	}
+	// 台北車站 (約 5 km)
+	if ids := resolve(25.0478, 121.5170); len(ids) != 0 {
+		t.Errorf("expected no geofences, got: %v", ids)
+	}
+
+	testCases := []struct {
+		name     string
+		arg      sqlc.GetActiveAdvertisementsParams
+		expected []string
+	}{
+		{name: "no location", expected: []string{"AD 1", "AD 4"}},
+		{name: "region", arg: sqlc.GetActiveAdvertisementsParams{Country: sql.NullString{String: "TW", Valid: true}, Region: sql.NullString{String: "TW-TPE", Valid: true}}, expected: []string{"AD 1", "AD 4"}},
+		{name: "other region", arg: sqlc.GetActiveAdvertisementsParams{Country: sql.NullString{String: "TW", Valid: true}, Region: sql.NullString{String: "TW-KHH", Valid: true}}, expected: []string{"AD 4"}},
+		{name: "country of region", arg: sqlc.GetActiveAdvertisementsParams{Country: sql.NullString{String: "TW", Valid: true}}, expected: []string{"AD 1", "AD 4"}},
+		{name: "other country", arg: sqlc.GetActiveAdvertisementsParams{Country: sql.NullString{String: "JP", Valid: true}}, expected: []string{"AD 4"}},
+		{name: "inside geofence", arg: sqlc.GetActiveAdvertisementsParams{GeofenceIds: []int32{1}}, expected: []string{"AD 1", "AD 2", "AD 4"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arg.Limit = 10
+			ads, err := store.GetActiveAdvertisements(ctx, tc.arg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			titles := make([]string, len(ads))
+			for i, ad := range ads {
+				titles[i] = ad.Title
+			}
+			if !reflect.DeepEqual(titles, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, titles)
+			}
+		})
+	}
+
+	var regions []string
+	var geofences []json.RawMessage
+	if err := store.ExportAdvertisementsEach(ctx, sqlc.ExportAdvertisementsParams{}, func(row sqlc.ExportAdvertisementsRow) error {
+		regions = append(regions, joinJSON(t, row.Regions))
+		geofences = append(geofences, row.Geofences)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if regions[0] != "TW-TPE,TW-NWT" {
+		t.Errorf("expected: TW-TPE,TW-NWT, got: %s", regions[0])
+	}
+	if !equalJSON(t, `[{"lat": 25.034, "lng": 121.5645, "radius": 2000}]`, geofences[1]) || !equalJSON(t, `[]`, geofences[3]) {
+		t.Errorf("unexpected geofences: %s", geofences)
+	}
+}
+
+func TestStore_Segments(t *testing.T) {
+	forEachStore(t, testSegments)
+}
+
+func testSegments(t *testing.T, store testStore) {
+	for _, segment := range []sqlc.UpsertSegmentParams{{Code: "gamer", Name: "Gamers"}, {Code: "new_parent", Name: "New Parents"}, {Code: "traveler", Name: "Travel"}} {
+		if err := store.UpsertSegment(ctx, segment); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := store.UpsertSegment(ctx, sqlc.UpsertSegmentParams{Code: "traveler", Name: "Travelers"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	segments, err := store.GetAllSegments(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(segments)
+	if !reflect.DeepEqual(segments, []string{"gamer", "new_parent", "traveler"}) {
+		t.Errorf("expected: [gamer new_parent traveler], got: %v", segments)
+	}
+
+	day := time.Now().UTC().Truncate(time.Second)
+	createTestAdvertisement(t, store, "AD 1", "active", day.AddDate(0, 0, 1), testCondition{segments: []string{"gamer", "traveler"}})
+	createTestAdvertisement(t, store, "AD 2", "active", day.AddDate(0, 0, 2), testCondition{segments: []string{"new_parent"}}, testCondition{countries: []string{"JP"}})
+	createTestAdvertisement(t, store, "AD 3", "active", day.AddDate(0, 0, 3))
+	if err := store.CreateConditionSegment(ctx, sqlc.CreateConditionSegmentParams{ConditionID: 1, Segment: "unknown"}); err == nil {
+		t.Errorf("expected unknown segment error, but got nil")
+	}
+
+	testCases := []struct {
+		name     string
+		arg      sqlc.GetActiveAdvertisementsParams
+		expected []string
+	}{
+		{name: "no segments", expected: []string{"AD 2", "AD 3"}},
+		{name: "one segment", arg: sqlc.GetActiveAdvertisementsParams{Segments: []string{"traveler"}}, expected: []string{"AD 1", "AD 2", "AD 3"}},
+		{name: "overlapping segments", arg: sqlc.GetActiveAdvertisementsParams{Segments: []string{"gamer", "new_parent"}}, expected: []string{"AD 1", "AD 2", "AD 3"}},
+		{name: "unused segment", arg: sqlc.GetActiveAdvertisementsParams{Segments: []string{"sports"}}, expected: []string{"AD 2", "AD 3"}},
+		{name: "other condition", arg: sqlc.GetActiveAdvertisementsParams{Country: sql.NullString{String: "TW", Valid: true}}, expected: []string{"AD 3"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arg.Limit = 10
+			ads, err := store.GetActiveAdvertisements(ctx, tc.arg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			titles := make([]string, len(ads))
+			for i, ad := range ads {
+				titles[i] = ad.Title
+			}
+			if !reflect.DeepEqual(titles, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, titles)
+			}
+		})
+	}
+
+	rows, err := store.ListSegments(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedRows := []sqlc.ListSegmentsRow{
+		{Code: "gamer", Name: "Gamers", Conditions: 1},
+		{Code: "new_parent", Name: "New Parents", Conditions: 1},
+		{Code: "traveler", Name: "Travelers", Conditions: 1},
+	}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("expected: %+v, got: %+v", expectedRows, rows)
+	}
+
+	count, err := store.CountConditionsUsingSegment(ctx, "gamer")
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 condition, got: %d (%v)", count, err)
+	}
+	if err := store.UpsertSegment(ctx, sqlc.UpsertSegmentParams{Code: "sports", Name: "Sports"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deleted, err := store.DeleteSegment(ctx, "sports")
+	if err != nil || deleted != 1 {
+		t.Errorf("expected 1 deleted segment, got: %d (%v)", deleted, err)
+	}
+	deleted, err = store.DeleteSegment(ctx, "sports")
+	if err != nil || deleted != 0 {
+		t.Errorf("expected 0 deleted segments, got: %d (%v)", deleted, err)
+	}
+
+	var exported []string
+	if err := store.ExportAdvertisementsEach(ctx, sqlc.ExportAdvertisementsParams{}, func(row sqlc.ExportAdvertisementsRow) error {
+		exported = append(exported, joinJSON(t, row.Segments))
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(exported, []string{"gamer,traveler", "new_parent", "", ""}) {
+		t.Errorf("expected: [gamer,traveler new_parent  ], got: %v", exported)
+	}
+}
+
+func TestStore_ContextualConditions(t *testing.T) {
+	forEachStore(t, testContextualConditions)
+}
+
+func testContextualConditions(t *testing.T, store testStore) {
+	day := time.Now().UTC().Truncate(time.Second)
+	createTestAdvertisement(t, store, "AD 1", "active", day.AddDate(0, 0, 1), testCondition{keywords: []string{"switch", "ps5"}})
+	createTestAdvertisement(t, store, "AD 2", "active", day.AddDate(0, 0, 2), testCondition{categories: []string{"makeup", "美妝"}})
+	createTestAdvertisement(t, store, "AD 3", "active", day.AddDate(0, 0, 3), testCondition{keywords: []string{"nintendo switch"}, categories: []string{"game"}})
+	createTestAdvertisement(t, store, "AD 4", "active", day.AddDate(0, 0, 4))
+
+	testCases := []struct {
+		name     string
+		arg      sqlc.GetActiveAdvertisementsParams
+		expected []string
+	}{
+		{name: "no context", expected: []string{"AD 4"}},
+		{name: "keyword", arg: sqlc.GetActiveAdvertisementsParams{Keywords: []string{"ps5", "xbox"}}, expected: []string{"AD 1", "AD 4"}},
+		{name: "keyword is not a prefix", arg: sqlc.GetActiveAdvertisementsParams{Keywords: []string{"ps"}}, expected: []string{"AD 4"}},
+		{name: "category", arg: sqlc.GetActiveAdvertisementsParams{Category: sql.NullString{String: "美妝", Valid: true}}, expected: []string{"AD 2", "AD 4"}},
+		{name: "keyword and category", arg: sqlc.GetActiveAdvertisementsParams{Keywords: []string{"nintendo switch", "switch"}, Category: sql.NullString{String: "game", Valid: true}}, expected: []string{"AD 1", "AD 3", "AD 4"}},
+		{name: "keyword in other category", arg: sqlc.GetActiveAdvertisementsParams{Keywords: []string{"nintendo switch"}, Category: sql.NullString{String: "makeup", Valid: true}}, expected: []string{"AD 2", "AD 4"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.arg.Limit = 10
+			ads, err := store.GetActiveAdvertisements(ctx, tc.arg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			titles := make([]string, len(ads))
+			for i, ad := range ads {
+				titles[i] = ad.Title
+			}
+			if !reflect.DeepEqual(titles, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, titles)
+			}
+		})
+	}
+
+	var keywords, categories []string
+	if err := store.ExportAdvertisementsEach(ctx, sqlc.ExportAdvertisementsParams{}, func(row sqlc.ExportAdvertisementsRow) error {
+		keywords = append(keywords, joinJSON(t, row.Keywords))
+		categories = append(categories, joinJSON(t, row.Categories))
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(keywords, []string{"switch,ps5", "", "nintendo switch", ""}) {
+		t.Errorf("unexpected keywords: %q", keywords)
+	}
+	if !reflect.DeepEqual(categories, []string{"", "makeup,美妝", "game", ""}) {
+		t.Errorf("unexpected categories: %q", categories)
+	}
+
+	// archived 的廣告不計算
+	createTestAdvertisement(t, store, "AD 5", "archived", day.AddDate(0, 0, 5), testCondition{keywords: []string{"xbox", "ps5"}, categories: []string{"news"}})
+	terms, err := store.GetAllKeywords(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(terms)
+	if !reflect.DeepEqual(terms, []string{"nintendo switch", "ps5", "switch"}) {
+		t.Errorf("unexpected keywords: %q", terms)
+	}
+	terms, err = store.GetAllCategories(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(terms)
+	if !reflect.DeepEqual(terms, []string{"game", "makeup", "美妝"}) {
+		t.Errorf("unexpected categories: %q", terms)
+	}
+}
+
+func TestStore_ExportAdvertisements(t *testing.T) {
+	forEachStore(t, testExportAdvertisements)
+}
+
+func testExportAdvertisements(t *testing.T, store testStore) {
+	day := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	createTestAdvertisement(t, store, "AD 1", "active", day,
+		testCondition{genders: []string{"M"}, countries: []string{"TW", "JP"}},
+		testCondition{platforms: []string{"ios"}},
+	)
+	createTestAdvertisement(t, store, "AD 2", "archived", day)
+	if err := store.CreateAdvertisementVariant(ctx, sqlc.CreateAdvertisementVariantParams{AdvertisementID: 2, Name: "A", Weight: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.CreateAdvertisementLocalization(ctx, sqlc.CreateAdvertisementLocalizationParams{AdvertisementID: 2, Locale: "zh-TW", Title: "廣告 2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.CreateAdvertisementLocalization(ctx, sqlc.CreateAdvertisementLocalizationParams{AdvertisementID: 2, Locale: "zh-TW", Title: "廣告 2"}); err == nil {
+		t.Errorf("expected duplicate localization error, but got nil")
+	}
+
+	rows, err := store.ExportAdvertisements(ctx, sqlc.ExportAdvertisementsParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got: %d", len(rows))
+	}
+	if joinJSON(t, rows[0].Countries) != "TW,JP" || joinJSON(t, rows[0].Platforms) != "" || joinJSON(t, rows[1].Platforms) != "ios" {
+		t.Errorf("unexpected condition rows: %+v", rows[:2])
+	}
+	if rows[2].CondID.Valid || !equalJSON(t, "[]", rows[0].Variants) || !equalJSON(t, "{}", rows[0].Localizations) {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+	expectedVariants := `[{"name":"A","weight":1,"title":"","creative":{"description":"","imageUrl":"","imageWidth":0,"imageHeight":0,"landingUrl":"","ctaLabel":"","format":""}}]`
+	if !equalJSON(t, expectedVariants, rows[2].Variants) {
+		t.Errorf("expected: %s, got: %s", expectedVariants, rows[2].Variants)
+	}
+	expectedLocalizations := `{"zh-TW":{"title":"廣告 2","description":"","ctaLabel":""}}`
+	if !equalJSON(t, expectedLocalizations, rows[2].Localizations) {
+		t.Errorf("expected: %s, got: %s", expectedLocalizations, rows[2].Localizations)
+	}
+
+	rows, err = store.ExportAdvertisements(ctx, sqlc.ExportAdvertisementsParams{Status: sql.NullString{String: "archived", Valid: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || rows[0].ID != 2 {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	// 逐列讀取 (store.queryEach) 的結果要與 sqlc 產生的 ExportAdvertisements 相同
+	for _, arg := range []sqlc.ExportAdvertisementsParams{
+		{},
+		{Status: sql.NullString{String: "active", Valid: true}},
+		{WindowStart: sql.NullTime{Time: day.Add(-time.Hour), Valid: true}, WindowEnd: sql.NullTime{Time: day.Add(time.Hour), Valid: true}},
+	} {
+		expected, err := store.ExportAdvertisements(ctx, arg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []sqlc.ExportAdvertisementsRow
+		err = store.ExportAdvertisementsEach(ctx, arg, func(row sqlc.ExportAdvertisementsRow) error {
+			got = append(got, row)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("ExportAdvertisementsEach(%+v) = %+v, ExportAdvertisements = %+v", arg, got, expected)
+		}
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = store.ExportAdvertisementsEach(ctx, sqlc.ExportAdvertisementsParams{}, func(sqlc.ExportAdvertisementsRow) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Errorf("expected to stop after the first row, got: %d rows (%v)", count, err)
+	}
+}
+
+func TestStore_ExportLongConditions(t *testing.T) {
+	forEachStore(t, testExportLongConditions)
+}
+
+// 超過 group_concat_max_len (預設 1024 bytes) 的 condition 也不能被截斷
+func testExportLongConditions(t *testing.T, store testStore) {
+	var keywords []string
+	for i := 0; i < 100; i++ {
+		keywords = append(keywords, fmt.Sprintf("long keyword %03d", i))
+	}
+	createTestAdvertisement(t, store, "AD 1", "active", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), testCondition{keywords: keywords})
+
+	rows, err := store.ExportAdvertisements(ctx, sqlc.ExportAdvertisementsParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got: %d", len(rows))
+	}
+	var got []string
+	if err := json.Unmarshal(rows[0].Keywords, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(got)
+	if !reflect.DeepEqual(got, keywords) {
+		t.Errorf("expected %d keywords, got: %d", len(keywords), len(got))
+	}
+}
+
+func TestStore_CountAdvertisementsByStatus(t *testing.T) {
+	forEachStore(t, testCountAdvertisementsByStatus)
+}
+
+func testCountAdvertisementsByStatus(t *testing.T, store testStore) {
+	day := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	createTestAdvertisement(t, store, "AD 1", "active", day)
+	createTestAdvertisement(t, store, "AD 2", "paused", day)
+	createTestAdvertisement(t, store, "AD 3", "active", day)
+
+	rows, err := store.CountAdvertisementsByStatus(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []sqlc.CountAdvertisementsByStatusRow{{Status: "active", Count: 2}, {Status: "paused", Count: 1}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected: %v, got: %v", expected, rows)
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "-- name: GetActiveAdvertisements :many\nSELECT 1", expected: "GetActiveAdvertisements"},
+		{query: "-- name: CountAdvertisementsByStatus :many\n", expected: "CountAdvertisementsByStatus"},
+		{query: "SELECT 1", expected: "unknown"},
+		{query: "-- name: Broken", expected: "unknown"},
+	}
+	for _, tc := range testCases {
+		if got := queryName(tc.query); got != tc.expected {
+			t.Errorf("%q: expected: %q, got: %q", tc.query, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"reflect"
+
+	sqlc "github.com/lnfu/dcard-intern/app/models/sqlc"
+)
+
+// sqlc 只產生把結果收集成 slice 的 :many query, export 需要逐列處理 (固定的記憶體串流整張表),
+// 所以這裡使用 sqlc 產生的 query (emit_exported_queries) 與 Row struct 逐列讀取.
+// sqlc 的 Row struct 欄位順序與 SELECT 的欄位相同, 依欄位順序 scan, query 改變後重新 generate 即可
+func queryEach[T any](ctx context.Context, db sqlc.DBTX, query string, args []any, fn func(T) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row T
+		value := reflect.ValueOf(&row).Elem()
+		dest := make([]any, value.NumField())
+		for i := range dest {
+			dest[i] = value.Field(i).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
@@ -0,0 +1,36 @@
+package term
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 正規化後的長度上限 (rune)
+const MaxLength = 64
+
+var folder = cases.Fold()
+
+// 條件與 request 中的 keyword/category 都以正規化後的值比對:
+// NFKC (全形英數與符號 -> 半形, 半形片假名 -> 全形), case folding, 連續空白合併成一個空白
+func Normalize(s string) string {
+	s = folder.String(norm.NFKC.String(s))
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
+
+// 正規化每個值並以逗號 (包含全形逗號) 分隔成 terms, 去除空值與重複並排序 (cache key 與比對使用相同的順序)
+func Terms(values []string) []string {
+	var terms []string
+	for _, value := range values {
+		for _, t := range strings.Split(Normalize(value), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				terms = append(terms, t)
+			}
+		}
+	}
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
@@ -0,0 +1,41 @@
+package term
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "ascii", value: "iPhone", expected: "iphone"},
+		{name: "full-width latin", value: "ｉＰｈｏｎｅ　１５", expected: "iphone 15"},
+		{name: "full-width digits", value: "２０２４", expected: "2024"},
+		{name: "half-width katakana", value: "ｹﾞｰﾑ", expected: "ゲーム"},
+		{name: "chinese", value: "美妝", expected: "美妝"},
+		{name: "mixed", value: "  Nintendo　Ｓｗｉｔｃｈ  遊戲 ", expected: "nintendo switch 遊戲"},
+		{name: "case folding", value: "STRASSE Straße", expected: "strasse strasse"},
+		{name: "empty", value: "　", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Normalize(tc.value); got != tc.expected {
+				t.Errorf("expected: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms([]string{"Switch,ＰＳ５", "switch", "美妝，保養", " , ", "ps5"})
+	expected := []string{"ps5", "switch", "保養", "美妝"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+	if got := Terms(nil); len(got) != 0 {
+		t.Errorf("expected no terms, got: %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lnfu/dcard-intern/app/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// trace 中的 service.name (也是 HTTP server span 的 server name)
+const ServiceName = "dcard-intern"
+
+// instrumentation scope (cache 與 store 的 spans)
+const scope = "github.com/lnfu/dcard-intern/app"
+
+// 目前的 TracerProvider 的 tracer (Setup 之前是 no-op)
+func Tracer() trace.Tracer {
+	return otel.Tracer(scope)
+}
+
+// 設定 W3C trace context 的 propagation 與 exporter, 回傳的 shutdown 會送出還沒 export 的 spans
+// exporter 為 none 時不產生 spans, 但仍然傳遞 client 帶來的 traceparent
+func Setup(ctx context.Context, conf config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch conf.Exporter {
+	case config.TracingNone:
+		return func(context.Context) error { return nil }, nil
+	case config.TracingStdout:
+		var err error
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+	case config.TracingOTLP:
+		var options []otlptracehttp.Option
+		// 沒有設定時使用 OTEL_EXPORTER_OTLP_ENDPOINT (預設 http://localhost:4318)
+		if conf.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(conf.Endpoint))
+		}
+		var err error
+		exporter, err = otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", conf.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+		// client 帶來的 traceparent 已經決定是否 sample 時依照它的決定
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// 記錄 span 的錯誤 (err 為 nil 時不做任何事)
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/lnfu/dcard-intern/app/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	testCases := []struct {
+		name     string
+		conf     config.Tracing
+		hasError bool
+	}{
+		{name: "none", conf: config.Tracing{Exporter: config.TracingNone, SampleRatio: 1}},
+		{name: "stdout", conf: config.Tracing{Exporter: config.TracingStdout, SampleRatio: 1}},
+		{name: "otlp", conf: config.Tracing{Exporter: config.TracingOTLP, Endpoint: "http://localhost:4318", SampleRatio: 0.5}},
+		{name: "unknown exporter", conf: config.Tracing{Exporter: "jaeger"}, hasError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tc.conf)
+			if tc.hasError {
+				if err == nil {
+					t.Errorf("expected error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// 沒有 spans 時 shutdown 不會連線到 collector
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// exporter 為 none 時仍然傳遞 W3C traceparent
+func TestSetup_propagation(t *testing.T) {
+	if _, err := Setup(context.Background(), config.Tracing{Exporter: config.TracingNone}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	header := http.Header{"Traceparent": []string{traceparent}}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	injected := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(injected))
+	if got := injected.Get("traceparent"); got != traceparent {
+		t.Errorf("expected: %s, got: %s", traceparent, got)
+	}
+}
@@ -0,0 +1,127 @@
+package useragent
+
+import (
+	"strings"
+)
+
+// platform (與 platform table 的 name 相同)
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+)
+
+// 裝置類型
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+	DeviceTV      = "tv"
+	DeviceBot     = "bot"
+)
+
+// User-Agent 推測的結果, 無法判斷的欄位為空
+type Device struct {
+	Platform string
+	Class    string
+}
+
+// 爬蟲與 HTTP 工具 (不推測 platform)
+var botTokens = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headlesschrome"}
+
+// 名稱以 bot/crawler/spider 結尾的 product token (Googlebot/2.1, bingbot/2.0, Baiduspider/2.0)
+var botProductSuffixes = []string{"bot/", "crawler/", "spider/"}
+
+var tvTokens = []string{"smart-tv", "smarttv", "tizen", "web0s", "webos", "appletv", "crkey", "bravia", "hbbtv"}
+
+var iosTokens = []string{"iphone", "ipad", "ipod", "ios", "cfnetwork/", "darwin/"}
+
+var desktopTokens = []string{"windows", "macintosh", "x11", "cros", "linux"}
+
+// 依 User-Agent 推測 platform 與裝置類型:
+// app 的 HTTP client (okhttp, CFNetwork) 與 WebView 是 android/ios, 一般瀏覽器 (包含手機瀏覽器) 是 web.
+// token 只在前後不是英文字母時才算 (CUBOT 不是 bot, Kiosk 不是 iOS)
+func Parse(userAgent string) Device {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return Device{}
+	}
+	if hasAnyToken(ua, botTokens) || containsAny(ua, botProductSuffixes) {
+		return Device{Class: DeviceBot}
+	}
+	if hasAnyToken(ua, tvTokens) {
+		return Device{Class: DeviceTV}
+	}
+
+	browser := strings.HasPrefix(ua, "mozilla/")
+	switch {
+	case hasToken(ua, "android"):
+		device := Device{Platform: PlatformWeb, Class: DeviceMobile}
+		// Android 平板的瀏覽器沒有 "Mobile"
+		if browser && !hasToken(ua, "mobile") {
+			device.Class = DeviceTablet
+		}
+		// 不是瀏覽器的 UA 是 app, "; wv)" 是 app 內的 WebView
+		if !browser || strings.Contains(ua, "; wv)") {
+			device.Platform = PlatformAndroid
+		}
+		return device
+
+	case hasToken(ua, "okhttp/") || strings.HasPrefix(ua, "dalvik/"):
+		return Device{Platform: PlatformAndroid, Class: DeviceMobile}
+
+	case hasAnyToken(ua, iosTokens) && !hasToken(ua, "macintosh"):
+		device := Device{Platform: PlatformWeb, Class: DeviceMobile}
+		if hasToken(ua, "ipad") {
+			device.Class = DeviceTablet
+		}
+		// WKWebView 的 UA 沒有 "Safari/" (其他 iOS 瀏覽器都有)
+		if !browser || !hasToken(ua, "safari/") {
+			device.Platform = PlatformIOS
+		}
+		return device
+
+	case browser && hasAnyToken(ua, desktopTokens):
+		return Device{Platform: PlatformWeb, Class: DeviceDesktop}
+	}
+	return Device{}
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyToken(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if hasToken(s, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// s 中有前後都不是英文字母的 token (數字與符號算邊界, e.g. "AppleTV11,1", "X11;")
+func hasToken(s string, token string) bool {
+	for offset := 0; offset+len(token) <= len(s); {
+		i := strings.Index(s[offset:], token)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(token)
+		if (start == 0 || !isLetter(s[start-1])) && (end == len(s) || !isLetter(s[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
@@ -0,0 +1,60 @@
+package useragent
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name      string
+		userAgent string
+		expected  Device
+	}{
+		// web (瀏覽器)
+		{name: "chrome windows", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36", expected: Device{PlatformWeb, DeviceDesktop}},
+		{name: "safari macos", userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15", expected: Device{PlatformWeb, DeviceDesktop}},
+		{name: "firefox linux", userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0", expected: Device{PlatformWeb, DeviceDesktop}},
+		{name: "edge windows", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36 Edg/123.0.2420.81", expected: Device{PlatformWeb, DeviceDesktop}},
+		{name: "chromebook", userAgent: "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36", expected: Device{PlatformWeb, DeviceDesktop}},
+		{name: "safari iphone", userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", expected: Device{PlatformWeb, DeviceMobile}},
+		{name: "chrome iphone", userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/123.0.6312.52 Mobile/15E148 Safari/604.1", expected: Device{PlatformWeb, DeviceMobile}},
+		{name: "safari ipad", userAgent: "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", expected: Device{PlatformWeb, DeviceTablet}},
+		{name: "chrome android", userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.6312.40 Mobile Safari/537.36", expected: Device{PlatformWeb, DeviceMobile}},
+		{name: "samsung browser", userAgent: "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36", expected: Device{PlatformWeb, DeviceMobile}},
+		{name: "chrome android tablet", userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36", expected: Device{PlatformWeb, DeviceTablet}},
+
+		// app
+		{name: "android webview", userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240205.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/123.0.6312.40 Mobile Safari/537.36", expected: Device{PlatformAndroid, DeviceMobile}},
+		{name: "android app", userAgent: "Dcard/5.32.0 (Android 14; Pixel 7)", expected: Device{PlatformAndroid, DeviceMobile}},
+		{name: "okhttp", userAgent: "okhttp/4.12.0", expected: Device{PlatformAndroid, DeviceMobile}},
+		{name: "dalvik", userAgent: "Dalvik/2.1.0 (Linux; U; Android 14; Pixel 7 Build/UQ1A.240205.004)", expected: Device{PlatformAndroid, DeviceMobile}},
+		{name: "ios wkwebview", userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148", expected: Device{PlatformIOS, DeviceMobile}},
+		{name: "ios app", userAgent: "Dcard/5.32.0 (iPhone; iOS 17.4; Scale/3.00)", expected: Device{PlatformIOS, DeviceMobile}},
+		{name: "ipad app", userAgent: "Dcard/5.32.0 (iPad; iOS 17.4; Scale/2.00)", expected: Device{PlatformIOS, DeviceTablet}},
+		{name: "cfnetwork", userAgent: "Dcard/1 CFNetwork/1494.0.7 Darwin/23.4.0", expected: Device{PlatformIOS, DeviceMobile}},
+
+		// 不推測 platform
+		{name: "googlebot", userAgent: "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", expected: Device{Class: DeviceBot}},
+		{name: "bingbot", userAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", expected: Device{Class: DeviceBot}},
+		{name: "curl", userAgent: "curl/8.4.0", expected: Device{Class: DeviceBot}},
+		{name: "go client", userAgent: "Go-http-client/1.1", expected: Device{Class: DeviceBot}},
+		{name: "smart tv", userAgent: "Mozilla/5.0 (SMART-TV; Linux; Tizen 7.0) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/5.0 Chrome/94.0.4606.31 TV Safari/537.36", expected: Device{Class: DeviceTV}},
+		{name: "chromecast", userAgent: "Mozilla/5.0 (X11; Linux armv7l) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 CrKey/1.56.500000", expected: Device{Class: DeviceTV}},
+		{name: "baiduspider", userAgent: "Mozilla/5.0 (compatible; Baiduspider/2.0; +http://www.baidu.com/search/spider.html)", expected: Device{Class: DeviceBot}},
+		{name: "apple tv", userAgent: "AppleTV11,1/11.1", expected: Device{Class: DeviceTV}},
+		{name: "empty", userAgent: "", expected: Device{}},
+		{name: "unknown", userAgent: "SomeClient/1.0", expected: Device{}},
+
+		// token 在其他字的中間
+		{name: "cubot phone", userAgent: "Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Mobile Safari/537.36", expected: Device{PlatformWeb, DeviceMobile}},
+		{name: "kiosk client", userAgent: "KioskBrowser/2.0 (Windows NT 10.0)", expected: Device{}},
+		{name: "robotics app", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) RoboticsStudio/3.1", expected: Device{PlatformWeb, DeviceDesktop}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Parse(tc.userAgent); got != tc.expected {
+				t.Errorf("expected: %+v, got: %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"database/sql"
+	"time"
 )
 
 func NullInt32FromInt32Pointer(int32_p *int32) sql.NullInt32 {
@@ -17,3 +18,17 @@ func NullStringFromStringPointer(string_p *string) sql.NullString {
 	}
 	return sql.NullString{String: (*string_p), Valid: true}
 }
+
+func Int32PointerFromNullInt32(nullInt32 sql.NullInt32) *int32 {
+	if !nullInt32.Valid {
+		return nil
+	}
+	return &nullInt32.Int32
+}
+
+func NullTimeFromTimePointer(time_p *time.Time) sql.NullTime {
+	if time_p == nil {
+		return sql.NullTime{Time: time.Time{}, Valid: false}
+	}
+	return sql.NullTime{Time: (*time_p), Valid: true}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"database/sql"
 	"testing"
+	"time"
 )
 
 func TestNullInt32FromInt32Pointer(t *testing.T) {
@@ -78,3 +79,39 @@ func TestNullStringFromStringPointer(t *testing.T) {
 		t.Errorf("Expected %+v, got %+v", expected, got)
 	}
 }
+
+func TestInt32PointerFromNullInt32(t *testing.T) {
+	// Test case: invalid NullInt32 should return nil
+	if got := Int32PointerFromNullInt32(sql.NullInt32{Int32: 42, Valid: false}); got != nil {
+		t.Errorf("Expected nil, got %v", *got)
+	}
+
+	// Test case: valid NullInt32 should return pointer to the value
+	got := Int32PointerFromNullInt32(sql.NullInt32{Int32: 42, Valid: true})
+	if got == nil || *got != 42 {
+		t.Errorf("Expected 42, got %v", got)
+	}
+
+	// Test case: valid NullInt32 with zero value should return pointer to zero
+	got = Int32PointerFromNullInt32(sql.NullInt32{Int32: 0, Valid: true})
+	if got == nil || *got != 0 {
+		t.Errorf("Expected 0, got %v", got)
+	}
+}
+
+func TestNullTimeFromTimePointer(t *testing.T) {
+	// Test case: nil pointer should return NullTime with Valid=false
+	got := NullTimeFromTimePointer(nil)
+	expected := sql.NullTime{Time: time.Time{}, Valid: false}
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// Test case: valid pointer should return NullTime with Valid=true
+	input := time.Date(2023, 12, 10, 3, 0, 0, 0, time.UTC)
+	got = NullTimeFromTimePointer(&input)
+	expected = sql.NullTime{Time: input, Valid: true}
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
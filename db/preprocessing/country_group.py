@@ -0,0 +1,17 @@
+import csv
+
+# country_group.csv: code, name, 以空白分隔的 country codes
+def process_csv(file_path):
+    with open(file_path, 'r', newline='') as file:
+        rows = [row for row in csv.reader(file) if len(row) >= 3]
+
+    print('INSERT INTO\n    country_group (code, name, builtin)\nVALUES')
+    print(',\n'.join(f"    ('{row[0]}', '{row[1]}', true)" for row in rows) + ';')
+    for row in rows:
+        codes = ', '.join(f"'{code}'" for code in row[2].split())
+        print()
+        print('INSERT INTO\n    country_group_member (country_group_id, country_id)')
+        print(f"SELECT country_group.id,\n    country.id\nFROM country_group\n    JOIN country ON country.code IN ({codes})\nWHERE country_group.code = '{row[0]}';")
+
+file_path = 'country_group.csv'
+process_csv(file_path)
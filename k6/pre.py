@@ -2,12 +2,11 @@ import http.client
 import json
 from random import randint
 from datetime import datetime, timedelta
-from time import sleep
 
 hostname = "localhost"
 port = 8080
-path = "/api/v1/ad"
-headers = {"Content-Type": "application/json"}
+path = "/api/v1/ads:bulk"
+headers = {"Content-Type": "application/x-ndjson"}
 
 
 def random_int(min, max):
@@ -27,8 +26,7 @@ platform_sets = [
     ["android", "ios", "web"],
 ]
 
-connection = http.client.HTTPConnection(hostname, port)
-
+lines = []
 for i in range(1000):
     age_start = random_int(1, 100)
     now = datetime.now()
@@ -48,11 +46,11 @@ for i in range(1000):
             }
         ],
     }
+    lines.append(json.dumps(postData))
 
-    connection.request("POST", path, json.dumps(postData), headers)
-    response = connection.getresponse()
-    data = response.read().decode()
-    print(data)
-    sleep(0.1)
-
+connection = http.client.HTTPConnection(hostname, port)
+connection.request("POST", path, "\n".join(lines), headers)
+response = connection.getresponse()
+data = json.loads(response.read().decode())
+print({key: data[key] for key in ("total", "created", "invalid", "failed")})
 connection.close()